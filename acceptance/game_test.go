@@ -43,7 +43,8 @@ func TestUnknownCommand(t *testing.T) {
 
 	output := buf.String()
 
-	expected := "\r\nUnknown command: X\r\nAvailable commands: C (setup), E (reverse), P (print), Q (quit), 0-7 (move)\n"
+	expected := "\r\nUnknown command: X\r\nAvailable commands: C (setup), E (reverse), P (print), 0-7 (type the squares of a move), " +
+		"Enter (move), L (list), S (eval), H (hint), A (analyze), T (exchange), M (engine), Q (quit), : (command line), ? (help)\r\n"
 
 	assert.Equal(t, expected, output, "Unknown command should show error message")
 }
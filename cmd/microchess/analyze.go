@@ -0,0 +1,78 @@
+// ABOUTME: This file implements the analyze subcommand: the best moves of one position.
+// ABOUTME: The position is a FEN or the end of a PGN game; results are text or the batch JSON.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// runAnalyze is the analyze subcommand.
+func runAnalyze(args []string) int {
+	fs := newFlagSet("analyze", "[flags] FEN|FILE.pgn",
+		"Rank the best moves of a position, given as FEN or as a PGN file (the position at the\n"+
+			"end of its first game), with their scores and principal variations in SAN.")
+	engine := addEngineFlags(fs, "alphabeta", "strategy")
+	jsonOut := fs.Bool("json", false, "print the result as the JSON object of the batch analyze command")
+	if err := fs.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	game := microchess.NewGame(os.Stdout)
+	if _, err := engine.setup(game); err != nil {
+		return fail(err)
+	}
+	if err := setPosition(game, strings.Join(fs.Args(), " ")); err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result := game.BatchCommand(ctx, "analyze")
+	if *jsonOut {
+		_ = json.NewEncoder(os.Stdout).Encode(result)
+		if !result.OK {
+			return 1
+		}
+		return 0
+	}
+	if !result.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", result.Error)
+		return 1
+	}
+
+	fmt.Printf("Position: %s (%s to move)\n", result.FEN, result.Side)
+	if len(result.Lines) == 0 {
+		fmt.Println("No legal moves")
+		return 0
+	}
+	fmt.Printf("Engine: %s depth %d nodes %d time %dms\n", result.Engine, result.Depth, result.Nodes, result.TimeMS)
+	for i, l := range result.Lines {
+		fmt.Printf("%2d. %-7s %6d  %s\n", i+1, l.Move.SAN, l.Score, strings.Join(l.PV, " "))
+	}
+	return 0
+}
+
+// setPosition sets up a position given as FEN or as the path of a PGN
+// file. Names ending in ".pgn", existing files and arguments without a
+// slash (which every FEN placement has) are read as PGN.
+func setPosition(game *microchess.GameState, arg string) error {
+	if strings.HasSuffix(strings.ToLower(arg), ".pgn") || !strings.Contains(arg, "/") {
+		return game.LoadPGN(arg)
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return game.LoadPGN(arg)
+	}
+	return game.SetFEN(arg)
+}
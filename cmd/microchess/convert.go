@@ -0,0 +1,65 @@
+// ABOUTME: This file implements the convert subcommand: moves and positions between notations.
+// ABOUTME: Moves in SAN or UCI are played from a FEN or PGN start, then written as FEN, PGN, SAN or UCI.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// runConvert is the convert subcommand.
+func runConvert(args []string) int {
+	fs := newFlagSet("convert", "[flags] [MOVE...]",
+		"Play the moves, in SAN (Nf3) or UCI (g1f3) notation, from the -start position or the\n"+
+			"one of -fen or -pgn, and write the result as -to says: the final position as FEN,\n"+
+			"the game as PGN, or all its moves (those of -pgn too) in SAN or UCI notation.")
+	start := addStartFlags(fs)
+	to := fs.String("to", "pgn", "output: fen, pgn, san or uci")
+	if err := fs.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	switch *to {
+	case "fen", "pgn", "san", "uci":
+	default:
+		return fail(fmt.Errorf("unknown -to %q (want fen, pgn, san or uci)", *to))
+	}
+
+	game := microchess.NewGame(os.Stdout)
+	if _, err := start.setup(game, true); err != nil {
+		return fail(err)
+	}
+	for _, text := range fs.Args() {
+		m, err := game.ParseMove(text)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		game.PlayMove(m)
+	}
+
+	var moves []string
+	for _, m := range game.Record() {
+		switch *to {
+		case "san":
+			moves = append(moves, m.SAN)
+		case "uci":
+			moves = append(moves, m.From.String()+m.To.String())
+		}
+	}
+	switch *to {
+	case "fen":
+		fmt.Println(game.FEN())
+	case "pgn":
+		if err := game.WritePGN(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Println(strings.Join(moves, " "))
+	}
+	return 0
+}
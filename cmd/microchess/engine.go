@@ -0,0 +1,166 @@
+// ABOUTME: This file holds the engine flags shared by the subcommands that think about positions.
+// ABOUTME: They select the engine, the evaluator, the evaluation profile and the search options.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/matteo/microchess-go/pkg/search"
+)
+
+// engineFlags are the flags of play, analyze, uci and serve.
+type engineFlags struct {
+	opts    search.Options
+	engine  string
+	eval    string
+	profile string
+	multiPV int
+}
+
+// addEngineFlags declares the engine flags on fs; engine and eval are the
+// defaults of -engine and -eval.
+func addEngineFlags(fs *flag.FlagSet, engine, eval string) *engineFlags {
+	// Engine options use the same names and ranges as the UCI options
+	f := &engineFlags{opts: search.DefaultOptions()}
+	fs.IntVar(&f.opts.HashMB, "hash", f.opts.HashMB, "transposition table size in MB (UCI option Hash)")
+	fs.IntVar(&f.opts.Depth, "depth", f.opts.Depth, "alphabeta search depth in plies")
+	fs.IntVar(&f.opts.Threads, "threads", f.opts.Threads, "search threads (UCI option Threads); the faithful engine scores its root moves on as many")
+	fs.StringVar(&f.engine, "engine", engine, "engine: faithful or alphabeta")
+	fs.StringVar(&f.eval, "eval", eval, "evaluator for S, H and the search: strategy, material, pst or composite")
+	fs.StringVar(&f.profile, "profile", "", "evaluation profile (YAML or JSON) with piece values and STRATGY weights; default is the 1976 one")
+	fs.IntVar(&f.multiPV, "multipv", microchess.DefaultMultiPV, "number of moves ranked by analysis")
+	return f
+}
+
+// setup checks the flags, loads the profile and installs the evaluator
+// and both engines on game, the chosen one first. It returns the
+// alphabeta engine, whose options the UCI front end changes.
+func (f *engineFlags) setup(game *microchess.GameState) (*search.Engine, error) {
+	if err := f.opts.Validate(); err != nil {
+		return nil, err
+	}
+	if f.multiPV < 1 || f.multiPV > search.MaxMultiPV {
+		return nil, fmt.Errorf("-multipv %d out of range [1, %d]", f.multiPV, search.MaxMultiPV)
+	}
+	if f.profile != "" {
+		profile, err := microchess.LoadProfile(f.profile)
+		if err == nil {
+			err = microchess.UseProfile(profile)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	faithfulEval, searchEval, err := evaluators(f.eval)
+	if err != nil {
+		return nil, err
+	}
+	game.SetEvaluator(faithfulEval)
+	game.SetMultiPV(f.multiPV)
+
+	// Both engines play on the same GameState; the first one is active
+	alphabeta := search.New(f.opts)
+	alphabeta.Eval = searchEval
+	faithful, modern := microchess.Engine(&microchess.FaithfulEngine{Workers: f.opts.Threads}), microchess.Engine(alphabeta)
+	switch f.engine {
+	case "faithful":
+		game.SetEngines(faithful, modern)
+	case "alphabeta":
+		game.SetEngines(modern, faithful)
+	default:
+		return nil, fmt.Errorf("unknown engine %q (want faithful or alphabeta)", f.engine)
+	}
+	return alphabeta, nil
+}
+
+// evaluators returns the evaluator named on the command line in two forms:
+// as is for 'S' and the faithful engine, and antisymmetric for the search.
+func evaluators(name string) (faithful, modern microchess.Evaluator, err error) {
+	pst := microchess.MaterialPSTEvaluator{PST: microchess.DefaultPST}
+	switch name {
+	case "strategy":
+		return microchess.StrategyEvaluator{}, search.Strategy, nil
+	case "material":
+		return search.Material, search.Material, nil
+	case "pst":
+		return pst, pst, nil
+	case "composite":
+		// 4 centipawns per STRATGY unit on top of material and piece-square bonuses
+		composite := func(strategy microchess.Evaluator) microchess.Evaluator {
+			return microchess.WeightedEvaluator{Terms: []microchess.WeightedTerm{
+				{Evaluator: strategy, Weight: 400},
+				{Evaluator: pst, Weight: 100},
+			}}
+		}
+		return composite(microchess.StrategyEvaluator{}), composite(search.Strategy), nil
+	}
+	return nil, nil, fmt.Errorf("unknown evaluator %q (want strategy, material, pst or composite)", name)
+}
+
+// startPositions are the positions -start names. The default is standard
+// chess, which UCI's startpos, PGN games without a FEN tag and chess GUIs
+// all assume; "1976" is the setup of the original, with the king on d1.
+var startPositions = map[string]string{
+	"standard": microchess.StandardFEN,
+	"1976":     microchess.InitialFEN,
+}
+
+// defaultStart is the -start position of every subcommand.
+const defaultStart = "standard"
+
+// addStartFlag declares -start on fs.
+func addStartFlag(fs *flag.FlagSet) *string {
+	return fs.String("start", "", `start position when no other is given: "standard" (the default) or "1976"`)
+}
+
+// startFEN returns the FEN of the -start position name ("" for the default).
+func startFEN(name string) (string, error) {
+	if name == "" {
+		name = defaultStart
+	}
+	fen, ok := startPositions[name]
+	if !ok {
+		return "", fmt.Errorf("unknown -start %q (want standard or 1976)", name)
+	}
+	return fen, nil
+}
+
+// startFlags are the flags choosing the start position.
+type startFlags struct {
+	fen, pgn string
+	start    *string
+}
+
+// addStartFlags declares -fen, -pgn and -start on fs.
+func addStartFlags(fs *flag.FlagSet) *startFlags {
+	f := &startFlags{}
+	fs.StringVar(&f.fen, "fen", "", "start from this position (FEN)")
+	fs.StringVar(&f.pgn, "pgn", "", "start from the end of the first game in this PGN file")
+	f.start = addStartFlag(fs)
+	return f
+}
+
+// setup sets up the start position on game: that of -fen or -pgn, else
+// that of -start when it is given or always is set. It reports whether
+// -fen or -pgn gave one.
+func (f *startFlags) setup(game *microchess.GameState, always bool) (bool, error) {
+	switch {
+	case f.fen != "" && f.pgn != "":
+		return false, fmt.Errorf("-fen and -pgn cannot be combined")
+	case f.fen != "":
+		return true, game.SetFEN(f.fen)
+	case f.pgn != "":
+		return true, game.LoadPGN(f.pgn)
+	case always || *f.start != "":
+		fen, err := startFEN(*f.start)
+		if err != nil {
+			return false, err
+		}
+		return false, game.SetFEN(fen)
+	}
+	return false, nil
+}
@@ -1,76 +1,111 @@
-// ABOUTME: This is the main CLI entry point for MicroChess.
-// ABOUTME: It provides a text-based interface similar to the original 1976 serial terminal version.
+// ABOUTME: This is the main CLI entry point for MicroChess: one binary with subcommands.
+// ABOUTME: play is the 1976-style interface; analyze, perft, uci, serve and convert share the engine packages.
 
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
-
-	"github.com/matteo/microchess-go/pkg/microchess"
-	"golang.org/x/term"
+	"strings"
 )
 
-func main() {
-	game := microchess.NewGame(os.Stdout)
-	game.Display()
+// subcommand is one of the programs in the binary.
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string) int // Returns the exit status
+}
 
-	// Check if stdin is a terminal or a pipe
-	fd := int(os.Stdin.Fd())
-	isTerminal := term.IsTerminal(fd)
+// subcommands lists the subcommands in the order help shows them.
+var subcommands []subcommand
 
-	if isTerminal {
-		// Terminal mode: use raw mode for character-by-character input
-		// This matches the original 1976 MicroChess serial terminal behavior
-		oldState, err := term.MakeRaw(fd)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Could not set raw terminal mode: %v\n", err)
-			os.Exit(1)
-		}
-		defer func() {
-			_ = term.Restore(fd, oldState)
-		}()
+func init() {
+	subcommands = []subcommand{
+		{"play", "play in the terminal, as in 1976 (the default)", runPlay},
+		{"analyze", "rank the best moves of a FEN position or of the end of a PGN game", runAnalyze},
+		{"perft", "count the positions of the legal move tree", runPerft},
+		{"uci", "talk UCI to a chess GUI on standard input and output", runUCI},
+		{"serve", "run batch scripts sent over HTTP", runServe},
+		{"convert", "convert moves and positions between SAN, UCI, FEN and PGN", runConvert},
+		{"help", "describe a subcommand", runHelp},
+	}
+}
 
-		// Read one character at a time, just like the original assembly (syskin routine)
-		buf := make([]byte, 1)
-		for {
-			fmt.Print("? ")
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-			// Blocking read of single character
-			_, err := os.Stdin.Read(buf)
-			if err != nil {
-				fmt.Println("\r\nError reading input:", err)
-				continue
-			}
+// run dispatches to a subcommand. Without one, or when the first argument
+// is a flag, the program plays, as it did before it had subcommands.
+func run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runPlay(args)
+	}
+	if c, ok := subcommandByName(args[0]); ok {
+		return c.run(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "Error: unknown subcommand %q\n\n", args[0])
+	usage()
+	return 2
+}
 
-			char := buf[0]
+// subcommandByName returns the subcommand called name.
+func subcommandByName(name string) (subcommand, bool) {
+	for _, c := range subcommands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return subcommand{}, false
+}
 
-			// Echo the character (original does this via syschout)
-			fmt.Printf("%c", char)
+// usage lists the subcommands on standard error.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: microchess [SUBCOMMAND] [flags] [arguments]\n\nSubcommands:\n")
+	for _, c := range subcommands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun \"microchess help SUBCOMMAND\" or \"microchess SUBCOMMAND -h\" for its flags.\n")
+}
 
-			// Handle the character
-			if !game.HandleCharacter(char) {
-				fmt.Println("\r") // Clean newline before exit
-				return
-			}
-		}
-	} else {
-		// Piped input mode: read character-by-character but don't use raw mode
-		// This allows testing with printf 'CPQ' | ./microchess
-		buf := make([]byte, 1)
-		for {
-			_, err := os.Stdin.Read(buf)
-			if err != nil {
-				// EOF or error - exit gracefully
-				return
-			}
+// runHelp is the help subcommand.
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 0
+	}
+	c, ok := subcommandByName(args[0])
+	if !ok || c.name == "help" {
+		usage()
+		return 2
+	}
+	return c.run([]string{"-h"})
+}
+
+// newFlagSet returns the flag set of a subcommand, whose usage shows its
+// synopsis and description before the flags.
+func newFlagSet(name, synopsis, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: microchess %s %s\n\n%s\n\nFlags:\n", name, synopsis, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-			char := buf[0]
+// fail prints err and returns the exit status of a usage error.
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return 2
+}
 
-			// Handle the character (no echo needed for piped input)
-			if !game.HandleCharacter(char) {
-				return
-			}
-		}
+// parseStatus is the exit status after fs.Parse failed: 0 when help was
+// asked for with -h, 2 otherwise (the flag package printed the error).
+func parseStatus(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
 	}
+	return 2
 }
@@ -0,0 +1,55 @@
+// ABOUTME: This file implements the perft subcommand: counting the legal move tree to a depth.
+// ABOUTME: -divide prints the count below each root move, to compare with other move generators.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// runPerft is the perft subcommand.
+func runPerft(args []string) int {
+	fs := newFlagSet("perft", "[flags] DEPTH",
+		"Count the positions reached by every sequence of DEPTH legal moves, from the -start\n"+
+			"position unless -fen or -pgn gives another. MicroChess has no castling, en passant\n"+
+			"or promotion, so the counts differ from standard chess once those can happen.")
+	start := addStartFlags(fs)
+	divide := fs.Bool("divide", false, "print the count below each root move too")
+	if err := fs.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	depth, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || depth < 1 {
+		return fail(fmt.Errorf("depth %q: want a number of plies from 1", fs.Arg(0)))
+	}
+
+	game := microchess.NewGame(os.Stdout)
+	if _, err := start.setup(game, true); err != nil {
+		return fail(err)
+	}
+
+	begin := time.Now()
+	var nodes uint64
+	if *divide {
+		for _, c := range game.PerftDivide(depth) {
+			fmt.Printf("%s: %d\n", game.UCI(c.Move), c.Nodes)
+			nodes += c.Nodes
+		}
+		fmt.Println()
+	} else {
+		nodes = game.Perft(depth)
+	}
+	elapsed := time.Since(begin)
+	fmt.Printf("Nodes: %d\n", nodes)
+	fmt.Printf("Time: %dms (%.0f nodes/s)\n", elapsed.Milliseconds(), float64(nodes)/max(elapsed.Seconds(), 1e-9))
+	return 0
+}
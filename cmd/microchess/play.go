@@ -0,0 +1,255 @@
+// ABOUTME: This file implements the play subcommand, the interactive MicroChess program.
+// ABOUTME: It provides a text-based interface similar to the original 1976 serial terminal version.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/matteo/microchess-go/pkg/tui"
+	"golang.org/x/term"
+)
+
+// runPlay is the play subcommand: the interactive program, as in 1976.
+func runPlay(args []string) int {
+	fs := newFlagSet("play", "[flags] [BATCH COMMAND...]",
+		"Play in the terminal: the 1976 serial terminal interface with the single-key commands,\n"+
+			"or the -tui, -kim and -batch variants.")
+	engine := addEngineFlags(fs, "faithful", "strategy")
+	start := addStartFlags(fs)
+	tuiMode := fs.Bool("tui", false, "full-screen terminal UI (board, moves, output and status panes)")
+	kimMode := fs.Bool("kim", false, "KIM-1 mode: 7-segment LED display and keypad keys (see README)")
+	rendererName := fs.String("renderer", microchess.RendererNames[0], "board display: "+strings.Join(microchess.RendererNames, " or "))
+	tracePath := fs.String("trace", "", "record the search tree of H and A into this file: Graphviz DOT (.dot, .gv) or JSON (.json)")
+	traceDepth := fs.Int("trace-depth", 3, "plies below the root recorded by -trace (0: no limit)")
+	traceNodes := fs.Int("trace-nodes", 5000, "nodes recorded by -trace (0: no limit)")
+	levelSpec := fs.String("level", "", `time control for the H command: "5s" per move, or "<moves> <minutes> <increment>" like xboard`)
+	blind := fs.Bool("blind", false, "blindfold and screen reader mode: no board, every move described in words (see README)")
+	hotSeat := fs.Bool("hotseat", false, "two players take turns: legal moves only, the board turns after each (see README)")
+	batchMode := fs.Bool("batch", false, "run the commands given as arguments (or read from stdin) and print JSON results, one per line (see README)")
+	if err := fs.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	game := microchess.NewGame(os.Stdout)
+	if _, err := engine.setup(game); err != nil {
+		return fail(err)
+	}
+	renderer, err := microchess.RendererByName(*rendererName)
+	if err != nil {
+		return fail(err)
+	}
+	if *kimMode {
+		if *tuiMode {
+			return fail(fmt.Errorf("-kim and -tui cannot be combined"))
+		}
+		renderer = microchess.KIMRenderer{}
+	}
+	if u, ok := renderer.(microchess.UnicodeRenderer); ok {
+		u.Color = colorTerminal()
+		renderer = u
+	}
+	game.SetRenderer(renderer)
+	game.SetHotSeat(*hotSeat)
+	if *tracePath != "" {
+		game.SetTrace(microchess.NewTrace(*traceDepth, *traceNodes), *tracePath)
+	}
+
+	if *levelSpec != "" {
+		level, err := microchess.ParseLevel(*levelSpec)
+		if err != nil {
+			return fail(err)
+		}
+		game.SetLevel(level)
+	}
+
+	// Batch mode needs a position; the terminal starts empty, as in 1976,
+	// until C (or -start) sets up the board
+	if _, err := start.setup(game, *batchMode); err != nil {
+		return fail(err)
+	}
+	// After the start position, so that the moves of -pgn are not read out
+	game.SetAnnounce(*blind)
+	game.SetBoardHidden(*blind)
+
+	if *batchMode {
+		if *tuiMode || *kimMode {
+			return fail(fmt.Errorf("-batch cannot be combined with -tui or -kim"))
+		}
+		return runBatch(game, fs.Args())
+	}
+
+	if *tuiMode {
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return fail(fmt.Errorf("-tui needs a terminal"))
+		}
+	} else {
+		game.Display()
+	}
+
+	// Read stdin on its own goroutine, so that keys (Ctrl-C in particular)
+	// are seen while a command such as H is still thinking
+	input := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				close(input) // EOF or error - exit gracefully
+				return
+			}
+			input <- buf[0]
+		}
+	}()
+
+	// In piped mode Ctrl-C arrives as SIGINT; in raw mode as the byte 0x03
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	// Check if stdin is a terminal or a pipe
+	fd := int(os.Stdin.Fd())
+	isTerminal := term.IsTerminal(fd)
+
+	if isTerminal {
+		// Terminal mode: use raw mode for character-by-character input
+		// This matches the original 1976 MicroChess serial terminal behavior
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not set raw terminal mode: %v\n", err)
+			return 1
+		}
+		defer func() {
+			_ = term.Restore(fd, oldState)
+		}()
+
+		// Print a dot for each progress report, like the original did while thinking
+		game.SetObserver(func(microchess.Progress) { fmt.Print(".") })
+	}
+
+	if *tuiMode {
+		if err := tui.New(game, os.Stdout).Run(input); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	var pending []byte // Keys typed while a command was running
+	for {
+		_, editing := game.EditingLine()
+		if isTerminal && !editing {
+			fmt.Print("? ")
+		}
+
+		var char byte
+		if len(pending) > 0 {
+			char, pending = pending[0], pending[1:]
+		} else {
+			select {
+			case c, ok := <-input:
+				if !ok {
+					return 0
+				}
+				char = c
+			case <-interrupt:
+				// Nothing to abort: Ctrl-C quits as usual
+				fmt.Println("\r")
+				return 0
+			}
+		}
+
+		if isTerminal && !editing {
+			// Echo the character (original does this via syschout)
+			fmt.Printf("%c", char)
+		}
+
+		if *kimMode {
+			// Only the keypad keys do something, as on the KIM-1
+			cmd, ok := microchess.KIMKey(char)
+			if !ok {
+				if isTerminal {
+					fmt.Print("\r\n")
+				}
+				continue
+			}
+			char = cmd
+		}
+
+		// Handle the character
+		if !runCommand(game, char, input, interrupt, &pending) {
+			if isTerminal {
+				fmt.Println("\r") // Clean newline before exit
+			}
+			return 0
+		}
+
+		// The ':' command line is redrawn after every key it gets
+		if text, editing := game.EditingLine(); isTerminal && editing {
+			fmt.Printf("\r\x1b[K? :%s", text)
+		}
+	}
+}
+
+// runBatch runs the batch commands given as arguments, or read from stdin
+// when there are none, and returns the exit status: 1 when a command
+// failed. Ctrl-C stops the script.
+func runBatch(game *microchess.GameState, args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var script io.Reader = os.Stdin
+	if len(args) > 0 {
+		script = strings.NewReader(strings.Join(args, "\n"))
+	}
+	if err := game.RunBatch(ctx, script, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// colorTerminal reports whether standard output can show ANSI colours:
+// it must be a terminal, TERM must not be "dumb", and NO_COLOR
+// (https://no-color.org) must be unset or empty.
+func colorTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb" && os.Getenv("NO_COLOR") == ""
+}
+
+// runCommand handles one character on a separate goroutine and cancels it
+// when Ctrl-C is pressed (byte 0x03 in raw mode, SIGINT otherwise).
+// Other keys typed in the meantime are queued in pending.
+func runCommand(game *microchess.GameState, char byte, input <-chan byte, interrupt <-chan os.Signal, pending *[]byte) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan bool, 1)
+	go func() {
+		done <- game.HandleCharacterContext(ctx, char)
+	}()
+
+	for {
+		select {
+		case ok := <-done:
+			return ok
+		case c, open := <-input:
+			switch {
+			case !open:
+				input = nil // EOF: let the command finish, the main loop exits next
+			case c == ctrlC:
+				cancel()
+			default:
+				*pending = append(*pending, c)
+			}
+		case <-interrupt:
+			cancel()
+		}
+	}
+}
+
+// ctrlC is the byte a raw-mode terminal sends for Ctrl-C.
+const ctrlC = 0x03
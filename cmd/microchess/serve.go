@@ -0,0 +1,98 @@
+// ABOUTME: This file implements the serve subcommand: batch scripts run over HTTP.
+// ABOUTME: POST /batch takes a script and answers with the JSON lines of the batch mode.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// maxScript bounds the size of a script posted to /batch.
+const maxScript = 1 << 20
+
+// runServe is the serve subcommand.
+func runServe(args []string) int {
+	fs := newFlagSet("serve", "[flags]",
+		"Serve batch mode over HTTP. POST a script (the batch commands of play -batch, one per\n"+
+			"line) to /batch; the answer has one JSON object per command. Each request starts from\n"+
+			"the position of the fen query parameter, or the -start one. Requests are served one at\n"+
+			"a time, each with a new game and engine.")
+	engine := addEngineFlags(fs, "alphabeta", "strategy")
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	start := addStartFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	// Check the flags once, before the first request
+	if _, err := engine.setup(microchess.NewGame(io.Discard)); err != nil {
+		return fail(err)
+	}
+	startpos, err := startFEN(*start)
+	if err != nil {
+		return fail(err)
+	}
+
+	s := &server{engine: engine, start: startpos}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/batch", s.batch)
+	log.Printf("MicroChess serving on http://%s/batch", *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// server runs the requests of the serve subcommand.
+type server struct {
+	mu     sync.Mutex // One search at a time
+	engine *engineFlags
+	start  string // FEN of requests without one
+}
+
+// batch handles POST /batch?fen=FEN.
+func (s *server) batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST a batch script", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxScript))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	game := microchess.NewGame(io.Discard)
+	if _, err := s.engine.setup(game); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fen := r.URL.Query().Get("fen")
+	if fen == "" {
+		fen = s.start
+	}
+	if err := game.SetFEN(fen); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if err := game.RunBatch(r.Context(), bytes.NewReader(body), w); err != nil && r.Context().Err() == nil {
+		log.Printf("batch: %v", err) // Failed commands; their results say why
+	}
+}
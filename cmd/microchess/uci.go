@@ -0,0 +1,280 @@
+// ABOUTME: This file implements the uci subcommand: the Universal Chess Interface on stdin and stdout.
+// ABOUTME: It plays the alphabeta engine, with the UCI options of pkg/search and MultiPV info lines.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/matteo/microchess-go/pkg/search"
+)
+
+// runUCI is the uci subcommand.
+func runUCI(args []string) int {
+	fs := newFlagSet("uci", "[flags]",
+		"Talk UCI on standard input and output, for chess GUIs. The engine is alphabeta; the flags\n"+
+			"give the defaults of the UCI options, which the GUI can change with setoption.\n"+
+			"Positions and moves are standard chess: MicroChess has no castling, en passant or promotion.\n"+
+			"The evaluator defaults to pst, whose scores are centipawns as UCI's \"score cp\" expects.")
+	// STRATGY scores are not centipawns: UCI reports a pawn as 100
+	engine := addEngineFlags(fs, "alphabeta", "pst")
+	start := addStartFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "multipv" {
+			engine.opts.MultiPV = engine.multiPV // UCI's MultiPV is 1 unless asked
+		}
+	})
+
+	game := microchess.NewGame(io.Discard)
+	alphabeta, err := engine.setup(game)
+	if err != nil {
+		return fail(err)
+	}
+	startpos, err := startFEN(*start)
+	if err != nil {
+		return fail(err)
+	}
+	u := &uciSession{out: os.Stdout, game: game, opts: engine.opts, engine: alphabeta, startpos: startpos}
+	if err := u.position([]string{"startpos"}); err != nil {
+		return fail(err)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if !u.command(strings.Fields(scanner.Text())) {
+			break
+		}
+	}
+	u.stop()
+	return 0
+}
+
+// uciSession is the state of a UCI conversation.
+type uciSession struct {
+	mu  sync.Mutex // Serializes output from the search goroutine
+	out io.Writer
+
+	game     *microchess.GameState
+	opts     search.Options
+	engine   *search.Engine
+	startpos string // FEN of "position startpos" (-start)
+
+	cancel context.CancelFunc // Stops the running search (nil when idle)
+	done   chan struct{}      // Closed when the running search has answered
+}
+
+// println writes one line of output.
+func (u *uciSession) println(format string, args ...any) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_, _ = fmt.Fprintf(u.out, format+"\n", args...)
+}
+
+// command handles one input line and reports whether to go on. Unknown
+// commands are ignored, as UCI asks; errors are reported as info strings.
+func (u *uciSession) command(fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	var err error
+	switch fields[0] {
+	case "uci":
+		u.println("id name MicroChess Go")
+		u.println("id author Peter Jennings, Go port by Matteo Vaccari")
+		for _, line := range search.UCIOptions() {
+			u.println("%s", line)
+		}
+		u.println("uciok")
+	case "isready":
+		u.println("readyok")
+	case "setoption":
+		u.stop()
+		err = u.setOption(fields[1:])
+	case "ucinewgame":
+		u.stop()
+		err = u.newEngine()
+	case "position":
+		u.stop()
+		err = u.position(fields[1:])
+	case "go":
+		u.stop()
+		err = u.goSearch(fields[1:])
+	case "stop":
+		u.stop()
+	case "quit":
+		return false
+	}
+	if err != nil {
+		u.println("info string error: %v", err)
+	}
+	return true
+}
+
+// setOption handles "setoption name NAME value VALUE".
+func (u *uciSession) setOption(args []string) error {
+	var name, value []string
+	target := &name
+	for _, a := range args {
+		switch a {
+		case "name":
+			target = &name
+		case "value":
+			target = &value
+		default:
+			*target = append(*target, a)
+		}
+	}
+	if err := u.opts.Set(strings.Join(name, " "), strings.Join(value, " ")); err != nil {
+		return err
+	}
+	return u.newEngine()
+}
+
+// newEngine replaces the engine with one built from the options, with an
+// empty transposition table.
+func (u *uciSession) newEngine() error {
+	if err := u.opts.Validate(); err != nil {
+		return err
+	}
+	engine := search.New(u.opts)
+	engine.Eval = u.engine.Eval
+	u.engine = engine
+	u.game.SetEngines(engine)
+	return nil
+}
+
+// position handles "position startpos|fen FEN [moves M...]".
+func (u *uciSession) position(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("position: want startpos or fen")
+	}
+	setup, moves, _ := strings.Cut(strings.Join(args, " "), "moves")
+	setup = strings.TrimSpace(setup)
+	switch {
+	case setup == "startpos":
+		setup = u.startpos
+	case strings.HasPrefix(setup, "fen "):
+		setup = strings.TrimPrefix(setup, "fen ")
+	default:
+		return fmt.Errorf("position: want startpos or fen, not %q", setup)
+	}
+	if err := u.game.SetFEN(setup); err != nil {
+		return err
+	}
+	for _, text := range strings.Fields(moves) {
+		m, err := u.game.ParseMove(text)
+		if err != nil {
+			return err
+		}
+		u.game.PlayMove(m)
+	}
+	return nil
+}
+
+// goSearch handles "go": the time control, "depth N" (a cap on the
+// iterations, with or without clocks) and "infinite" (search until "stop").
+// The search runs on its own goroutine, which prints the info lines and
+// the best move; an infinite search keeps its best move until "stop" or
+// "quit", even when it ends sooner, as UCI asks.
+func (u *uciSession) goSearch(args []string) error {
+	tc, err := microchess.ParseGo(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	u.cancel, u.done = cancel, done
+	reversed := u.game.Reversed
+	start := time.Now()
+	observe := func(p microchess.Progress) {
+		if p.Move.Piece != microchess.NoPiece {
+			u.println("info depth %d nodes %d score %s pv %s", p.Depth, p.Nodes, uciScore(p.Score), uciMove(p.Move, reversed))
+		}
+	}
+	go func() {
+		defer close(done)
+		result := u.engine.Analyze(ctx, u.game, tc, u.opts.MultiPV, observe)
+		elapsed := time.Since(start)
+		if tc.Infinite {
+			<-ctx.Done()
+		}
+		u.report(result, elapsed)
+	}()
+	return nil
+}
+
+// report prints the info line of every PV line, then the best move.
+func (u *uciSession) report(result microchess.SearchResult, elapsed time.Duration) {
+	lines := result.Lines
+	if len(lines) == 0 && result.Move.Piece != microchess.NoPiece {
+		lines = []microchess.PVLine{{Move: result.Move, Score: result.Score, PV: result.PV}}
+	}
+	ms := max(elapsed.Milliseconds(), 1)
+	nodes := result.Nodes + result.QNodes
+	for i, l := range lines {
+		u.println("info depth %d multipv %d score %s nodes %d nps %d time %d pv %s",
+			result.Depth, i+1, uciScore(l.Score), nodes, nodes*1000/uint64(ms), ms,
+			strings.Join(u.game.NamePV(l.PV, (*microchess.GameState).UCI), " "))
+	}
+	if result.Move.Piece == microchess.NoPiece {
+		u.println("bestmove 0000") // No legal move
+		return
+	}
+	pv := u.game.NamePV(result.PV, (*microchess.GameState).UCI)
+	if len(pv) > 1 {
+		u.println("bestmove %s ponder %s", pv[0], pv[1])
+		return
+	}
+	u.println("bestmove %s", u.game.UCI(result.Move))
+}
+
+// stop cancels the running search, if any, and waits for its best move.
+func (u *uciSession) stop() {
+	if u.cancel == nil {
+		return
+	}
+	u.cancel()
+	<-u.done
+	u.cancel, u.done = nil, nil
+}
+
+// uciScore writes a score as "cp N", or "mate N" in moves for a forced
+// mate (negative when the engine is mated). Mates score Mate minus the
+// plies to the mate, and the search looks at most 64 plies deep.
+func uciScore(score int) string {
+	switch {
+	case score > search.Mate-100:
+		return fmt.Sprintf("mate %d", (search.Mate-score+1)/2)
+	case score < -search.Mate+100:
+		return fmt.Sprintf("mate %d", -(search.Mate+score)/2)
+	}
+	return fmt.Sprintf("cp %d", score)
+}
+
+// uciMove writes a root move in UCI notation without reading the board,
+// which the search is changing when progress is reported.
+func uciMove(m microchess.Move, reversed bool) string {
+	from, to := m.From, m.To
+	if reversed {
+		from, to = 0x77-from, 0x77-to
+	}
+	return board.Square(from).String() + board.Square(to).String()
+}
@@ -0,0 +1,82 @@
+// ABOUTME: This is the offline tuner: it fits a MicroChess evaluation profile to labelled positions.
+// ABOUTME: It reads EPD/CSV files, writes the tuned profile and prints the error before and after.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/matteo/microchess-go/pkg/tune"
+)
+
+func main() {
+	startPath := flag.String("start", "", "profile to start from (YAML or JSON); default is the 1976 one")
+	outPath := flag.String("o", "tuned.yaml", "where to write the tuned profile (.yaml, .yml or .json)")
+	name := flag.String("name", "tuned", "name of the tuned profile")
+	passes := flag.Int("passes", 0, "stop after this many passes (0: until no parameter improves)")
+	workers := flag.Int("workers", 0, "goroutines running GNMZ (0: one per CPU)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [positions.epd|positions.csv ...]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Positions are read from standard input when no file is given.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	start := microchess.DefaultProfile()
+	if *startPath != "" {
+		var err error
+		if start, err = microchess.LoadProfile(*startPath); err != nil {
+			fail(err)
+		}
+	}
+
+	var samples []tune.Sample
+	if flag.NArg() == 0 {
+		s, err := tune.ReadSamples(os.Stdin)
+		if err != nil {
+			fail(fmt.Errorf("stdin: %w", err))
+		}
+		samples = s
+	}
+	for _, path := range flag.Args() {
+		s, err := readSamples(path)
+		if err != nil {
+			fail(err)
+		}
+		samples = append(samples, s...)
+	}
+
+	tuner := tune.Tuner{Samples: samples, Workers: *workers, MaxPasses: *passes, Log: os.Stderr}
+	report, err := tuner.Run(start)
+	if err != nil {
+		fail(err)
+	}
+	report.Tuned.Name = *name
+	if err := microchess.SaveProfile(*outPath, report.Tuned); err != nil {
+		fail(err)
+	}
+	_, _ = report.WriteTo(os.Stdout)
+	fmt.Printf("Profile %q written to %s\n", report.Tuned.Name, *outPath)
+}
+
+// readSamples reads the labelled positions of one file.
+func readSamples(path string) ([]tune.Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	samples, err := tune.ReadSamples(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(2)
+}
@@ -0,0 +1,136 @@
+// ABOUTME: This file computes attack sets using precomputed leaper tables and hyperbola quintessence.
+// ABOUTME: Sliding attacks use the o^(o-2r) trick on rank, file and diagonal masks.
+
+package bitboard
+
+import (
+	"math/bits"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+
+	// Line masks through each square, excluding the square itself.
+	rankMask     [64]Bitboard
+	fileMask     [64]Bitboard
+	diagMask     [64]Bitboard // a1-h8 direction
+	antiDiagMask [64]Bitboard // h1-a8 direction
+)
+
+func init() {
+	for index := 0; index < 64; index++ {
+		sq := SquareOf(index)
+
+		// Leapers reuse the MOVEX offsets so the tables agree with CMOVE:
+		// entries 1-8 are the king steps, 9-16 the knight jumps.
+		for moven := 1; moven <= 16; moven++ {
+			target := int16(sq) + int16(microchess.MOVEX[moven])
+			if target&0x88 != 0 {
+				continue
+			}
+			bit := Bitboard(1) << uint(Index(board.Square(target)))
+			if moven <= 8 {
+				kingAttacks[index] |= bit
+			} else {
+				knightAttacks[index] |= bit
+			}
+		}
+
+		rank, file := index/8, index%8
+		for other := 0; other < 64; other++ {
+			if other == index {
+				continue
+			}
+			r, f := other/8, other%8
+			bit := Bitboard(1) << uint(other)
+			switch {
+			case r == rank:
+				rankMask[index] |= bit
+			case f == file:
+				fileMask[index] |= bit
+			case r-f == rank-file:
+				diagMask[index] |= bit
+			case r+f == rank+file:
+				antiDiagMask[index] |= bit
+			}
+		}
+	}
+}
+
+// lineAttacks returns the squares attacked along one line by a slider on
+// index, stopping at (and including) the first blocker in each direction.
+//
+// This is hyperbola quintessence: subtracting twice the slider bit from the
+// occupancy flips every bit up to the first blocker above the slider, and
+// doing the same on the bit-reversed board handles the other direction.
+func lineAttacks(occupied Bitboard, mask Bitboard, index int) Bitboard {
+	slider := uint64(1) << uint(index)
+	o := uint64(occupied & mask)
+	forward := o - 2*slider
+	reverse := bits.Reverse64(bits.Reverse64(o) - 2*bits.Reverse64(slider))
+	return Bitboard(forward^reverse) & mask
+}
+
+// RookAttacks returns the squares a rook on index attacks given the occupancy.
+func RookAttacks(index int, occupied Bitboard) Bitboard {
+	return lineAttacks(occupied, rankMask[index], index) |
+		lineAttacks(occupied, fileMask[index], index)
+}
+
+// BishopAttacks returns the squares a bishop on index attacks given the occupancy.
+func BishopAttacks(index int, occupied Bitboard) Bitboard {
+	return lineAttacks(occupied, diagMask[index], index) |
+		lineAttacks(occupied, antiDiagMask[index], index)
+}
+
+// QueenAttacks returns the union of rook and bishop attacks.
+func QueenAttacks(index int, occupied Bitboard) Bitboard {
+	return RookAttacks(index, occupied) | BishopAttacks(index, occupied)
+}
+
+// KnightAttacks returns the squares a knight on index attacks.
+func KnightAttacks(index int) Bitboard {
+	return knightAttacks[index]
+}
+
+// KingAttacks returns the squares a king on index attacks.
+func KingAttacks(index int) Bitboard {
+	return kingAttacks[index]
+}
+
+// pawnAttacks returns the squares attacked by a pawn of the given side on index.
+// Side Board pawns capture towards rank 7 (MOVEX entries 5 and 6), BK pawns towards rank 0.
+func pawnAttacks(side microchess.Side, index int) Bitboard {
+	bit := Bitboard(1) << uint(index)
+	const notFileA, notFileH = 0xFEFEFEFEFEFEFEFE, 0x7F7F7F7F7F7F7F7F
+	if side == microchess.SideBoard {
+		return (bit&notFileH)<<9 | (bit&notFileA)<<7
+	}
+	return (bit&notFileA)>>9 | (bit&notFileH)>>7
+}
+
+// Attacked reports whether any piece of side by attacks the square at index.
+func (p *Position) Attacked(index int, by microchess.Side) bool {
+	occupied := p.occupied[0] | p.occupied[1]
+	them := &p.pieces[by]
+	if knightAttacks[index]&them[microchess.KindKnight] != 0 {
+		return true
+	}
+	if kingAttacks[index]&them[microchess.KindKing] != 0 {
+		return true
+	}
+	// A pawn of side `by` attacks index if a pawn of the other side on
+	// index would attack the pawn's square.
+	if pawnAttacks(1-by, index)&them[microchess.KindPawn] != 0 {
+		return true
+	}
+	queens := them[microchess.KindQueen]
+	if RookAttacks(index, occupied)&(them[microchess.KindRook]|queens) != 0 {
+		return true
+	}
+	return BishopAttacks(index, occupied)&(them[microchess.KindBishop]|queens) != 0
+}
@@ -0,0 +1,172 @@
+// ABOUTME: This file defines a bitboard position backend implementing microchess.Position.
+// ABOUTME: It converts losslessly to and from the Board/BK piece lists used by GameState.
+
+package bitboard
+
+import (
+	"math/bits"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// Bitboard is a set of squares, one bit per square.
+// Bit n corresponds to rank n/8 and file n%8, i.e. bit 0 is $00 and bit 63 is $77.
+type Bitboard uint64
+
+// Index converts a 0x88 square to a bit index (0-63).
+// The caller must make sure the square is valid.
+func Index(sq board.Square) int {
+	return sq.Rank()*8 + sq.File()
+}
+
+// SquareOf converts a bit index (0-63) back to a 0x88 square.
+func SquareOf(index int) board.Square {
+	return board.Square((index/8)<<4 | index%8)
+}
+
+// Has reports whether the square at bit index is in the set.
+func (b Bitboard) Has(index int) bool {
+	return b&(1<<uint(index)) != 0
+}
+
+// Count returns the number of squares in the set.
+func (b Bitboard) Count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// empty marks a mailbox entry without a piece.
+const empty uint8 = 0xFF
+
+// Position is a bitboard representation of a MicroChess position.
+//
+// Like GameState it is always seen from the side to move: Side Board pawns
+// advance towards rank 7, and squares are stored exactly as in the Board/BK
+// arrays (already transformed by REVERSE when the REV flag is set).
+//
+// Besides the occupancy sets, Position keeps a mailbox with the 0-31 slot of
+// the piece on each square (Board pieces 0-15, BK pieces 16-31) and the
+// off-board value of every piece which is not on the board. That extra
+// bookkeeping is what makes the round trip with GameState lossless: piece
+// identity matters to the faithful engine (GNM walks pieces 15 down to 0).
+type Position struct {
+	pieces   [2][6]Bitboard   // Occupancy per side and Kind
+	occupied [2]Bitboard      // Occupancy per side
+	mailbox  [64]uint8        // Slot of the piece on each square, or empty
+	offBoard [32]board.Square // Square value of pieces not on the board ($CC, $FF)
+	slots    [32]int8         // Bit index of each slot, -1 when off board
+	reversed bool             // REV flag
+}
+
+// Compile-time check that Position satisfies microchess.Position.
+var _ microchess.Position = (*Position)(nil)
+
+// FromGameState builds a bitboard position from the Board/BK arrays of g.
+//
+// If two pieces claim the same square (which the original program never
+// produces) the lower slot occupies it; the other keeps its square in the
+// off-board list so that ToGameState still restores it unchanged.
+func FromGameState(g *microchess.GameState) *Position {
+	p := &Position{reversed: g.Reversed}
+	for i := range p.mailbox {
+		p.mailbox[i] = empty
+	}
+	for slot := 0; slot < 32; slot++ {
+		side, piece := slotSide(slot)
+		sq := g.PieceSquare(side, piece)
+		p.slots[slot] = -1
+		p.offBoard[slot] = sq
+		if !sq.IsValid() || p.mailbox[Index(sq)] != empty {
+			continue
+		}
+		p.put(slot, Index(sq))
+	}
+	return p
+}
+
+// ToGameState writes the position back into the Board/BK arrays and REV flag of g
+// and refreshes its Zobrist key. All other GameState fields (LED display,
+// search registers, history) are left untouched.
+func (p *Position) ToGameState(g *microchess.GameState) {
+	for slot := 0; slot < 32; slot++ {
+		sq := p.offBoard[slot]
+		if p.slots[slot] >= 0 {
+			sq = SquareOf(int(p.slots[slot]))
+		}
+		side, piece := slotSide(slot)
+		if side == microchess.SideBK {
+			g.BK[piece] = sq
+		} else {
+			g.Board[piece] = sq
+		}
+	}
+	g.Reversed = p.reversed
+	g.Hash = g.ComputeHash()
+}
+
+// slotSide splits a 0-31 slot into side and 0-15 piece index.
+func slotSide(slot int) (microchess.Side, microchess.Piece) {
+	if slot >= 16 {
+		return microchess.SideBK, microchess.Piece(slot - 16)
+	}
+	return microchess.SideBoard, microchess.Piece(slot)
+}
+
+// put places the piece in slot on bit index.
+func (p *Position) put(slot int, index int) {
+	side, piece := slotSide(slot)
+	bit := Bitboard(1) << uint(index)
+	p.pieces[side][piece.Kind()] |= bit
+	p.occupied[side] |= bit
+	p.mailbox[index] = uint8(slot)
+	p.slots[slot] = int8(index)
+}
+
+// remove takes the piece on bit index off the board, parking it at $CC
+// like MOVE does for captured pieces.
+func (p *Position) remove(index int) {
+	slot := int(p.mailbox[index])
+	side, piece := slotSide(slot)
+	bit := Bitboard(1) << uint(index)
+	p.pieces[side][piece.Kind()] &^= bit
+	p.occupied[side] &^= bit
+	p.mailbox[index] = empty
+	p.slots[slot] = -1
+	p.offBoard[slot] = 0xCC
+}
+
+// PieceAt implements microchess.Position with a single mailbox lookup.
+func (p *Position) PieceAt(sq board.Square) (microchess.Piece, microchess.Side, bool) {
+	if !sq.IsValid() || p.mailbox[Index(sq)] == empty {
+		return microchess.NoPiece, microchess.SideBoard, false
+	}
+	side, piece := slotSide(int(p.mailbox[Index(sq)]))
+	return piece, side, true
+}
+
+// PieceSquare implements microchess.Position.
+func (p *Position) PieceSquare(side microchess.Side, piece microchess.Piece) board.Square {
+	slot := int(piece)
+	if side == microchess.SideBK {
+		slot += 16
+	}
+	if p.slots[slot] < 0 {
+		return p.offBoard[slot]
+	}
+	return SquareOf(int(p.slots[slot]))
+}
+
+// IsReversed implements microchess.Position.
+func (p *Position) IsReversed() bool {
+	return p.reversed
+}
+
+// Pieces returns the squares occupied by pieces of the given side and kind.
+func (p *Position) Pieces(side microchess.Side, kind microchess.Kind) Bitboard {
+	return p.pieces[side][kind]
+}
+
+// Occupied returns the squares occupied by the given side.
+func (p *Position) Occupied(side microchess.Side) Bitboard {
+	return p.occupied[side]
+}
@@ -0,0 +1,90 @@
+// ABOUTME: This file generates legal moves and makes moves on a bitboard Position.
+// ABOUTME: It follows MicroChess rules: no castling, en passant or promotion, pawns advance towards rank 7.
+
+package bitboard
+
+import (
+	"math/bits"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// rank1 holds the squares from which a pawn may make a double step.
+// GNM allows a second step only when the first one lands on rank 2 ($2x).
+const rank1 Bitboard = 0x000000000000FF00
+
+// MakeMove plays m for the side to move, capturing whatever stands on the
+// target square. It performs no legality checks, just like MOVE.
+func (p *Position) MakeMove(m microchess.Move) {
+	to := Index(m.To)
+	if p.mailbox[to] != empty {
+		p.remove(to)
+	}
+	from := Index(m.From)
+	slot := int(p.mailbox[from])
+	p.remove(from)
+	p.put(slot, to)
+}
+
+// targets returns the pseudo-legal destination squares of a Board-side piece.
+func (p *Position) targets(kind microchess.Kind, from int) Bitboard {
+	own, their := p.occupied[microchess.SideBoard], p.occupied[microchess.SideBK]
+	occupied := own | their
+	switch kind {
+	case microchess.KindKing:
+		return kingAttacks[from] &^ own
+	case microchess.KindQueen:
+		return QueenAttacks(from, occupied) &^ own
+	case microchess.KindRook:
+		return RookAttacks(from, occupied) &^ own
+	case microchess.KindBishop:
+		return BishopAttacks(from, occupied) &^ own
+	case microchess.KindKnight:
+		return knightAttacks[from] &^ own
+	}
+
+	// Pawn: diagonal captures plus one or two steps forward onto empty squares.
+	bit := Bitboard(1) << uint(from)
+	moves := pawnAttacks(microchess.SideBoard, from) & their
+	single := (bit << 8) &^ occupied
+	moves |= single
+	if bit&rank1 != 0 {
+		moves |= (single << 8) &^ occupied
+	}
+	return moves
+}
+
+// InCheck reports whether the king of the side to move is attacked.
+// A position without a Board king is never in check, matching CHKCHK which
+// only looks for moves landing on the king's square.
+func (p *Position) InCheck() bool {
+	king := p.slots[microchess.PieceKing]
+	return king >= 0 && p.Attacked(int(king), microchess.SideBK)
+}
+
+// LegalMoves implements microchess.Position.
+// Pieces are visited from 15 down to 0 like GNM, but the order of the
+// destination squares of each piece differs from the MOVEX walk, so callers
+// comparing with GameState.LegalMoves should compare sets, not sequences.
+func (p *Position) LegalMoves() []microchess.Move {
+	var moves []microchess.Move
+	for piece := microchess.Piece(15); piece != microchess.NoPiece; piece-- {
+		from := p.slots[piece]
+		if from < 0 {
+			continue
+		}
+		targets := p.targets(piece.Kind(), int(from))
+		for targets != 0 {
+			to := bits.TrailingZeros64(uint64(targets))
+			targets &= targets - 1
+
+			m := microchess.Move{From: SquareOf(int(from)), To: SquareOf(to), Piece: piece}
+			trial := *p
+			trial.MakeMove(m)
+			if !trial.InCheck() {
+				moves = append(moves, m)
+			}
+		}
+	}
+	return moves
+}
@@ -0,0 +1,105 @@
+// ABOUTME: This file implements the 'A' (analyze) command: a multi-PV ranking of the best root moves.
+// ABOUTME: It asks the active engine for its top N moves and prints each one with its score and PV.
+
+package microchess
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMultiPV is how many moves the 'A' command ranks unless SetMultiPV changes it.
+const DefaultMultiPV = 5
+
+// SetMultiPV sets how many moves the 'A' command ranks (0 restores DefaultMultiPV).
+func (g *GameState) SetMultiPV(n int) {
+	g.multiPV = n
+}
+
+// MultiPV returns how many moves the 'A' command ranks.
+func (g *GameState) MultiPV() int {
+	if g.multiPV <= 0 {
+		return DefaultMultiPV
+	}
+	return g.multiPV
+}
+
+// cmdMultiPV implements :multipv, how many moves 'A' ranks.
+func cmdMultiPV(_ context.Context, g *GameState, _ byte, args []string) error {
+	switch len(args) {
+	case 0:
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("multipv %q is not a positive number", args[0])
+		}
+		g.SetMultiPV(n)
+	default:
+		return fmt.Errorf("usage: :multipv [N]")
+	}
+	_, _ = fmt.Fprintf(g.out, "MultiPV: %d\r\n", g.MultiPV())
+	return nil
+}
+
+// ShowAnalysis ranks the best moves of the side to move with the active
+// engine and prints them, best first. This is the handler for the 'A'
+// command (NEW - not in original); unlike 'L' it shows a score and a
+// principal variation for each move. The LED display is left alone.
+//
+// The faithful engine ranks its candidates by their STRATGY values; the
+// alpha-beta engine searches each line in turn, excluding the moves
+// already ranked (multi-PV). Cancelling ctx stops the analysis; the lines
+// of the last completed iteration are still shown.
+func (g *GameState) ShowAnalysis(ctx context.Context) {
+	engine, ok := g.ActiveEngine().(Analyzer)
+	if !ok {
+		_, _ = fmt.Fprintf(g.out, "Engine %s cannot rank moves\r\n", g.ActiveEngine().Name())
+		return
+	}
+
+	g.beginTrace(engine)
+	start := time.Now()
+	result := engine.Analyze(ctx, g, g.TimeControl(), g.MultiPV(), g.observer)
+	elapsed := time.Since(start)
+	if g.observer != nil {
+		_, _ = fmt.Fprint(g.out, "\r\n") // End the progress indicator line
+	}
+	if result.Stopped {
+		_, _ = fmt.Fprintf(g.out, "Search stopped\r\n")
+	}
+	g.endTrace(result)
+	lines := result.Lines
+	if len(lines) == 0 && result.Move.Piece != NoPiece {
+		lines = []PVLine{{Move: result.Move, Score: result.Score, PV: result.PV}} // A single line was asked for
+	}
+	if len(lines) == 0 {
+		if !result.Stopped {
+			_, _ = fmt.Fprintf(g.out, "No legal moves\r\n")
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(g.out, "Analysis: %s depth %d nodes %d qnodes %d time %dms\r\n",
+		engine.Name(), result.Depth, result.Nodes, result.QNodes, elapsed.Milliseconds())
+	g.showLines(lines)
+}
+
+// showLines prints ranked root moves, one per line.
+func (g *GameState) showLines(lines []PVLine) {
+	for i, l := range lines {
+		_, _ = fmt.Fprintf(g.out, "%2d. %02X %02X score %d pv %s\r\n",
+			i+1, uint8(l.Move.From), uint8(l.Move.To), l.Score, formatPV(l.PV))
+	}
+}
+
+// formatPV writes a principal variation the way moves are entered, e.g. "1434 6343".
+func formatPV(pv []Move) string {
+	moves := make([]string, len(pv))
+	for i, m := range pv {
+		moves[i] = fmt.Sprintf("%02X%02X", uint8(m.From), uint8(m.To))
+	}
+	return strings.Join(moves, " ")
+}
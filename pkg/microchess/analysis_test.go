@@ -0,0 +1,96 @@
+// ABOUTME: This file contains tests for multi-PV analysis and the 'A' command.
+// ABOUTME: It checks the faithful ranking against per-candidate STRATGY values and the printed lines.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaithfulEngine_AnalyzeRanksByStrategy(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	moves, err := g.ScoreRootMoves(context.Background(), 1, func() RootScorer { return EvaluatorScorer(StrategyEvaluator{}) })
+	require.NoError(t, err)
+
+	result := FaithfulEngine{}.Analyze(context.Background(), g, TimeControl{}, 4, nil)
+
+	require.Len(t, result.Lines, 4)
+	assert.Equal(t, result.Move, result.Lines[0].Move, "the best line is the move Think would play")
+	for i, l := range result.Lines {
+		assert.Equal(t, []Move{l.Move}, l.PV)
+		assert.Contains(t, moves, ScoredMove{Move: l.Move, Score: l.Score}, "scores are the candidates' STRATGY values")
+		if i > 0 {
+			assert.LessOrEqual(t, l.Score, result.Lines[i-1].Score)
+		}
+	}
+	for _, sm := range moves {
+		assert.LessOrEqual(t, sm.Score, result.Lines[0].Score)
+	}
+
+	assert.Nil(t, FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil).Lines, "Think asks for one line")
+}
+
+func TestAnalysisCommand(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	g.SetMultiPV(3)
+	leds := [3]uint8{g.DIS1, g.DIS2, g.DIS3}
+
+	g.HandleCharacter('A')
+
+	out := buf.String()
+	assert.Contains(t, out, "Analysis: faithful depth 1 nodes 20")
+	assert.Contains(t, out, " 1. ")
+	assert.Contains(t, out, " 3. ")
+	assert.NotContains(t, out, " 4. ")
+	assert.Equal(t, leds, [3]uint8{g.DIS1, g.DIS2, g.DIS3}, "the LED display is left alone")
+}
+
+func TestAnalysisCommand_SingleLine(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	g.SetMultiPV(1)
+
+	g.HandleCharacter('A')
+	assert.Contains(t, buf.String(), " 1. ")
+	assert.NotContains(t, buf.String(), " 2. ")
+}
+
+func TestLine_MultiPV(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+
+	typeKeys(g, ":multipv 2\r")
+	assert.Contains(t, buf.String(), "MultiPV: 2")
+	assert.Equal(t, 2, g.MultiPV())
+
+	buf.Reset()
+	g.HandleCharacter('A')
+	assert.Contains(t, buf.String(), " 2. ")
+	assert.NotContains(t, buf.String(), " 3. ")
+
+	buf.Reset()
+	typeKeys(g, ":multipv 0\r")
+	assert.Contains(t, buf.String(), `Error: multipv "0" is not a positive number`)
+	assert.Equal(t, 2, g.MultiPV())
+}
+
+func TestAnalysisCommand_EngineWithoutRanking(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	g.SetEngines(fixedEngine{name: "fixed"})
+
+	g.HandleCharacter('A')
+	assert.Contains(t, buf.String(), "Engine fixed cannot rank moves")
+	assert.Equal(t, DefaultMultiPV, NewGame(&buf).MultiPV())
+}
@@ -0,0 +1,224 @@
+// ABOUTME: This file implements the spoken-style interface for blindfold play and screen readers.
+// ABOUTME: Moves are announced in words, the board can be hidden, and :what and :where describe it.
+
+package microchess
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// SetAnnounce turns move announcements on or off (NEW - not in original).
+// When on, every move played (Enter, hot seat, PlayMove) is described in
+// words before the board is shown, like "White knight from g1 to f3,
+// check", and H describes the move it suggests.
+func (g *GameState) SetAnnounce(on bool) {
+	g.announce = on
+}
+
+// SetBoardHidden hides the board (NEW - not in original): Display then
+// only says whose turn it is, for blindfold play and screen readers.
+func (g *GameState) SetBoardHidden(hidden bool) {
+	g.boardHidden = hidden
+}
+
+// Announcement describes a move in spoken style: "White knight from g1
+// to f3", then ", takes bishop" for a capture and ", check" or
+// ", checkmate". m is in stored squares; like SAN, pieces of the side not
+// to move (16-31) can be described too.
+func (g *GameState) Announcement(m Move) string {
+	white := !g.Reversed
+	if m.Piece >= 16 {
+		white = !white
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s from %s to %s", sideName(white), m.Piece.Kind().Name(),
+		g.absSquare(m.From), g.absSquare(m.To))
+	if victim := g.FindPieceAtSquare(m.To); victim != NoPiece {
+		fmt.Fprintf(&sb, ", takes %s", victim.Kind().Name())
+	}
+	switch san := g.SAN(m); {
+	case strings.HasSuffix(san, "#"):
+		sb.WriteString(", checkmate")
+	case strings.HasSuffix(san, "+"):
+		sb.WriteString(", check")
+	}
+	return sb.String()
+}
+
+// sideName is "White" or "Black".
+func sideName(white bool) string {
+	if white {
+		return "White"
+	}
+	return "Black"
+}
+
+// WhatIsOn describes the absolute square sq: "White pawn on e4" or
+// "Nothing on e4".
+func (g *GameState) WhatIsOn(sq board.Square) string {
+	stored := g.absSquare(sq) // The transformation is its own inverse
+	piece := g.FindPieceAtSquare(stored)
+	if piece == NoPiece {
+		return fmt.Sprintf("Nothing on %s", sq)
+	}
+	white := (piece < 16) != g.Reversed
+	return fmt.Sprintf("%s %s on %s", sideName(white), piece.Kind().Name(), sq)
+}
+
+// WhereAre describes where the pieces of one kind of one side stand, in
+// absolute squares: "White rooks on a1 and h1", "White king on e1" or
+// "No white queens".
+func (g *GameState) WhereAre(white bool, kind Kind) string {
+	var squares []board.Square
+	pieces := &g.Board
+	if white == g.Reversed {
+		pieces = &g.BK
+	}
+	for i, sq := range pieces {
+		if Piece(i).Kind() == kind && sq.IsValid() {
+			squares = append(squares, g.absSquare(sq))
+		}
+	}
+	sort.Slice(squares, func(i, j int) bool { return squares[i] < squares[j] })
+
+	names := make([]string, len(squares))
+	for i, sq := range squares {
+		names[i] = sq.String()
+	}
+	switch len(names) {
+	case 0:
+		return fmt.Sprintf("No %s %ss", strings.ToLower(sideName(white)), kind.Name())
+	case 1:
+		return fmt.Sprintf("%s %s on %s", sideName(white), kind.Name(), names[0])
+	}
+	last := len(names) - 1
+	return fmt.Sprintf("%s %ss on %s and %s", sideName(white), kind.Name(), strings.Join(names[:last], ", "), names[last])
+}
+
+// cmdAnnounce implements :announce.
+func cmdAnnounce(_ context.Context, g *GameState, _ byte, args []string) error {
+	on, err := onOff(args, g.announce, "usage: :announce [on|off]")
+	if err != nil {
+		return err
+	}
+	g.SetAnnounce(on)
+	_, _ = fmt.Fprintf(g.out, "Announcements: %s\r\n", onOffName(on))
+	return nil
+}
+
+// cmdBoard implements :board.
+func cmdBoard(_ context.Context, g *GameState, _ byte, args []string) error {
+	shown, err := onOff(args, !g.boardHidden, "usage: :board [on|off]")
+	if err != nil {
+		return err
+	}
+	g.SetBoardHidden(!shown)
+	_, _ = fmt.Fprintf(g.out, "Board: %s\r\n", onOffName(shown))
+	return nil
+}
+
+// onOff parses the [on|off] argument of a setting whose value is now.
+func onOff(args []string, now bool, usage string) (bool, error) {
+	switch {
+	case len(args) == 0:
+		return now, nil
+	case len(args) == 1 && strings.EqualFold(args[0], "on"):
+		return true, nil
+	case len(args) == 1 && strings.EqualFold(args[0], "off"):
+		return false, nil
+	}
+	return now, fmt.Errorf("%s", usage)
+}
+
+// onOffName is "on" or "off".
+func onOffName(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// queryWords splits a question into lower-case words without the
+// punctuation and the filler words, so that ":what is on e4?" and
+// ":what e4" are the same.
+func queryWords(args []string) []string {
+	var words []string
+	for _, a := range args {
+		w := strings.ToLower(strings.Trim(a, "?.,!"))
+		w = strings.TrimSuffix(w, "'s")
+		switch w {
+		case "", "is", "are", "on", "at", "there", "the", "all", "of":
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// cmdWhat implements :what, as in ":what is on e4".
+func cmdWhat(_ context.Context, g *GameState, _ byte, args []string) error {
+	words := queryWords(args)
+	if len(words) != 1 {
+		return fmt.Errorf("usage: :what [is on] SQUARE, like :what is on e4")
+	}
+	sq, err := board.ParseSquare(words[0])
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(g.out, "%s\r\n", g.WhatIsOn(sq))
+	return nil
+}
+
+// cmdWhere implements :where, as in ":where are my rooks". The side is
+// "my" (the side to move), "their", "white" or "black", both sides when
+// none is given; "pieces" asks for every kind.
+func cmdWhere(_ context.Context, g *GameState, _ byte, args []string) error {
+	var sides []bool // true for white
+	var kinds []Kind
+	for _, w := range queryWords(args) {
+		switch w {
+		case "my", "mine", "our":
+			sides = append(sides, !g.Reversed)
+			continue
+		case "their", "your", "opponent", "enemy":
+			sides = append(sides, g.Reversed)
+			continue
+		case "white":
+			sides = append(sides, true)
+			continue
+		case "black":
+			sides = append(sides, false)
+			continue
+		case "pieces", "piece":
+			kinds = append(kinds, KindKing, KindQueen, KindRook, KindBishop, KindKnight, KindPawn)
+			continue
+		}
+		kind := NoKind
+		for k, name := range kindNames {
+			if w == name || w == name+"s" {
+				kind = Kind(k)
+			}
+		}
+		if kind == NoKind {
+			return fmt.Errorf("where: unknown word %q (try :where are my rooks)", w)
+		}
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		return fmt.Errorf("usage: :where [are] [my|their|white|black] PIECES, like :where are my rooks")
+	}
+	if len(sides) == 0 {
+		sides = []bool{true, false}
+	}
+	for _, white := range sides {
+		for _, k := range kinds {
+			_, _ = fmt.Fprintf(g.out, "%s\r\n", g.WhereAre(white, k))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// ABOUTME: This file contains tests for the spoken-style interface: announcements and board queries.
+// ABOUTME: It checks move descriptions, :what and :where, and hiding the board.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// announcementOf describes the move from from to to, in absolute squares.
+func announcementOf(t *testing.T, g *GameState, from, to board.Square) string {
+	t.Helper()
+	if g.Reversed {
+		from, to = 0x77-from, 0x77-to
+	}
+	piece := g.FindPieceAtSquare(from)
+	require.NotEqual(t, NoPiece, piece, "no piece on %s", from)
+	return g.Announcement(Move{Piece: piece, From: from, To: to})
+}
+
+func TestAnnouncement(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		from, to board.Square
+		want     string
+	}{
+		{"knight", StandardFEN, 0x06, 0x25, "White knight from g1 to f3"},
+		{"black pawn", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b - - 0 1", 0x64, 0x44, "Black pawn from e7 to e5"},
+		{"capture", "4k3/8/8/3b4/8/8/8/3RK3 w - - 0 1", 0x03, 0x43, "White rook from d1 to d5, takes bishop"},
+		{"check", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", 0x00, 0x70, "White rook from a1 to a8, check"},
+		{"mate", "4k3/8/4K3/8/8/8/8/R7 w - - 0 1", 0x00, 0x70, "White rook from a1 to a8, checkmate"},
+		{"side not to move", StandardFEN, 0x76, 0x55, "Black knight from g8 to f6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGame(&bytes.Buffer{})
+			require.NoError(t, g.SetFEN(tt.fen))
+			assert.Equal(t, tt.want, announcementOf(t, g, tt.from, tt.to))
+		})
+	}
+}
+
+func TestAnnounce_Moves(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, "C")
+	g.SetAnnounce(true)
+	g.SetBoardHidden(true)
+	g.SetHotSeat(true)
+
+	buf.Reset()
+	typeKeys(g, "1333\r")
+	assert.Equal(t, "\r\n\r\n\r\n\r\n\r\nWhite pawn from d2 to d4\r\nBlack to move\r\n", buf.String(),
+		"digits are quiet, the move is spoken, no board")
+
+	buf.Reset()
+	typeKeys(g, "H")
+	assert.Contains(t, buf.String(), "Suggested: Black ")
+}
+
+func TestWhatAndWhere(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	require.NoError(t, g.SetFEN("4k3/8/8/8/4P3/8/8/R3K2R b - - 0 1"))
+
+	assert.Equal(t, "White pawn on e4", g.WhatIsOn(0x34))
+	assert.Equal(t, "Black king on e8", g.WhatIsOn(0x74))
+	assert.Equal(t, "Nothing on e5", g.WhatIsOn(0x44))
+	assert.Equal(t, "White rooks on a1 and h1", g.WhereAre(true, KindRook))
+	assert.Equal(t, "No black rooks", g.WhereAre(false, KindRook))
+
+	typeKeys(g, ":what is on e4?\r")
+	assert.Contains(t, buf.String(), "White pawn on e4\r\n")
+
+	buf.Reset()
+	typeKeys(g, ":where are my kings\r")
+	assert.Contains(t, buf.String(), "Black king on e8\r\n", "my is the side to move")
+	assert.NotContains(t, buf.String(), "White")
+
+	buf.Reset()
+	typeKeys(g, ":where rooks\r")
+	assert.Contains(t, buf.String(), "White rooks on a1 and h1\r\nNo black rooks\r\n")
+
+	buf.Reset()
+	typeKeys(g, ":where are the dragons\r:what e9\r")
+	assert.Contains(t, buf.String(), `Error: where: unknown word "dragons"`)
+	assert.Contains(t, buf.String(), "Error: square out of range: e9")
+}
+
+func TestLine_BoardAndAnnounce(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, "C:board off\r")
+	assert.Contains(t, buf.String(), "Board: off")
+
+	buf.Reset()
+	typeKeys(g, "P")
+	assert.Equal(t, "\r\nWhite to move\r\n", buf.String(), "a hidden board only says whose turn it is")
+
+	buf.Reset()
+	typeKeys(g, ":announce on\r:board on\r")
+	assert.True(t, g.announce)
+	assert.False(t, g.boardHidden)
+	assert.Contains(t, buf.String(), "Announcements: on\r\n")
+	assert.Contains(t, buf.String(), "Board: on\r\n")
+}
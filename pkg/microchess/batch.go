@@ -0,0 +1,262 @@
+// ABOUTME: This file implements batch mode: a script of commands run without the board display.
+// ABOUTME: Each command writes one JSON object per line, so that other tools can drive MicroChess.
+
+package microchess
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatchMove is a move in batch results: SAN and absolute squares.
+type BatchMove struct {
+	SAN  string `json:"san"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BatchLine is one ranked move of an analysis, with its principal
+// variation in SAN.
+type BatchLine struct {
+	Move  BatchMove `json:"move"`
+	Score int       `json:"score"`
+	PV    []string  `json:"pv"`
+}
+
+// BatchResult is the JSON object written for each batch command. Command
+// and OK are always set, Error when OK is false; FEN and Side describe
+// the position after the command. The other fields belong to the
+// commands that fill them.
+type BatchResult struct {
+	Command string `json:"command"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	FEN     string `json:"fen,omitempty"`
+	Side    string `json:"side,omitempty"` // "white" or "black" to move
+
+	Moves     []BatchMove `json:"moves,omitempty"`       // move, list
+	Score     *int        `json:"score,omitempty"`       // eval
+	Evaluator string      `json:"evaluator,omitempty"`   // eval
+	Profile   string      `json:"profile,omitempty"`     // eval
+	Engine    string      `json:"engine,omitempty"`      // analyze
+	Depth     int         `json:"depth,omitempty"`       // analyze
+	Nodes     uint64      `json:"nodes,omitempty"`       // analyze
+	TimeMS    int64       `json:"time_ms,omitempty"`     // analyze
+	Lines     []BatchLine `json:"lines,omitempty"`       // analyze
+	PGN       string      `json:"pgn,omitempty"`         // pgn
+	InCheck   bool        `json:"check,omitempty"`       // position
+	Legal     *int        `json:"legal_moves,omitempty"` // position
+}
+
+// batchCommands maps the batch command names to their handlers.
+var batchCommands = map[string]func(ctx context.Context, g *GameState, args []string, r *BatchResult) error{
+	"move":     batchMove,
+	"list":     batchList,
+	"eval":     batchEval,
+	"analyze":  batchAnalyze,
+	"analyse":  batchAnalyze,
+	"fen":      batchFEN,
+	"pgn":      batchPGN,
+	"position": batchPosition,
+}
+
+// RunBatch runs a batch script (NEW - not in original): one command per
+// line or separated by ';', blank lines and lines starting with '#'
+// ignored. Each command writes one JSON object (a BatchResult) on its
+// own line to w. The commands are:
+//
+//	move M...      play moves, in SAN or from-to squares (see ParseMove)
+//	list           the legal moves
+//	eval           the evaluation of the active evaluator
+//	analyze [N]    the best N moves of the active engine (default MultiPV)
+//	fen [FEN]      the position as FEN, or set it up
+//	pgn            the game so far as PGN
+//	position       the FEN, side to move, check and number of legal moves
+//
+// A failing command reports ok false and the script goes on; RunBatch
+// then returns an error counting the failures. The board must be set
+// up (SetupBoard, SetFEN or ReadPGN) before a script that moves.
+func (g *GameState) RunBatch(ctx context.Context, script io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	failed, total := 0, 0
+	scanner := bufio.NewScanner(script)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, cmd := range strings.Split(line, ";") {
+			if strings.TrimSpace(cmd) == "" {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			total++
+			result := g.BatchCommand(ctx, cmd)
+			if !result.OK {
+				failed++
+			}
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch commands failed", failed, total)
+	}
+	return nil
+}
+
+// BatchCommand runs one batch command, as RunBatch does for each line,
+// and returns its result instead of writing it as JSON.
+func (g *GameState) BatchCommand(ctx context.Context, cmd string) BatchResult {
+	fields := strings.Fields(cmd)
+	result := BatchResult{Command: strings.Join(fields, " ")}
+	if len(fields) == 0 {
+		result.Error = "empty command"
+		return result
+	}
+	name := strings.ToLower(fields[0])
+	run, ok := batchCommands[name]
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("unknown command %q (want move, list, eval, analyze, fen, pgn or position)", fields[0])
+	case g.recordStart == "" && name != "fen":
+		err = fmt.Errorf("no position: set up the board first")
+	default:
+		err = run(ctx, g, fields[1:], &result)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	result.FEN = g.FEN()
+	result.Side = "white"
+	if g.Reversed {
+		result.Side = "black"
+	}
+	return result
+}
+
+// batchMoveOf describes a legal move of the side to move.
+func (g *GameState) batchMoveOf(m Move) BatchMove {
+	uci := g.UCI(m)
+	return BatchMove{SAN: g.SAN(m), From: uci[:2], To: uci[2:]}
+}
+
+// batchMove plays moves; those before a failing one stay played.
+func batchMove(_ context.Context, g *GameState, args []string, r *BatchResult) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: move M...")
+	}
+	for _, a := range args {
+		m, err := g.ParseMove(a)
+		if err != nil {
+			return err
+		}
+		r.Moves = append(r.Moves, g.batchMoveOf(m))
+		g.PlayMove(m)
+	}
+	return nil
+}
+
+// batchList lists the legal moves in GNM order.
+func batchList(_ context.Context, g *GameState, _ []string, r *BatchResult) error {
+	r.Moves = []BatchMove{} // An empty list, not a missing one
+	for _, m := range g.LegalMoves() {
+		r.Moves = append(r.Moves, g.batchMoveOf(m))
+	}
+	return nil
+}
+
+// batchEval scores the position like the 'S' command.
+func batchEval(_ context.Context, g *GameState, _ []string, r *BatchResult) error {
+	ev := g.ActiveEvaluator()
+	score := ev.Score(g, NoMoveContext)
+	r.Score = &score
+	r.Evaluator = ev.Name()
+	r.Profile = ActiveProfile().Name
+	return nil
+}
+
+// batchAnalyze ranks the best moves like the 'A' command.
+func batchAnalyze(ctx context.Context, g *GameState, args []string, r *BatchResult) error {
+	n := g.MultiPV()
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("analyze %q: want a number of moves", args[0])
+		}
+	default:
+		return fmt.Errorf("usage: analyze [N]")
+	}
+	engine, ok := g.ActiveEngine().(Analyzer)
+	if !ok {
+		return fmt.Errorf("engine %s cannot rank moves", g.ActiveEngine().Name())
+	}
+
+	g.beginTrace(engine)
+	start := time.Now()
+	result := engine.Analyze(ctx, g, g.TimeControl(), n, nil)
+	g.endTrace(result)
+	if result.Stopped {
+		return fmt.Errorf("search stopped")
+	}
+	lines := result.Lines
+	if len(lines) == 0 && result.Move.Piece != NoPiece {
+		lines = []PVLine{{Move: result.Move, Score: result.Score, PV: result.PV}}
+	}
+	r.Engine = engine.Name()
+	r.Depth = result.Depth
+	r.Nodes = result.Nodes + result.QNodes
+	r.TimeMS = time.Since(start).Milliseconds()
+	r.Lines = []BatchLine{}
+	for _, l := range lines {
+		r.Lines = append(r.Lines, BatchLine{Move: g.batchMoveOf(l.Move), Score: l.Score, PV: g.NamePV(l.PV, (*GameState).SAN)})
+	}
+	return nil
+}
+
+// batchFEN sets up a position given as FEN; the FEN of every result
+// shows it either way.
+func batchFEN(_ context.Context, g *GameState, args []string, _ *BatchResult) error {
+	if len(args) == 0 {
+		if g.recordStart == "" {
+			return fmt.Errorf("no position: set up the board first")
+		}
+		return nil
+	}
+	return g.SetFEN(strings.Join(args, " "))
+}
+
+// batchPGN writes the game so far as PGN.
+func batchPGN(_ context.Context, g *GameState, _ []string, r *BatchResult) error {
+	var sb strings.Builder
+	if err := g.WritePGN(&sb); err != nil {
+		return err
+	}
+	r.PGN = sb.String()
+	return nil
+}
+
+// batchPosition describes the side to move's situation.
+func batchPosition(_ context.Context, g *GameState, _ []string, r *BatchResult) error {
+	legal := len(g.LegalMoves())
+	r.Legal = &legal
+	r.InCheck = g.InCheck()
+	return nil
+}
@@ -0,0 +1,74 @@
+// ABOUTME: This file contains tests for batch mode and its JSON results.
+// ABOUTME: It runs small scripts and decodes every result line.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runBatch runs script on g and decodes its results.
+func runBatch(t *testing.T, g *GameState, script string) ([]BatchResult, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := g.RunBatch(context.Background(), strings.NewReader(script), &out)
+	var results []BatchResult
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r BatchResult
+		require.NoError(t, dec.Decode(&r))
+		results = append(results, r)
+	}
+	return results, err
+}
+
+func TestRunBatch(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN(StandardFEN))
+	results, err := runBatch(t, g, "# opening\nmove e4 e5; move Nf3\n\nlist\neval\nanalyze 2\nposition\n")
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	for _, r := range results {
+		assert.True(t, r.OK, r.Command)
+	}
+	assert.Equal(t, "white", results[0].Side, "after e4 e5")
+	assert.Equal(t, "black", results[1].Side, "after Nf3")
+
+	assert.Equal(t, "move e4 e5", results[0].Command)
+	assert.Equal(t, []BatchMove{{SAN: "e4", From: "e2", To: "e4"}, {SAN: "e5", From: "e7", To: "e5"}}, results[0].Moves)
+	assert.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b - - 1 2", results[1].FEN)
+	assert.Len(t, results[2].Moves, len(g.LegalMoves()))
+	require.NotNil(t, results[3].Score)
+	assert.Equal(t, "strategy", results[3].Evaluator)
+
+	analysis := results[4]
+	assert.Equal(t, "faithful", analysis.Engine)
+	require.Len(t, analysis.Lines, 2)
+	assert.Equal(t, []string{analysis.Lines[0].Move.SAN}, analysis.Lines[0].PV)
+	require.NotNil(t, results[5].Legal)
+	assert.Equal(t, len(g.LegalMoves()), *results[5].Legal)
+}
+
+func TestRunBatch_Errors(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	results, err := runBatch(t, g, "list\nfen 4k3/8/8/8/8/8/8/R3K3 w\nmove Ra8 Kf8\nfly\nposition")
+	assert.EqualError(t, err, "3 of 5 batch commands failed")
+	require.Len(t, results, 5)
+
+	assert.Equal(t, "no position: set up the board first", results[0].Error)
+	assert.True(t, results[1].OK)
+	assert.False(t, results[2].OK)
+	assert.Equal(t, `move "Kf8" is not legal`, results[2].Error)
+	assert.Contains(t, results[3].Error, `unknown command "fly"`)
+
+	position := results[4]
+	assert.Equal(t, "R3k3/8/8/8/8/8/8/4K3 b - - 1 1", position.FEN, "moves before a failing one stay played")
+	assert.True(t, position.InCheck)
+}
@@ -0,0 +1,320 @@
+// ABOUTME: This file implements time controls: fixed move time, sudden death, increment and moves-to-go.
+// ABOUTME: A TimeControl is turned into a per-move Budget; a Clock runs a Level over a whole game.
+
+package microchess
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeControl is the time situation at the moment a move must be chosen,
+// expressed exactly like the parameters of the UCI "go" command.
+// The zero value means no time limit at all.
+type TimeControl struct {
+	MoveTime     time.Duration // Fixed time for this move (UCI movetime); overrides the clock fields
+	WTime, BTime time.Duration // Time left on each clock (UCI wtime/btime)
+	WInc, BInc   time.Duration // Increment per move (UCI winc/binc)
+	MovesToGo    int           // Moves until the next time control, 0 = sudden death (UCI movestogo)
+	Depth        int           // Deepest iteration, also under a clock; 0 = the engine's own (UCI depth)
+	Infinite     bool          // Search until cancelled, ignoring the clocks (UCI infinite)
+}
+
+// Budget is the time an engine may spend on one move.
+//
+// Soft is the target: an iterative search should not start a new iteration
+// once it is likely to overrun it. Hard is the limit at which a running
+// iteration is abandoned. Zero means unlimited: the search is then bounded
+// by its depth only.
+type Budget struct {
+	Soft, Hard time.Duration
+}
+
+// Unlimited reports whether b sets no time limit.
+func (b Budget) Unlimited() bool {
+	return b.Hard == 0
+}
+
+const (
+	// defaultMovesToGo is the number of moves a sudden death clock is spread over.
+	defaultMovesToGo = 30
+	// moveOverhead is kept in reserve for I/O and the GUI.
+	moveOverhead = 20 * time.Millisecond
+	// minBudget is never cut below, so that at least one iteration completes.
+	minBudget = 5 * time.Millisecond
+)
+
+// Unlimited reports whether tc sets no limit for either side. Engines
+// should look at the Budget of the side to move instead: a GUI may send
+// only its own clock, which leaves the other side without a limit.
+func (tc TimeControl) Unlimited() bool {
+	return tc.MoveTime == 0 && tc.WTime == 0 && tc.BTime == 0
+}
+
+// Budget allots time for one move of the given side (black = REV set).
+// A side whose clock is not given gets an unlimited Budget, and so does
+// an infinite search.
+//
+// A fixed move time is used as both limits. Otherwise the remaining time is
+// spread over the moves to go (30 under sudden death), three quarters of the
+// increment is added, and the hard limit allows a hard position up to four
+// times that, but never more than half of what is left on the clock.
+func (tc TimeControl) Budget(black bool) Budget {
+	if tc.Infinite {
+		return Budget{}
+	}
+	if tc.MoveTime > 0 {
+		t := max(tc.MoveTime-moveOverhead, minBudget)
+		return Budget{Soft: t, Hard: t}
+	}
+
+	left, inc := tc.WTime, tc.WInc
+	if black {
+		left, inc = tc.BTime, tc.BInc
+	}
+	if left <= 0 {
+		return Budget{}
+	}
+
+	movesToGo := tc.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+
+	usable := max(left-moveOverhead, minBudget)
+	soft := usable/time.Duration(movesToGo) + inc*3/4
+	hard := min(4*soft, usable/2)
+	if movesToGo == 1 {
+		hard = usable // Last move before the control: everything may go
+	}
+	hard = max(hard, minBudget)
+	return Budget{Soft: min(soft, hard), Hard: hard}
+}
+
+// ParseGo extracts the time control from the arguments of a UCI "go" command
+// ("go wtime 60000 btime 60000 winc 1000 binc 1000 movestogo 20").
+// Times are in milliseconds. "depth N" and "infinite" are kept too; other
+// parameters (nodes, mate, searchmoves...) are ignored.
+func ParseGo(args []string) (TimeControl, error) {
+	var tc TimeControl
+	for i := 0; i < len(args); i++ {
+		var ms *time.Duration
+		switch args[i] {
+		case "wtime":
+			ms = &tc.WTime
+		case "btime":
+			ms = &tc.BTime
+		case "winc":
+			ms = &tc.WInc
+		case "binc":
+			ms = &tc.BInc
+		case "movetime":
+			ms = &tc.MoveTime
+		case "movestogo", "depth":
+		case "infinite":
+			tc.Infinite = true
+			continue
+		default:
+			continue
+		}
+		if i+1 >= len(args) {
+			return TimeControl{}, fmt.Errorf("go: missing value for %s", args[i])
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n < 0 {
+			return TimeControl{}, fmt.Errorf("go: bad value %q for %s", args[i+1], args[i])
+		}
+		switch {
+		case ms != nil:
+			*ms = time.Duration(n) * time.Millisecond
+		case args[i] == "depth":
+			tc.Depth = n
+		default:
+			tc.MovesToGo = n
+		}
+		i++
+	}
+	return tc, nil
+}
+
+// Level is a time control for a whole game, in the style of the xboard
+// "level" command: Moves moves in Base time, plus Inc per move.
+// Moves = 0 is sudden death. A non-zero MoveTime gives a fixed time per move instead.
+type Level struct {
+	Moves    int
+	Base     time.Duration
+	Inc      time.Duration
+	MoveTime time.Duration
+}
+
+// ParseLevel parses a level description. Two forms are accepted:
+//
+//	"5s"       fixed time per move (any Go duration, or plain seconds)
+//	"40 5 0"   xboard style: moves per control, base minutes, increment seconds
+func ParseLevel(s string) (Level, error) {
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		d, err := parseSeconds(fields[0])
+		if err != nil || d <= 0 {
+			return Level{}, fmt.Errorf("level: bad move time %q", fields[0])
+		}
+		return Level{MoveTime: d}, nil
+	case 3:
+		moves, err := strconv.Atoi(fields[0])
+		if err != nil || moves < 0 {
+			return Level{}, fmt.Errorf("level: bad move count %q", fields[0])
+		}
+		base, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || base <= 0 {
+			return Level{}, fmt.Errorf("level: bad base time %q (minutes)", fields[1])
+		}
+		inc, err := parseSeconds(fields[2])
+		if err != nil || inc < 0 {
+			return Level{}, fmt.Errorf("level: bad increment %q (seconds)", fields[2])
+		}
+		return Level{Moves: moves, Base: time.Duration(base * float64(time.Minute)), Inc: inc}, nil
+	}
+	return Level{}, fmt.Errorf("level: want \"<movetime>\" or \"<moves> <minutes> <increment>\", got %q", s)
+}
+
+// parseSeconds accepts a Go duration ("1.5s", "200ms") or a plain number of seconds.
+func parseSeconds(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// String describes the level for display.
+func (l Level) String() string {
+	if l.MoveTime > 0 {
+		return l.MoveTime.String() + " per move"
+	}
+	return fmt.Sprintf("%d moves in %v + %v", l.Moves, l.Base, l.Inc)
+}
+
+// Clock runs a Level over a game: it charges each side for the time it
+// spends and adds increments and new controls as moves are made.
+type Clock struct {
+	level Level
+	left  [2]time.Duration // Indexed by side: 0 = white, 1 = black
+	moves [2]int
+}
+
+// NewClock starts both sides' clocks at the level's base time.
+func NewClock(level Level) *Clock {
+	return &Clock{level: level, left: [2]time.Duration{level.Base, level.Base}}
+}
+
+// Level returns the level the clock was started with.
+func (c *Clock) Level() Level {
+	return c.level
+}
+
+// TimeControl describes the current clock state in UCI "go" terms, with
+// the moves to go counted for the side about to move (black = REV set).
+func (c *Clock) TimeControl(black bool) TimeControl {
+	if c.level.MoveTime > 0 {
+		return TimeControl{MoveTime: c.level.MoveTime}
+	}
+	tc := TimeControl{
+		WTime: max(c.left[0], 1), BTime: max(c.left[1], 1),
+		WInc: c.level.Inc, BInc: c.level.Inc,
+	}
+	if c.level.Moves > 0 {
+		tc.MovesToGo = c.level.Moves - c.moves[sideIndex(black)]%c.level.Moves
+	}
+	return tc
+}
+
+// Punch stops the clock of the side that just moved (black = REV set):
+// elapsed is deducted, the increment added and, when a control is
+// reached, the base time given again.
+func (c *Clock) Punch(black bool, elapsed time.Duration) {
+	if c.level.MoveTime > 0 {
+		return
+	}
+	side := sideIndex(black)
+	c.left[side] += c.level.Inc - elapsed
+	c.moves[side]++
+	if c.level.Moves > 0 && c.moves[side]%c.level.Moves == 0 {
+		c.left[side] += c.level.Base
+	}
+}
+
+// Remaining returns the time left on a side's clock.
+func (c *Clock) Remaining(black bool) time.Duration {
+	return c.left[sideIndex(black)]
+}
+
+// sideIndex maps the REV flag to the clock index (0 = white, 1 = black).
+func sideIndex(black bool) int {
+	if black {
+		return 1
+	}
+	return 0
+}
+
+// SetLevel installs a clock used by the 'H' command to budget engine time.
+func (g *GameState) SetLevel(level Level) {
+	g.clock = NewClock(level)
+}
+
+// cmdLevel implements :level: it shows the level and the clocks, sets a
+// level written like -level ("5s" or "40 5 0"), or removes it ("off").
+// A new level starts both clocks again.
+func cmdLevel(_ context.Context, g *GameState, _ byte, args []string) error {
+	switch {
+	case len(args) == 1 && strings.EqualFold(args[0], "off"):
+		g.clock = nil
+	case len(args) > 0:
+		level, err := ParseLevel(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		g.SetLevel(level)
+	}
+	if g.clock == nil {
+		_, _ = fmt.Fprint(g.out, "Level: none\r\n")
+		return nil
+	}
+	level := g.clock.Level()
+	if level.MoveTime > 0 {
+		_, _ = fmt.Fprintf(g.out, "Level: %s\r\n", level)
+		return nil
+	}
+	_, _ = fmt.Fprintf(g.out, "Level: %s, white %v, black %v\r\n", level,
+		g.clock.Remaining(false).Round(time.Second), g.clock.Remaining(true).Round(time.Second))
+	return nil
+}
+
+// TimeControl returns the time control for the side to move (no limit if
+// no level is set), less the engine time already spent on its move.
+func (g *GameState) TimeControl() TimeControl {
+	if g.clock == nil {
+		return TimeControl{}
+	}
+	tc := g.clock.TimeControl(g.Reversed)
+	switch {
+	case tc.MoveTime > 0:
+	case g.Reversed:
+		tc.BTime = max(tc.BTime-g.thinking, 1)
+	default:
+		tc.WTime = max(tc.WTime-g.thinking, 1)
+	}
+	return tc
+}
+
+// punchClock charges the engine time spent since the last move to the
+// side that has just moved (black = REV set for it). Hints that are never
+// played cost nothing more than the time they took before the next move.
+func (g *GameState) punchClock(black bool) {
+	if g.clock != nil {
+		g.clock.Punch(black, g.thinking)
+	}
+	g.thinking = 0
+}
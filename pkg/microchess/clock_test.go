@@ -0,0 +1,176 @@
+// ABOUTME: This file contains tests for time controls, per-move budgets and the game clock.
+// ABOUTME: It covers UCI "go" parsing, level parsing and how the clock charges each side.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudget_MoveTime(t *testing.T) {
+	b := TimeControl{MoveTime: time.Second}.Budget(false)
+	assert.Equal(t, time.Second-moveOverhead, b.Soft)
+	assert.Equal(t, b.Soft, b.Hard)
+}
+
+func TestBudget_Unlimited(t *testing.T) {
+	assert.True(t, TimeControl{}.Unlimited())
+	assert.Equal(t, Budget{}, TimeControl{}.Budget(false))
+}
+
+func TestBudget_SuddenDeathWithIncrement(t *testing.T) {
+	tc := TimeControl{WTime: 60 * time.Second, BTime: 30 * time.Second, WInc: time.Second, BInc: time.Second}
+
+	white, black := tc.Budget(false), tc.Budget(true)
+
+	assert.Equal(t, (60*time.Second-moveOverhead)/defaultMovesToGo+750*time.Millisecond, white.Soft)
+	assert.Greater(t, white.Soft, black.Soft, "black has less time left")
+	assert.LessOrEqual(t, white.Hard, 30*time.Second, "never more than half the clock")
+	assert.GreaterOrEqual(t, white.Hard, white.Soft)
+}
+
+func TestBudget_MovesToGo(t *testing.T) {
+	tc := TimeControl{WTime: 10 * time.Second, MovesToGo: 5}
+	assert.Equal(t, (10*time.Second-moveOverhead)/5, tc.Budget(false).Soft)
+
+	last := TimeControl{WTime: 10 * time.Second, MovesToGo: 1}.Budget(false)
+	assert.Equal(t, 10*time.Second-moveOverhead, last.Hard, "the last move may use the whole clock")
+}
+
+func TestParseGo(t *testing.T) {
+	tc, err := ParseGo([]string{"wtime", "60000", "btime", "50000", "winc", "1000", "binc", "500", "movestogo", "20", "depth", "9"})
+	require.NoError(t, err)
+	assert.Equal(t, TimeControl{
+		WTime: 60 * time.Second, BTime: 50 * time.Second,
+		WInc: time.Second, BInc: 500 * time.Millisecond, MovesToGo: 20, Depth: 9,
+	}, tc)
+
+	tc, err = ParseGo([]string{"infinite", "wtime", "1000"})
+	require.NoError(t, err)
+	assert.True(t, tc.Infinite)
+	assert.True(t, tc.Budget(false).Unlimited(), "an infinite search ignores the clocks")
+
+	tc, err = ParseGo([]string{"movetime", "250"})
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, tc.MoveTime)
+
+	_, err = ParseGo([]string{"wtime"})
+	assert.Error(t, err)
+	_, err = ParseGo([]string{"btime", "soon"})
+	assert.Error(t, err)
+	_, err = ParseGo([]string{"depth", "deep"})
+	assert.Error(t, err)
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Level
+	}{
+		{"5", Level{MoveTime: 5 * time.Second}},
+		{"250ms", Level{MoveTime: 250 * time.Millisecond}},
+		{"40 5 0", Level{Moves: 40, Base: 5 * time.Minute}},
+		{"0 2 1", Level{Base: 2 * time.Minute, Inc: time.Second}},
+		{"0 0.5 0.1", Level{Base: 30 * time.Second, Inc: 100 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"", "0", "fast", "40 5", "40 0 0", "-1 5 0"} {
+		_, err := ParseLevel(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestClock_ChargesTheSideThatMoved(t *testing.T) {
+	c := NewClock(Level{Moves: 2, Base: time.Minute, Inc: time.Second})
+	assert.Equal(t, 2, c.TimeControl(false).MovesToGo)
+
+	c.Punch(false, 10*time.Second)
+	assert.Equal(t, 51*time.Second, c.Remaining(false))
+	assert.Equal(t, time.Minute, c.Remaining(true))
+	assert.Equal(t, 1, c.TimeControl(false).MovesToGo)
+	assert.Equal(t, 2, c.TimeControl(true).MovesToGo)
+
+	// Second move reaches the control: the base time is added again
+	c.Punch(false, time.Second)
+	assert.Equal(t, 51*time.Second+time.Minute, c.Remaining(false))
+	assert.Equal(t, 2, c.TimeControl(false).MovesToGo)
+}
+
+func TestHint_UsesAndChargesTheClock(t *testing.T) {
+	var seen TimeControl
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.SetEngines(recordingEngine{tc: &seen})
+	g.SetLevel(Level{Base: time.Minute})
+
+	g.HandleCharacter('H')
+	assert.Equal(t, time.Minute, seen.WTime)
+	assert.Equal(t, time.Minute, g.clock.Remaining(false), "a hint is not a move")
+
+	g.HandleCharacter('H')
+	assert.Less(t, seen.WTime, time.Minute, "the first hint's time is spent")
+
+	g.HandleCharacter('\r')
+	assert.Less(t, g.clock.Remaining(false), time.Minute)
+	assert.Equal(t, time.Minute, g.clock.Remaining(true))
+	assert.Equal(t, 1, len(g.Record()))
+}
+
+func TestBudget_OtherSideOnly(t *testing.T) {
+	tc := TimeControl{WTime: time.Minute, WInc: time.Second}
+	assert.False(t, tc.Unlimited())
+	assert.True(t, tc.Budget(true).Unlimited(), "black has no clock")
+	assert.False(t, tc.Budget(false).Unlimited())
+}
+
+// recordingEngine remembers the time control it was given and plays the
+// first legal move.
+type recordingEngine struct {
+	tc *TimeControl
+}
+
+func (e recordingEngine) Name() string { return "recording" }
+
+func (e recordingEngine) Think(ctx context.Context, g *GameState, tc TimeControl, observe Observer) SearchResult {
+	*e.tc = tc
+	time.Sleep(time.Millisecond)
+	return SearchResult{Move: g.LegalMoves()[0]}
+}
+
+func TestLine_Level(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, ":level\r")
+	assert.Contains(t, buf.String(), "Level: none")
+
+	buf.Reset()
+	typeKeys(g, ":level 40 5 0\r")
+	assert.Contains(t, buf.String(), "Level: 40 moves in 5m0s + 0s, white 5m0s, black 5m0s")
+	assert.Equal(t, 5*time.Minute, g.TimeControl().WTime, "H thinks within the new clock")
+	assert.Equal(t, 40, g.TimeControl().MovesToGo)
+
+	buf.Reset()
+	typeKeys(g, ":level 2s\r:level\r")
+	assert.Equal(t, 2, strings.Count(buf.String(), "Level: 2s per move"))
+	assert.Equal(t, 2*time.Second, g.TimeControl().MoveTime)
+
+	buf.Reset()
+	typeKeys(g, ":level fast\r")
+	assert.Contains(t, buf.String(), `Error: level: bad move time "fast"`)
+	assert.Equal(t, 2*time.Second, g.TimeControl().MoveTime, "a bad level keeps the old one")
+
+	typeKeys(g, ":level off\r")
+	assert.Equal(t, TimeControl{}, g.TimeControl())
+}
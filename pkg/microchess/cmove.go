@@ -198,3 +198,72 @@ func (g *GameState) CMOVE(from board.Square, moven uint8) CMoveResult {
 
 	return savedResult
 }
+
+// InCheck reports whether the king of the side to move can be captured by the opponent.
+// This is NEW (not in original): it runs the CHKCHK test on the current position
+// without a trial move. REVERSE, GNM with STATE = -7 (JANUS sets INCHEK when a
+// move lands on BK[0], which after REVERSE is our king), REVERSE back.
+// All registers touched by GNM are restored.
+func (g *GameState) InCheck() bool {
+	if !g.Board[PieceKing].IsValid() {
+		return false // No king on the board (captured or never set up)
+	}
+
+	savedState, savedInChek := g.State, g.InChek
+	savedMovePiece, savedMoveSquare, savedMoveN := g.MovePiece, g.MoveSquare, g.MoveN
+
+	g.State = -7
+	g.InChek = 0xF9
+	g.Reverse()
+	g.GNM(nil)
+	g.Reverse()
+	inCheck := g.InChek != 0xF9
+
+	g.State, g.InChek = savedState, savedInChek
+	g.MovePiece, g.MoveSquare, g.MoveN = savedMovePiece, savedMoveSquare, savedMoveN
+	return inCheck
+}
+
+// IsLegal reports whether LegalMoves would return the move m of the side
+// to move, which must come from PseudoLegalMoves. This is NEW (not in
+// original): it gives CHKCHK's verdict without the trial MOVE and the GNM
+// of every reply, by looking for an attacker of the king (see attacks)
+// once m is played on a copy of the piece lists.
+//
+// The CMOVE quirks are kept: LINE accepts a sliding capture even if it
+// leaves the king capturable, and a pawn only makes its double step when
+// the single step is legal too.
+func (g *GameState) IsLegal(m Move) bool {
+	kind := m.Piece.Kind()
+	_, _, capture := g.PieceAt(m.To)
+	switch {
+	case capture && (kind == KindQueen || kind == KindRook || kind == KindBishop):
+		return true
+	case kind == KindPawn && m.To-m.From == 0x20:
+		return g.kingSafeAfter(Move{Piece: m.Piece, From: m.From, To: m.From + 0x10}) && g.kingSafeAfter(m)
+	}
+	return g.kingSafeAfter(m)
+}
+
+// kingSafeAfter reports whether no piece of the opponent attacks the king
+// of the side to move once m is played.
+func (g *GameState) kingSafeAfter(m Move) bool {
+	lists := [2][16]board.Square{g.Board, g.BK}
+	for i, sq := range lists[SideBK] {
+		if sq == m.To {
+			lists[SideBK][i] = 0xCC
+		}
+	}
+	lists[SideBoard][m.Piece] = m.To
+
+	king := lists[SideBoard][PieceKing]
+	if !king.IsValid() {
+		return true // No king to lose, as for CHKCHK
+	}
+	for i, from := range lists[SideBK] {
+		if from.IsValid() && attacks(&lists, SideBK, Piece(i), from, king) {
+			return false
+		}
+	}
+	return true
+}
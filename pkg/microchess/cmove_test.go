@@ -461,3 +461,45 @@ func TestCMOVE_CHKCHKSkippedWhenNotNeeded(t *testing.T) {
 		})
 	}
 }
+
+// TestIsLegal_AgreesWithCHKCHK walks the move tree of a few positions and
+// checks that filtering PseudoLegalMoves with IsLegal gives exactly the
+// moves of LegalMoves, in the same order, at every node.
+func TestIsLegal_AgreesWithCHKCHK(t *testing.T) {
+	fens := []string{
+		StandardFEN,
+		InitialFEN,
+		"4k3/8/8/8/1b6/8/3P4/4K3 w - - 0 1", // Pinned pawn: no push, no double step
+		"4k3/4r3/8/8/8/8/4Q3/4K3 w - - 0 1", // Pinned queen slides along the pin
+		"r3k2r/ppp2ppp/2n5/3qp3/1b1P4/2N2N2/PPP2PPP/R2QKB1R w - - 0 1",
+		"4k3/8/8/8/8/8/3q4/4K3 w - - 0 1",    // In check by an adjacent queen
+		"4k3/8/8/8/8/5n2/8/4K3 w - - 0 1",    // In check by a knight
+		"4k3/8/8/8/r6K/8/3P4/8 w - - 0 1",    // d4 would block the check, but d3 does not
+		"4r1k1/8/8/8/8/8/n3R3/4K3 w - - 0 1", // The pinned rook may still take on a2 (LINE)
+	}
+	var walk func(g *GameState, depth int)
+	walk = func(g *GameState, depth int) {
+		var filtered []Move
+		for _, m := range g.PseudoLegalMoves(nil) {
+			if g.IsLegal(m) {
+				filtered = append(filtered, m)
+			}
+		}
+		legal := g.LegalMoves()
+		if !assert.Equal(t, legal, filtered, g.FEN()) || depth == 0 {
+			return
+		}
+		for _, m := range legal {
+			g.perftMove(m)
+			walk(g, depth-1)
+			g.RUM()
+		}
+	}
+	for _, fen := range fens {
+		g := NewGame(&bytes.Buffer{})
+		if !assert.NoError(t, g.SetFEN(fen)) {
+			continue
+		}
+		walk(g, 2)
+	}
+}
@@ -0,0 +1,358 @@
+// ABOUTME: This file holds the command registry: every command's keys, name, help text and handler.
+// ABOUTME: HandleCharacter and the ':' command line dispatch through it, and help is generated from it.
+
+package microchess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// Command is a command of the CLI, run by a single key, by name on the
+// ':' command line, or both.
+type Command struct {
+	// Keys are the single keys that run the command, upper case for
+	// letters ("" for line-only commands). Help shows the first one, or
+	// a range like "0-7" for digits.
+	Keys string
+
+	// Name runs the command on the ':' command line ("" for key-only
+	// commands). Args is its argument synopsis for help, like "[FEN]".
+	Name string
+	Args string
+
+	// Help is a one-line description.
+	Help string
+
+	// Quiet commands do not start a new line when their key is pressed
+	// (':' keeps the key's line for the command line).
+	Quiet bool
+
+	// Run executes the command. key is the key pressed, or 0 on the ':'
+	// command line, where args holds the words after the name. Returning
+	// ErrQuit ends the program; other errors are printed.
+	Run func(ctx context.Context, g *GameState, key byte, args []string) error
+}
+
+// ErrQuit is returned by a command to end the program.
+var ErrQuit = errors.New("quit")
+
+// commands holds the registered commands in registration order.
+var commands []Command
+
+// RegisterCommand adds a command to the CLI of every game. It panics when
+// one of its keys or its name is already taken, or when it has neither.
+func RegisterCommand(c Command) {
+	if c.Keys == "" && c.Name == "" {
+		panic("microchess: RegisterCommand: command has no key and no name")
+	}
+	if c.Run == nil {
+		panic("microchess: RegisterCommand: command " + c.label() + " has no handler")
+	}
+	for i := 0; i < len(c.Keys); i++ {
+		if _, ok := CommandByKey(c.Keys[i]); ok {
+			panic(fmt.Sprintf("microchess: RegisterCommand: key %q is taken", c.Keys[i]))
+		}
+	}
+	if _, ok := CommandByName(c.Name); ok && c.Name != "" {
+		panic("microchess: RegisterCommand: name " + c.Name + " is taken")
+	}
+	commands = append(commands, c)
+}
+
+// Commands returns the registered commands in registration order.
+func Commands() []Command {
+	return append([]Command(nil), commands...)
+}
+
+// CommandByKey returns the command run by key (letters in either case).
+func CommandByKey(key byte) (Command, bool) {
+	key = upper(key)
+	for _, c := range commands {
+		if strings.IndexByte(c.Keys, key) >= 0 {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// CommandByName returns the command with the given name (any case).
+func CommandByName(name string) (Command, bool) {
+	for _, c := range commands {
+		if c.Name != "" && strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// upper converts a lower case letter to upper case.
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// keyLabel names the command's keys for help: "Enter", "0-7" or the key.
+func (c Command) keyLabel() string {
+	switch {
+	case c.Keys == "":
+		return ""
+	case c.Keys[0] == '\r':
+		return "Enter"
+	case len(c.Keys) > 1:
+		return fmt.Sprintf("%c-%c", c.Keys[0], c.Keys[len(c.Keys)-1])
+	}
+	return c.Keys[:1]
+}
+
+// label names the command in messages: its name, or its keys.
+func (c Command) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.keyLabel()
+}
+
+// run executes the command and reports whether the program should continue.
+func (g *GameState) run(ctx context.Context, c Command, key byte, args []string) bool {
+	err := c.Run(ctx, g, key, args)
+	if errors.Is(err, ErrQuit) {
+		return false
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(g.out, "Error: %v\r\n", err)
+	}
+	return true
+}
+
+// availableCommands is the one-line summary of the single keys printed
+// after an unknown key, like "C (setup), E (reverse), ...".
+func availableCommands() string {
+	var items []string
+	for _, c := range commands {
+		if c.Keys == "" {
+			continue
+		}
+		desc := c.Name
+		if desc == "" {
+			desc = c.Help
+		}
+		items = append(items, fmt.Sprintf("%s (%s)", c.keyLabel(), desc))
+	}
+	return "Available commands: " + strings.Join(items, ", ")
+}
+
+// ShowHelp lists every command with its key, its ':' name and its help.
+func (g *GameState) ShowHelp() {
+	_, _ = fmt.Fprint(g.out, "Key    Command line          Description\r\n")
+	for _, c := range commands {
+		line := ""
+		if c.Name != "" {
+			line = strings.TrimSpace(":" + c.Name + " " + c.Args)
+		}
+		_, _ = fmt.Fprintf(g.out, "%-6s %-21s %s\r\n", c.keyLabel(), line, c.Help)
+	}
+}
+
+func init() {
+	for _, c := range []Command{
+		{Keys: "C", Name: "setup", Help: "set up the board (LEDs CC CC CC)", Run: cmdSetup},
+		{Keys: "E", Name: "reverse", Help: "reverse the board: the other side moves (LEDs EE EE EE)", Run: cmdReverse},
+		{Keys: "P", Name: "print", Help: "print the board", Run: cmdPrint},
+		{Keys: "01234567", Help: "type the squares of a move", Run: cmdDigit},
+		{Keys: "\r\n", Name: "move", Args: "[FROMTO]", Help: "play the typed move, or FROMTO like 1333", Run: cmdMove},
+		{Keys: "L", Name: "list", Help: "list the legal moves", Run: cmdList},
+		{Keys: "S", Name: "eval", Help: "show the position evaluation", Run: cmdEval},
+		{Keys: "H", Name: "hint", Help: "ask the engine for a move (Enter plays it)", Run: cmdHint},
+		{Keys: "A", Name: "analyze", Help: "rank the best moves with their scores and lines", Run: cmdAnalyze},
+		{Keys: "T", Name: "exchange", Help: "explain the exchange started by the typed capture", Run: cmdExchange},
+		{Keys: "M", Name: "engine", Help: "switch to the next engine", Run: cmdEngine},
+		{Keys: "Q", Name: "quit", Help: "leave the program", Run: cmdQuit},
+		{Keys: ":", Help: "command line", Quiet: true, Run: cmdLine},
+		{Keys: "?", Name: "help", Help: "list the commands", Run: cmdHelp},
+		{Name: "fen", Args: "[FEN]", Help: "show the position as FEN, or set it up", Run: cmdFEN},
+		{Name: "pgn", Args: "[save FILE]", Help: "show the game as PGN, or save it to FILE", Run: cmdPGN},
+		{Name: "depth", Args: "[N]", Help: "show or set the search depth in plies", Run: cmdDepth},
+		{Name: "hash", Args: "[N]", Help: "show or set the transposition table size in MB", Run: cmdHash},
+		{Name: "threads", Args: "[N]", Help: "show or set the number of search threads", Run: cmdThreads},
+		{Name: "multipv", Args: "[N]", Help: "show or set how many moves A ranks", Run: cmdMultiPV},
+		{Name: "level", Args: "[SPEC|off]", Help: `show or set the time control of H, like "5s" or "40 5 0"`, Run: cmdLevel},
+		{Name: "profile", Args: "[FILE]", Help: "show the evaluation profile, or load one (YAML or JSON)", Run: cmdProfile},
+		{Name: "announce", Args: "[on|off]", Help: "describe every move in words", Run: cmdAnnounce},
+		{Name: "board", Args: "[on|off]", Help: "show or hide the board", Run: cmdBoard},
+		{Name: "what", Args: "[is on] SQUARE", Help: "say what stands on a square", Run: cmdWhat},
+		{Name: "where", Args: "[are my] PIECES", Help: "say where pieces stand, like :where are my rooks", Run: cmdWhere},
+		{Name: "hotseat", Args: "[on|off]", Help: "two players: legal moves only, the board turns after each", Run: cmdHotSeat},
+	} {
+		RegisterCommand(c)
+	}
+}
+
+// cmdSetup sets up the board (SETUP routine, line 665, called at line 116).
+func cmdSetup(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.SetupBoard()
+	// Set LED display to "CC CC CC" to indicate setup
+	g.DIS1 = 0xCC
+	g.DIS2 = 0xCC
+	g.DIS3 = 0xCC
+	g.Display()
+	return nil
+}
+
+// cmdReverse reverses the board perspective (REVERSE routine, line 382,
+// called at line 126).
+func cmdReverse(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.Reverse()
+	// Set LED display to "EE EE EE" to indicate reversal
+	g.DIS1 = 0xEE
+	g.DIS2 = 0xEE
+	g.DIS3 = 0xEE
+	g.Display()
+	return nil
+}
+
+// cmdPrint prints the board (POUT routine, line 702, called at line 140).
+func cmdPrint(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.Display()
+	return nil
+}
+
+// cmdDigit handles digit input for move entry (INPUT routine, assembly
+// line 262) and shows the LEDs, unless the board is hidden: then only
+// the move is spoken, once played.
+func cmdDigit(_ context.Context, g *GameState, key byte, _ []string) error {
+	g.enterDigit(key - '0')
+	if !g.boardHidden {
+		g.Display()
+	}
+	return nil
+}
+
+// enterDigit rotates one digit into the move on the LEDs.
+func (g *GameState) enterDigit(digit uint8) {
+	// On first digit, reset DIS1 to 0xFF (no piece selected yet)
+	// This matches observed 6502 behavior where DIS1 shows FF during digit entry
+	if g.DigitCount == 0 {
+		g.DIS1 = 0xFF
+	}
+
+	// If we've completed 4 digits previously (DigitCount >= 4),
+	// reset DIS1 to FF but keep the digit rotation going (don't reset DigitCount)
+	// This allows entering 5+ digits - they keep rotating through DIS2/DIS3
+	if g.DigitCount >= 4 {
+		g.DIS1 = 0xFF
+		// Don't reset DigitCount - just let it keep incrementing
+	}
+
+	// Rotate digit into move display (DISMV routine, line 625)
+	g.RotateDigitIntoMove(digit)
+	g.DigitCount++
+
+	// After 4 or more digits, always find piece at from square (DIS2)
+	// The last 4 digits in the rolling buffer define the current move
+	// Assembly lines 266-272 (SEARCH loop in INPUT)
+	if g.DigitCount >= 4 {
+		fromSquare := board.Square(g.DIS2)
+		piece := g.FindPieceAtSquare(fromSquare)
+
+		// Store piece index in DIS1 and SelectedPiece
+		// Assembly line 271-272: STX DIS1 / STX PIECE
+		g.DIS1 = uint8(piece)
+		g.SelectedPiece = piece
+	}
+}
+
+// cmdMove plays the move typed with the digit keys (MOVE, line 146). On
+// the command line the move may be given as four digits instead. In
+// hot-seat mode only legal moves are played (see SetHotSeat).
+func cmdMove(_ context.Context, g *GameState, _ byte, args []string) error {
+	switch len(args) {
+	case 0:
+	case 1:
+		digits := args[0]
+		if len(digits) != 4 || strings.Trim(digits, "01234567") != "" {
+			return fmt.Errorf("move %q: want four digits 0-7, like 1333", digits)
+		}
+		for i := 0; i < len(digits); i++ {
+			g.enterDigit(digits[i] - '0')
+		}
+	default:
+		return fmt.Errorf("usage: :move [FROMTO]")
+	}
+
+	// If we have 4 or more digits entered, execute the move using the last 4 digits
+	// The last 4 digits are stored in DIS2 (from square) and DIS3 (to square)
+	// This allows users to enter extra digits (5, 6, 7, 8, ...) and still execute
+	switch {
+	case g.DigitCount < 4:
+	case g.hotSeat:
+		g.playTypedMove() // Legal moves only, then the other side's turn
+	default:
+		g.ExecuteMove()
+	}
+	// Always display board after carriage return (even if no move executed)
+	// This matches 6502 behavior
+	g.Display()
+	return nil
+}
+
+// cmdList lists the legal moves (NEW command - not in original).
+func cmdList(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.ListLegalMoves()
+	return nil
+}
+
+// cmdEval shows the position evaluation (NEW command - not in original).
+func cmdEval(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.ShowEvaluation()
+	return nil
+}
+
+// cmdHint asks the active engine for a move (NEW command - not in original).
+func cmdHint(ctx context.Context, g *GameState, _ byte, _ []string) error {
+	g.ShowHint(ctx)
+	return nil
+}
+
+// cmdAnalyze ranks the best moves with scores and PVs (NEW command - not
+// in original).
+func cmdAnalyze(ctx context.Context, g *GameState, _ byte, _ []string) error {
+	g.ShowAnalysis(ctx)
+	return nil
+}
+
+// cmdExchange explains the capture entered with the digit keys (NEW
+// command - not in original).
+func cmdExchange(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.ExplainCapture()
+	return nil
+}
+
+// cmdEngine switches engine mode (NEW command - not in original).
+func cmdEngine(_ context.Context, g *GameState, _ byte, _ []string) error {
+	_, _ = fmt.Fprintf(g.out, "Engine: %s\r\n", g.NextEngine().Name())
+	return nil
+}
+
+// cmdQuit quits the program (assembly line 148).
+func cmdQuit(context.Context, *GameState, byte, []string) error {
+	return ErrQuit
+}
+
+// cmdLine opens the ':' command line (NEW command - not in original).
+func cmdLine(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.line = &lineEditor{recall: len(g.lineHistory)}
+	return nil
+}
+
+// cmdHelp lists the commands (NEW command - not in original).
+func cmdHelp(_ context.Context, g *GameState, _ byte, _ []string) error {
+	g.ShowHelp()
+	return nil
+}
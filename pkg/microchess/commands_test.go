@@ -0,0 +1,140 @@
+// ABOUTME: This file contains tests for the command registry and the help generated from it.
+// ABOUTME: It checks key and name lookup, registering an extension command and duplicate detection.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withCommands restores the registry when the test ends.
+func withCommands(t *testing.T) {
+	saved := Commands()
+	t.Cleanup(func() { commands = saved })
+}
+
+func TestCommandByKey(t *testing.T) {
+	c, ok := CommandByKey('s')
+	assert.True(t, ok, "lower case letters find the command")
+	assert.Equal(t, "eval", c.Name)
+
+	c, ok = CommandByKey('5')
+	assert.True(t, ok)
+	assert.Equal(t, "0-7", c.keyLabel())
+
+	c, ok = CommandByKey('\n')
+	assert.True(t, ok)
+	assert.Equal(t, "Enter", c.keyLabel())
+
+	_, ok = CommandByKey('X')
+	assert.False(t, ok)
+}
+
+func TestCommandByName(t *testing.T) {
+	c, ok := CommandByName("SETUP")
+	assert.True(t, ok)
+	assert.Equal(t, "C", c.Keys)
+
+	_, ok = CommandByName("")
+	assert.False(t, ok, "key-only commands have no name")
+}
+
+func TestRegisterCommand(t *testing.T) {
+	withCommands(t)
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+
+	var got []string
+	RegisterCommand(Command{Keys: "Z", Name: "zap", Args: "[WHAT]", Help: "zap something",
+		Run: func(_ context.Context, g *GameState, key byte, args []string) error {
+			got = append(got, string(rune(key))+" "+strings.Join(args, " "))
+			if len(args) > 0 && args[0] == "fail" {
+				return errors.New("zap failed")
+			}
+			return nil
+		}})
+
+	assert.True(t, g.HandleCharacter('z'))
+	assert.True(t, g.RunLine(context.Background(), "zap it"))
+	assert.Equal(t, []string{"Z ", "\x00 it"}, got)
+
+	assert.True(t, g.RunLine(context.Background(), "zap fail"))
+	assert.Contains(t, buf.String(), "Error: zap failed\r\n")
+
+	buf.Reset()
+	g.ShowHelp()
+	assert.Contains(t, buf.String(), "Z      :zap [WHAT]           zap something\r\n")
+	assert.Contains(t, availableCommands(), "Z (zap)")
+
+	assert.Panics(t, func() { RegisterCommand(Command{Keys: "Q", Run: cmdQuit}) }, "Q is taken")
+	assert.Panics(t, func() { RegisterCommand(Command{Name: "Fen", Run: cmdQuit}) }, "fen is taken")
+	assert.Panics(t, func() { RegisterCommand(Command{Help: "nothing", Run: cmdQuit}) }, "no key and no name")
+	assert.Panics(t, func() { RegisterCommand(Command{Name: "noop"}) }, "no handler")
+}
+
+func TestHelp_ListsEveryCommand(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	assert.True(t, g.HandleCharacter('?'))
+	for _, c := range Commands() {
+		assert.Contains(t, buf.String(), c.Help)
+	}
+
+	summary := availableCommands()
+	for _, item := range []string{"C (setup)", "L (list)", "S (eval)", "0-7 (", "Enter (move)", "Q (quit)"} {
+		assert.Contains(t, summary, item)
+	}
+}
+
+func TestLine_KeyCommandsByName(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, ":setup\r:move 1333\r")
+	assert.Equal(t, "d4", g.Record()[0].SAN)
+
+	buf.Reset()
+	typeKeys(g, ":move 1399\r")
+	assert.Contains(t, buf.String(), `Error: move "1399": want four digits 0-7`)
+	assert.False(t, typeKeys(g, ":QUIT\r"))
+}
+
+func TestLine_Profile(t *testing.T) {
+	t.Cleanup(func() { _ = UseProfile(DefaultProfile()) })
+	dir := t.TempDir()
+	good := filepath.Join(dir, "aggressive.yaml")
+	require.NoError(t, os.WriteFile(good, []byte("points:\n  queen: 30\n"), 0o644))
+	bad := filepath.Join(dir, "broken.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("points:\n  pawn: 0\n"), 0o644))
+
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, ":profile\r")
+	assert.Contains(t, buf.String(), "Profile: 1976")
+
+	buf.Reset()
+	typeKeys(g, ":profile "+good+"\r")
+	assert.Contains(t, buf.String(), "Profile: aggressive")
+	assert.Equal(t, uint8(30), POINTS[PieceQueen])
+
+	buf.Reset()
+	points, weights := POINTS, strategyWeights
+	typeKeys(g, ":profile "+bad+"\r")
+	assert.Contains(t, buf.String(), "Error:")
+	assert.Equal(t, points, POINTS, "an invalid profile leaves POINTS alone")
+	assert.Equal(t, weights, strategyWeights)
+	assert.Equal(t, "aggressive", ActiveProfile().Name)
+
+	buf.Reset()
+	typeKeys(g, ":profile "+filepath.Join(dir, "missing.yaml")+"\r")
+	assert.Contains(t, buf.String(), "Error:")
+	assert.Equal(t, "aggressive", ActiveProfile().Name)
+}
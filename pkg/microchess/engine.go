@@ -0,0 +1,331 @@
+// ABOUTME: This file defines the Engine interface used to let the computer choose a move.
+// ABOUTME: It ships the faithful STRATGY-scored engine and the 'H' (hint) and 'M' (engine mode) commands.
+
+package microchess
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SearchResult is what an engine reports after thinking about a position.
+//
+// Each PV move is expressed in the frame of the side playing it, since the
+// board is reversed between plies (exactly as the moves would be entered).
+type SearchResult struct {
+	Move   Move   // Best move found (Piece == NoPiece if the side to move has no legal move)
+	Score  int    // Score of Move from the side to move's point of view, in engine units
+	Depth  int    // Depth of the last completed iteration
+	Nodes  uint64 // Positions visited by the main search
+	QNodes uint64 // Positions visited by the quiescence search
+	PV     []Move // Principal variation, starting with Move
+
+	// Stopped is set when the search was cancelled through its context.
+	// Move is then the best one found so far (NoPiece if none was finished).
+	Stopped bool
+
+	// Threads holds per-thread statistics of a multi-threaded search
+	// (nil when the engine searched on a single thread).
+	Threads []ThreadStats
+
+	// Lines holds the best root moves, best first, when more than one was
+	// asked for (see Analyzer); Lines[0] is Move. nil otherwise.
+	Lines []PVLine
+}
+
+// PVLine is one ranked root move of a multi-PV search.
+type PVLine struct {
+	Move  Move
+	Score int    // From the side to move's point of view, in engine units
+	PV    []Move // Starting with Move
+}
+
+// ThreadStats describes the work done by one thread of a parallel search.
+type ThreadStats struct {
+	Depth    int    // Deepest iteration the thread completed
+	Nodes    uint64 // Positions visited by the main search
+	QNodes   uint64 // Positions visited by the quiescence search
+	TTProbes uint64 // Transposition table lookups
+	TTHits   uint64 // Lookups which found the position
+}
+
+// Progress is what an engine reports to its Observer while thinking.
+type Progress struct {
+	Depth int    // Iteration being searched
+	Nodes uint64 // Positions visited so far (main and quiescence search)
+	Move  Move   // Best move so far (Piece == NoPiece if none yet)
+	Score int    // Score of Move
+}
+
+// Observer receives progress reports from a running search. It is called
+// on the searching goroutine, so it must be quick; a nil Observer is allowed.
+type Observer func(Progress)
+
+// Engine chooses a move for the side to move (the Board array).
+//
+// Engines use the GameState routines (GNM, MOVE, UMOVE, Reverse) to walk the
+// tree and must leave the position exactly as they found it.
+//
+// tc is the time situation of the side to move; engines that search
+// iteratively budget their time from it (see TimeControl.Budget) and
+// always return the best move of the last completed iteration.
+//
+// Cancelling ctx stops the search as soon as possible (the position is
+// still restored); observe, if not nil, is called as the search progresses.
+type Engine interface {
+	Name() string
+	Think(ctx context.Context, g *GameState, tc TimeControl, observe Observer) SearchResult
+}
+
+// Analyzer is an Engine that can rank root moves (multi-PV), as used by
+// the 'A' command and the UCI MultiPV option.
+type Analyzer interface {
+	Engine
+
+	// Analyze is Think for the best n root moves: when n > 1 they are
+	// returned in Lines, best first, each with its score and PV.
+	Analyze(ctx context.Context, g *GameState, tc TimeControl, n int, observe Observer) SearchResult
+}
+
+// DepthSetter is an Engine whose search depth (without a time control)
+// can be changed, as the ':depth' command does.
+type DepthSetter interface {
+	Engine
+	Depth() int
+	SetDepth(plies int) error
+}
+
+// HashSetter is an Engine with a transposition table whose size, in
+// megabytes, can be changed, as the ':hash' command does.
+type HashSetter interface {
+	Engine
+	HashMB() int
+	SetHashMB(mb int) error
+}
+
+// ThreadSetter is an Engine that can think on several threads, whose
+// number can be changed, as the ':threads' command does.
+type ThreadSetter interface {
+	Engine
+	ThreadCount() int
+	SetThreadCount(n int) error
+}
+
+// FaithfulEngine plays in the 1976 style: every legal move is tried with MOVE,
+// the resulting position is scored with the original STRATGY formula, and the
+// highest score wins (the first one in GNM order on ties, like PUSH).
+//
+// The full GO search (JANUS with ON4, TREE and the opening book) is not
+// ported yet; until then this one-ply STRATGY scan stands in for it.
+type FaithfulEngine struct {
+	Eval Evaluator // Scores each candidate; nil uses the game's active evaluator (STRATGY by default)
+
+	// Workers is how many goroutines score the root moves, each on its own
+	// clone (see ScoreRootMoves); 0 or 1 scores them in turn on the game.
+	// The result is the same either way. While a trace is recorded the
+	// moves are scored in turn, since a Trace belongs to one goroutine.
+	Workers int
+}
+
+// Compile-time checks that the faithful engine can rank moves and, by
+// pointer, have its workers set by ':threads'.
+var (
+	_ Analyzer     = FaithfulEngine{}
+	_ ThreadSetter = (*FaithfulEngine)(nil)
+)
+
+// Name implements Engine.
+func (FaithfulEngine) Name() string {
+	return "faithful"
+}
+
+// ThreadCount implements ThreadSetter: the goroutines scoring root moves.
+func (f *FaithfulEngine) ThreadCount() int {
+	return max(1, f.Workers)
+}
+
+// SetThreadCount implements ThreadSetter.
+func (f *FaithfulEngine) SetThreadCount(n int) error {
+	if n < 1 {
+		return fmt.Errorf("threads: %d is not a positive number", n)
+	}
+	f.Workers = n
+	return nil
+}
+
+// Think implements Engine. The one-ply scan is instantaneous, so tc is
+// ignored; ctx is checked and observe called as each root move is scored.
+func (f FaithfulEngine) Think(ctx context.Context, g *GameState, tc TimeControl, observe Observer) SearchResult {
+	return f.Analyze(ctx, g, tc, 1, observe)
+}
+
+// Analyze implements Analyzer: the ranking is simply the candidates sorted
+// by their STRATGY (or evaluator) values, ties kept in GNM order.
+func (f FaithfulEngine) Analyze(ctx context.Context, g *GameState, tc TimeControl, n int, observe Observer) SearchResult {
+	result := SearchResult{Move: Move{Piece: NoPiece}, Depth: 1}
+	ev := f.Eval
+	if ev == nil {
+		ev = g.ActiveEvaluator()
+	}
+	scoreMove := EvaluatorScorer(ev)
+	if observe != nil {
+		scoreMove = reportingScorer(g, scoreMove, observe)
+	}
+	trace := g.ActiveTrace()
+	trace.Enter(g, TraceScan, Move{Piece: NoPiece})
+
+	var scored []ScoredMove
+	if f.Workers > 1 && trace == nil {
+		var err error
+		scored, err = g.ScoreRootMoves(ctx, f.Workers, func() RootScorer { return scoreMove })
+		result.Stopped = err != nil
+	} else {
+		scored, result.Stopped = scanRootMoves(ctx, g, scoreMove, trace)
+	}
+
+	result.Nodes = uint64(len(scored))
+	if best, ok := BestScored(scored); ok {
+		result.Move, result.Score, result.PV = best.Move, best.Score, []Move{best.Move}
+	}
+	trace.Leave(result.Score)
+	if n > 1 {
+		sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+		for _, sm := range scored[:min(n, len(scored))] {
+			result.Lines = append(result.Lines, PVLine{Move: sm.Move, Score: sm.Score, PV: []Move{sm.Move}})
+		}
+	}
+	return result
+}
+
+// reportingScorer wraps score so that observe hears of every root move as
+// soon as it is scored: Nodes counts the moves scored so far and Move is
+// the best of them, the first in GNM order on ties as BestScored picks it.
+// Workers may score moves concurrently, so the reports are serialized.
+func reportingScorer(g *GameState, score RootScorer, observe Observer) RootScorer {
+	moves := g.LegalMoves()
+	var (
+		mu        sync.Mutex
+		nodes     uint64
+		best      = ScoredMove{Move: Move{Piece: NoPiece}}
+		bestIndex = len(moves)
+	)
+	return func(c *GameState, m Move) int {
+		s := score(c, m)
+		i := slices.Index(moves, m)
+		mu.Lock()
+		defer mu.Unlock()
+		nodes++
+		if best.Move.Piece == NoPiece || s > best.Score || (s == best.Score && i < bestIndex) {
+			best, bestIndex = ScoredMove{Move: m, Score: s}, i
+		}
+		observe(Progress{Depth: 1, Nodes: nodes, Move: best.Move, Score: best.Score})
+		return s
+	}
+}
+
+// scanRootMoves scores the legal moves of g in GNM order on g itself,
+// recording each one in trace, and reports whether ctx stopped the scan
+// before the last move.
+func scanRootMoves(ctx context.Context, g *GameState, score RootScorer, trace *Trace) ([]ScoredMove, bool) {
+	var scored []ScoredMove
+	for _, m := range g.LegalMoves() {
+		if ctx.Err() != nil {
+			return scored, true
+		}
+		trace.Enter(g, TraceScan, m)
+		s := score(g, m)
+		trace.Leave(s)
+		scored = append(scored, ScoredMove{Move: m, Score: s})
+	}
+	return scored, false
+}
+
+// SetEngines installs the engines available to the 'H' and 'M' commands.
+// The first one becomes the active engine.
+func (g *GameState) SetEngines(engines ...Engine) {
+	g.engines = engines
+	g.engineIndex = 0
+}
+
+// ActiveEngine returns the engine used by the 'H' command.
+func (g *GameState) ActiveEngine() Engine {
+	if len(g.engines) == 0 {
+		g.engines = []Engine{FaithfulEngine{}}
+	}
+	return g.engines[g.engineIndex]
+}
+
+// NextEngine switches to the next installed engine ('M' command) and returns it.
+func (g *GameState) NextEngine() Engine {
+	active := g.ActiveEngine()
+	if len(g.engines) > 1 {
+		g.engineIndex = (g.engineIndex + 1) % len(g.engines)
+		active = g.engines[g.engineIndex]
+	}
+	return active
+}
+
+// SetObserver installs the progress callback passed to engines by the
+// 'H' command, e.g. to print a progress indicator (nil disables it).
+func (g *GameState) SetObserver(observe Observer) {
+	g.observer = observe
+}
+
+// ShowHint asks the active engine for a move and loads it into the LED
+// display like GO does (DIS1 = piece, DIS2 = from, DIS3 = to), so that
+// pressing Enter plays it. This is the handler for the 'H' command (NEW).
+//
+// When a level is set, the engine thinks within the clock's budget. The
+// time it used is charged to the side to move when that side plays its
+// move: only moves made punch the clock, not every H. Cancelling
+// ctx stops the engine early; the best move found so far is still shown.
+func (g *GameState) ShowHint(ctx context.Context) {
+	engine := g.ActiveEngine()
+	g.beginTrace(engine)
+	start := time.Now()
+	result := engine.Think(ctx, g, g.TimeControl(), g.observer)
+	elapsed := time.Since(start)
+	g.thinking += elapsed
+	if g.observer != nil {
+		_, _ = fmt.Fprint(g.out, "\r\n") // End the progress indicator line
+	}
+	if result.Stopped {
+		_, _ = fmt.Fprintf(g.out, "Search stopped\r\n")
+	}
+	g.endTrace(result)
+
+	if result.Move.Piece != NoPiece {
+		g.DIS1 = uint8(result.Move.Piece)
+		g.DIS2 = uint8(result.Move.From)
+		g.DIS3 = uint8(result.Move.To)
+		g.SelectedPiece = result.Move.Piece
+		g.DigitCount = 4
+	}
+
+	g.Display()
+
+	if result.Move.Piece == NoPiece {
+		if !result.Stopped {
+			_, _ = fmt.Fprintf(g.out, "No legal moves\r\n")
+		}
+		return
+	}
+	_, _ = fmt.Fprintf(g.out, "Best move: %02X %02X score %d\r\n", uint8(result.Move.From), uint8(result.Move.To), result.Score)
+	if g.announce {
+		_, _ = fmt.Fprintf(g.out, "Suggested: %s\r\n", g.Announcement(result.Move))
+	}
+	_, _ = fmt.Fprintf(g.out, "Engine: %s depth %d nodes %d qnodes %d time %dms pv %s\r\n", engine.Name(), result.Depth, result.Nodes, result.QNodes, elapsed.Milliseconds(), formatPV(result.PV))
+	g.showLines(result.Lines)
+	for i, ts := range result.Threads {
+		_, _ = fmt.Fprintf(g.out, "Thread %d: depth %d nodes %d qnodes %d tt hits %d/%d\r\n",
+			i, ts.Depth, ts.Nodes, ts.QNodes, ts.TTHits, ts.TTProbes)
+	}
+	if g.clock != nil && g.clock.Level().MoveTime == 0 {
+		_, _ = fmt.Fprintf(g.out, "Clock: white %v black %v\r\n",
+			g.clock.Remaining(false).Round(time.Second), g.clock.Remaining(true).Round(time.Second))
+	}
+}
@@ -0,0 +1,145 @@
+// ABOUTME: This file contains tests for the Engine interface, the faithful engine and the H/M commands.
+// ABOUTME: It checks that engines pick legal moves, restore the position, and that hints can be played.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedEngine always proposes the same move; used to test engine switching.
+type fixedEngine struct {
+	name string
+	move Move
+}
+
+func (e fixedEngine) Name() string { return e.name }
+
+func (e fixedEngine) Think(ctx context.Context, g *GameState, tc TimeControl, observe Observer) SearchResult {
+	return SearchResult{Move: e.move, Depth: 1, PV: []Move{e.move}}
+}
+
+func TestFaithfulEngine_PicksLegalMoveAndRestoresPosition(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	before := *g
+
+	result := FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil)
+
+	assert.Contains(t, g.LegalMoves(), result.Move)
+	assert.Equal(t, uint64(20), result.Nodes, "one node per legal root move")
+	assert.Equal(t, before.Board, g.Board)
+	assert.Equal(t, before.BK, g.BK)
+	assert.Equal(t, before.Hash, g.Hash)
+}
+
+func TestFaithfulEngine_PrefersCapturingTheQueen(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Board[PiecePawn7] = 0x34
+	g.BK[PieceQueen] = 0x43
+
+	result := FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil)
+
+	assert.Equal(t, Move{From: 0x34, To: 0x43, Piece: PiecePawn7}, result.Move)
+}
+
+// countingEvaluator is STRATGY counting its calls.
+type countingEvaluator struct {
+	StrategyEvaluator
+	calls int
+}
+
+// Score implements Evaluator.
+func (e *countingEvaluator) Score(g *GameState, mc MoveContext) int {
+	e.calls++
+	return e.StrategyEvaluator.Score(g, mc)
+}
+
+func TestFaithfulEngine_ObserverAndCancel(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	var reports []Progress
+	result := FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, func(p Progress) {
+		reports = append(reports, p)
+	})
+	require.Len(t, reports, 20, "one report per root move")
+	assert.Equal(t, result.Move, reports[19].Move)
+	assert.Equal(t, uint64(20), reports[19].Nodes)
+
+	// Each report comes as its move is scored, not in a burst at the end
+	ev := &countingEvaluator{}
+	var scoredBefore []int
+	moves := g.LegalMoves()
+	FaithfulEngine{Eval: ev}.Think(context.Background(), g, TimeControl{}, func(p Progress) {
+		scoredBefore = append(scoredBefore, ev.calls)
+		assert.Contains(t, moves[:p.Nodes], p.Move, "the best of the moves scored so far")
+	})
+	require.Len(t, scoredBefore, 20)
+	for i, calls := range scoredBefore {
+		assert.Equal(t, i+1, calls, "report %d", i+1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result = FaithfulEngine{}.Think(ctx, g, TimeControl{}, nil)
+	assert.True(t, result.Stopped)
+	assert.Equal(t, NoPiece, result.Move.Piece)
+}
+
+func TestHint_CancelledContext(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.True(t, g.HandleCharacterContext(ctx, 'H'), "an aborted search does not quit")
+	assert.Contains(t, buf.String(), "Search stopped")
+	assert.NotContains(t, buf.String(), "No legal moves")
+}
+
+func TestInCheck(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	assert.False(t, g.InCheck())
+
+	g.BK[PieceBishop1] = 0x12 // bishop on c2 attacks the king on d1
+	assert.True(t, g.InCheck())
+	assert.Equal(t, int8(0), g.State, "registers are restored")
+}
+
+func TestHintCanBePlayedWithEnter(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	move := Move{From: 0x14, To: 0x34, Piece: PiecePawn7}
+	g.SetEngines(fixedEngine{name: "fixed", move: move})
+
+	g.HandleCharacter('H')
+	assert.Contains(t, buf.String(), "0E 14 34")
+	assert.Contains(t, buf.String(), "Best move: 14 34")
+
+	g.HandleCharacter('\r')
+	assert.Equal(t, move.To, g.Board[PiecePawn7])
+}
+
+func TestEngineModeSwitch(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	assert.Equal(t, "faithful", g.ActiveEngine().Name(), "default engine")
+
+	g.SetEngines(fixedEngine{name: "first"}, fixedEngine{name: "second"})
+	g.HandleCharacter('M')
+	assert.Equal(t, "second", g.ActiveEngine().Name())
+	assert.Contains(t, buf.String(), "Engine: second")
+
+	g.HandleCharacter('M')
+	require.Equal(t, "first", g.ActiveEngine().Name())
+}
@@ -3,7 +3,11 @@
 
 package microchess
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
 
 // GNMZ clears all evaluation counters and calls GNM (assembly line 280).
 //
@@ -123,18 +127,11 @@ func (g *GameState) COUNTS(captureFlag bool) {
 	if captureFlag {
 		// Search BK array to find which piece is at SQUARE (target square)
 		// Assembly lines 182-187: ELOOP
-		capturedPieceIdx := NoPiece
-		var capturedValue uint8 = 0
-
-		for y := Piece(15); y != 0xFF; y-- { // Loop from 15 down to 0
-			if g.BK[y] == g.MoveSquare {
-				capturedPieceIdx = y
-				capturedValue = POINTS[y]
-				break
-			}
-		}
+		capturedPieceIdx := g.CapturedPiece(g.MoveSquare)
 
 		if capturedPieceIdx != NoPiece {
+			capturedValue := POINTS[capturedPieceIdx]
+
 			// Check if this is the best capture for this state
 			// Assembly lines 188-192
 			// Line 188: LDA POINTS,Y
@@ -161,6 +158,22 @@ func (g *GameState) COUNTS(captureFlag bool) {
 	// We'll implement this later for Phase 8-9 (search)
 }
 
+// CapturedPiece returns the BK piece standing on sq, or NoPiece.
+// This is the ELOOP search from COUNTS (assembly lines 182-187):
+//
+//	ELOOP    CMP BK,Y       ; Compare with SQUARE
+//	         BEQ FOUN       ; Found the captured piece
+//	         DEY
+//	         BPL ELOOP
+func (g *GameState) CapturedPiece(sq board.Square) Piece {
+	for y := Piece(15); y != 0xFF; y-- { // Loop from 15 down to 0
+		if g.BK[y] == sq {
+			return y
+		}
+	}
+	return NoPiece
+}
+
 // STRATGY evaluates the current position and returns a score (0-255).
 //
 // This is the EXACT evaluation formula from the 1976 original (assembly line 641).
@@ -206,29 +219,33 @@ func (g *GameState) COUNTS(captureFlag bool) {
 //	         BEQ POSN
 //	         ... (more center checks)
 //
+// The constants ($80, $40, $90) and the WCAP0, BMAXC and BMCC multipliers
+// come from the active Profile; the default profile uses the values above.
+//
 // Returns: Score 0-255 where higher is better for current side
 //
 // Assembly line: 641-701
 func (g *GameState) STRATGY() uint8 {
 	// Phase 1: Weight 0.25 (assembly lines 641-658)
-	// Start with $80 (128) as neutral base
-	acc := int16(0x80) // Use int16 to handle overflow/underflow
+	// Start with $80 (128) as neutral base (Phase1Base in the active profile)
+	w := strategyWeights
+	acc := int32(w.Phase1Base) // Use int32 to handle overflow/underflow, even with large profile weights
 
 	// Add white advantages
-	acc += int16(g.WMOB)
-	acc += int16(g.WMAXC)
-	acc += int16(g.WCC)
-	acc += int16(g.WCAP1)
-	acc += int16(g.WCAP2)
+	acc += int32(g.WMOB)
+	acc += int32(g.WMAXC)
+	acc += int32(g.WCC)
+	acc += int32(g.WCAP1)
+	acc += int32(g.WCAP2)
 
 	// Subtract position/black advantages
-	acc -= int16(g.PMAXC)
-	acc -= int16(g.PCC)
-	acc -= int16(g.BCAP0)
-	acc -= int16(g.BCAP1)
-	acc -= int16(g.BCAP2)
-	acc -= int16(g.PMOB)
-	acc -= int16(g.BMOB)
+	acc -= int32(g.PMAXC)
+	acc -= int32(g.PCC)
+	acc -= int32(g.BCAP0)
+	acc -= int32(g.BCAP1)
+	acc -= int32(g.BCAP2)
+	acc -= int32(g.PMOB)
+	acc -= int32(g.BMOB)
 
 	// Underflow prevention (assembly lines 656-657: BCS POS / LDA #$00)
 	if acc < 0 {
@@ -239,31 +256,26 @@ func (g *GameState) STRATGY() uint8 {
 	acc = acc >> 1
 
 	// Phase 2: Weight 0.5 (assembly lines 659-665)
-	acc += 0x40 // Add $40 (64)
-	acc += int16(g.WMAXC)
-	acc += int16(g.WCC)
-	acc -= int16(g.BMAXC)
+	acc += int32(w.Phase2Base) // Add $40 (64)
+	acc += int32(g.WMAXC)
+	acc += int32(g.WCC)
+	acc -= int32(g.BMAXC)
 
 	// Divide by 2 (assembly line 665: LSR)
 	acc = acc >> 1
 
 	// Phase 3: Weight 1.0 (assembly lines 666-678)
-	acc += 0x90 // Add $90 (144)
+	acc += int32(w.Phase3Base) // Add $90 (144)
 
 	// 4 * WCAP0 (assembly lines 668-671: add WCAP0 four times)
-	acc += int16(g.WCAP0)
-	acc += int16(g.WCAP0)
-	acc += int16(g.WCAP0)
-	acc += int16(g.WCAP0)
+	acc += int32(w.WCAP0) * int32(g.WCAP0)
 
-	acc += int16(g.WCAP1)
+	acc += int32(g.WCAP1)
 
 	// Subtract black advantages
-	acc -= int16(g.BMAXC)
-	acc -= int16(g.BMAXC) // 2 * BMAXC
-	acc -= int16(g.BMCC)
-	acc -= int16(g.BMCC) // 2 * BMCC
-	acc -= int16(g.BCAP1)
+	acc -= int32(w.BMAXC) * int32(g.BMAXC) // 2 * BMAXC
+	acc -= int32(w.BMCC) * int32(g.BMCC)   // 2 * BMCC
+	acc -= int32(g.BCAP1)
 
 	// Position bonus (assembly lines 679-701)
 	// NOTE: In the original assembly, STRATGY is called during move generation (from ON4)
@@ -289,12 +301,13 @@ func (g *GameState) STRATGY() uint8 {
 	return uint8(acc)
 }
 
-// ShowEvaluation displays position evaluation details and the board.
-// This is a NEW command (not in original) - the 'S' command shows evaluation breakdown.
+// Evaluate fills the White/Black counters for the current position and returns its STRATGY score.
 //
-// It generates all moves with STATE=4 (full analysis), calculates the STRATGY score,
-// and displays mobility, captures, and evaluation breakdown before showing the board.
-func (g *GameState) ShowEvaluation() {
+// It generates all moves with STATE=4 (full analysis) for the side to move
+// (WMOB, WMAXC, WCC, WMAXP), then reverses the board to do the same for the
+// opponent (BMOB, BMAXC, BMCC, BMAXP) and reverses back.
+// The search registers (STATE, PIECE, SQUARE, MOVEN) are preserved.
+func (g *GameState) Evaluate() uint8 {
 	// Save current state
 	savedState := g.State
 	savedMovePiece := g.MovePiece
@@ -303,6 +316,7 @@ func (g *GameState) ShowEvaluation() {
 
 	// Set STATE = 4 for full position analysis
 	g.State = 4
+	g.trace.beginEval()
 
 	// Generate all moves to populate evaluation counters
 	g.GNMZ()
@@ -329,21 +343,41 @@ func (g *GameState) ShowEvaluation() {
 	// Restore white counters
 	g.WMOB, g.WMAXC, g.WCC, g.WMAXP = savedWMOB, savedWMAXC, savedWCC, savedWMAXP
 
+	// Restore state
+	g.State = savedState
+	g.MovePiece = savedMovePiece
+	g.MoveSquare = savedMoveSquare
+	g.MoveN = savedMoveN
+
 	// Evaluate the position
 	score := g.STRATGY()
+	g.trace.evaluated(g, score)
+	return score
+}
+
+// ShowEvaluation displays position evaluation details and the board.
+// This is a NEW command (not in original) - the 'S' command shows evaluation breakdown.
+//
+// It scores the position with the active evaluator (STRATGY, computed by
+// Evaluate, unless SetEvaluator installed another) and displays the score
+// after showing the board. STRATGY scores are shown in hex like the
+// original's registers; other evaluators in decimal with their name.
+// The name of the active profile follows.
+func (g *GameState) ShowEvaluation() {
+	ev := g.ActiveEvaluator()
+	score := ev.Score(g, NoMoveContext)
 
 	// Display the board first
 	g.Display()
 
 	// Then display evaluation breakdown
-	_, _ = fmt.Fprintf(g.out, "Position Evaluation: %x\r\n", score)
+	if _, faithful := ev.(StrategyEvaluator); faithful {
+		_, _ = fmt.Fprintf(g.out, "Position Evaluation: %x\r\n", score)
+	} else {
+		_, _ = fmt.Fprintf(g.out, "Position Evaluation: %d (%s)\r\n", score, ev.Name())
+	}
+	_, _ = fmt.Fprintf(g.out, "Profile: %s\r\n", ActiveProfile().Name)
 	//_, _ = fmt.Fprintf(g.out, "Mobility: W=%d B=%d\r\n", g.WMOB, g.BMOB)
 	//_, _ = fmt.Fprintf(g.out, "Max Capture: W=%d B=%d\r\n", g.WMAXC, g.BMAXC)
 	//_, _ = fmt.Fprintf(g.out, "Capture Count: W=%d B=%d\r\n", g.WCC, g.BMCC)
-
-	// Restore state
-	g.State = savedState
-	g.MovePiece = savedMovePiece
-	g.MoveSquare = savedMoveSquare
-	g.MoveN = savedMoveN
 }
@@ -0,0 +1,270 @@
+// ABOUTME: This file defines the Evaluator interface and its stock implementations.
+// ABOUTME: STRATGY is the faithful reference; material+PST and a weighted composite are modern alternatives.
+
+package microchess
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// PawnValue is the unit of the modern evaluators: a pawn (POINTS value 2) is worth 100.
+const PawnValue = 100
+
+// MoveContext describes the move that led to the position being scored.
+//
+// In the original, STRATGY runs at the end of ON4 with PIECE and SQUARE
+// still describing the candidate move, and uses them for the position bonus.
+// Move is expressed in the frame of the side that played it; Mover tells
+// where that side is now: SideBoard when the position was not reversed
+// after MOVE (as in ON4 and the faithful engine), SideBK when it was (as in
+// the alpha-beta search).
+type MoveContext struct {
+	Move  Move // Piece == NoPiece when there is no move (e.g. the 'S' command)
+	Mover Side
+}
+
+// NoMoveContext is the context used to score a position on its own.
+var NoMoveContext = MoveContext{Move: Move{Piece: NoPiece}}
+
+// Evaluator scores a position from the point of view of the side in the
+// Board array. Higher is better for that side. Evaluators may use any
+// GameState routine but must leave the position as they found it.
+type Evaluator interface {
+	Name() string
+	Score(g *GameState, mc MoveContext) int
+}
+
+// StrategyEvaluator is the 1976 evaluation: the COUNT registers are filled
+// by Evaluate and combined with the STRATGY formula (0-255, neutral
+// around $D0). With a move context the +2 position bonus of assembly
+// lines 679-703 is added, exactly as ON4 would.
+type StrategyEvaluator struct{}
+
+// Name implements Evaluator.
+func (StrategyEvaluator) Name() string {
+	return "strategy"
+}
+
+// Score implements Evaluator.
+func (StrategyEvaluator) Score(g *GameState, mc MoveContext) int {
+	score := int(g.Evaluate())
+	if mc.Move.Piece != NoPiece && mc.Mover == SideBoard {
+		score += PositionBonus(mc.Move)
+	}
+	return score
+}
+
+// PositionBonus returns the STRATGY position bonus for a move: 2 for a
+// move to one of the centre squares $22, $25, $33, $34, or for moving a
+// piece other than the king out of the back rank, 0 otherwise.
+// STRATGY runs after UMOVE, so BOARD,X is the square the piece came from.
+//
+// Assembly reference (lines 679-703):
+//
+//	LDX SQUARE / CPX #$33 / BEQ POSN ... CPX #$25 / BEQ POSN
+//	LDX PIECE  / BEQ NOPOSN
+//	LDY BOARD,X / CPY #$10 / BPL NOPOSN
+//	POSN CLC / ADC #$02
+func PositionBonus(m Move) int {
+	switch m.To {
+	case 0x33, 0x34, 0x22, 0x25:
+		return 2
+	}
+	if m.Piece != PieceKing && m.From < 0x10 {
+		return 2
+	}
+	return 0
+}
+
+// PieceSquareTable holds a bonus per piece kind and square, in PawnValue
+// units, seen from the side to move: rank 0 is its own back rank.
+type PieceSquareTable [6][64]int
+
+// bonus returns the table entry for a piece of kind k on sq.
+func (t *PieceSquareTable) bonus(k Kind, sq board.Square) int {
+	return t[k][int(sq>>4)*8+int(sq&7)]
+}
+
+// DefaultPST rewards central pieces and advanced pawns, and keeps the king home.
+var DefaultPST = &PieceSquareTable{
+	KindKing: {
+		10, 15, 5, 0, 0, 5, 15, 10,
+		0, 0, -5, -10, -10, -5, 0, 0,
+		-10, -15, -20, -25, -25, -20, -15, -10,
+		-20, -25, -30, -35, -35, -30, -25, -20,
+		-30, -35, -40, -45, -45, -40, -35, -30,
+		-30, -35, -40, -45, -45, -40, -35, -30,
+		-30, -35, -40, -45, -45, -40, -35, -30,
+		-30, -35, -40, -45, -45, -40, -35, -30,
+	},
+	KindQueen: {
+		-10, -5, -5, 0, 0, -5, -5, -10,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, 0,
+		0, 0, 5, 5, 5, 5, 0, 0,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-10, -5, -5, 0, 0, -5, -5, -10,
+	},
+	KindRook: {
+		0, 0, 0, 5, 5, 0, 0, 0,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		10, 15, 15, 15, 15, 15, 15, 10,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	KindBishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	KindKnight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	KindPawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+}
+
+// MaterialPSTEvaluator counts POINTS for the pieces on the board (scaled
+// so that a pawn is PawnValue) plus, if PST is set, a piece-square bonus.
+// The score is the side to move's total minus the opponent's, so it is
+// antisymmetric: Score after Reverse is the negated score.
+type MaterialPSTEvaluator struct {
+	PST *PieceSquareTable // nil counts material only
+}
+
+// Name implements Evaluator.
+func (e MaterialPSTEvaluator) Name() string {
+	if e.PST == nil {
+		return "material"
+	}
+	return "material+pst"
+}
+
+// Score implements Evaluator. The move context is not used.
+func (e MaterialPSTEvaluator) Score(g *GameState, mc MoveContext) int {
+	score := 0
+	for p := Piece(0); p < 16; p++ {
+		if sq := g.Board[p]; sq.IsValid() {
+			score += int(POINTS[p]) * PawnValue / int(POINTS[PiecePawn1])
+			if e.PST != nil {
+				score += e.PST.bonus(p.Kind(), sq)
+			}
+		}
+		if sq := g.BK[p]; sq.IsValid() {
+			score -= int(POINTS[p]) * PawnValue / int(POINTS[PiecePawn1])
+			if e.PST != nil {
+				score -= e.PST.bonus(p.Kind(), 0x77-sq) // The opponent's own frame
+			}
+		}
+	}
+	return score
+}
+
+// PieceValue implements PieceValuer: POINTS scaled so that a pawn is
+// PawnValue, the piece-square bonus left out.
+func (e MaterialPSTEvaluator) PieceValue(p Piece) (int, bool) {
+	return int(POINTS[p&0x0F]) * PawnValue / int(POINTS[PiecePawn1]), true
+}
+
+// WeightedTerm is one evaluator of a WeightedEvaluator with its weight in percent.
+type WeightedTerm struct {
+	Evaluator Evaluator
+	Weight    int
+}
+
+// WeightedEvaluator combines several evaluators: the score is the sum of
+// each term's score times its weight, divided by 100.
+type WeightedEvaluator struct {
+	Terms []WeightedTerm
+}
+
+// Name implements Evaluator, e.g. "strategy*50+material+pst*100".
+func (e WeightedEvaluator) Name() string {
+	names := make([]string, len(e.Terms))
+	for i, t := range e.Terms {
+		names[i] = fmt.Sprintf("%s*%d", t.Evaluator.Name(), t.Weight)
+	}
+	return strings.Join(names, "+")
+}
+
+// Score implements Evaluator.
+func (e WeightedEvaluator) Score(g *GameState, mc MoveContext) int {
+	total := 0
+	for _, t := range e.Terms {
+		total += t.Weight * t.Evaluator.Score(g, mc)
+	}
+	return total / 100
+}
+
+// PieceValue implements PieceValuer when every term does: the weighted sum
+// of the terms' values.
+func (e WeightedEvaluator) PieceValue(p Piece) (int, bool) {
+	total := 0
+	for _, t := range e.Terms {
+		v, ok := PieceValueOf(t.Evaluator, p)
+		if !ok {
+			return 0, false
+		}
+		total += t.Weight * v
+	}
+	return total / 100, true
+}
+
+// PieceValuer is implemented by evaluators whose scores count material
+// (NEW - not in original). PieceValue is what losing piece p costs in the
+// evaluator's units, if its scores have such a scale; STRATGY has none.
+// The search uses it to size delta pruning.
+type PieceValuer interface {
+	PieceValue(p Piece) (int, bool)
+}
+
+// PieceValueOf returns ev's value of piece p, if ev is a PieceValuer with
+// a material scale.
+func PieceValueOf(ev Evaluator, p Piece) (int, bool) {
+	if pv, ok := ev.(PieceValuer); ok {
+		return pv.PieceValue(p)
+	}
+	return 0, false
+}
+
+// SetEvaluator installs the evaluator used by the 'S' command and the
+// faithful engine's scorer (nil restores STRATGY).
+func (g *GameState) SetEvaluator(ev Evaluator) {
+	g.evaluator = ev
+}
+
+// ActiveEvaluator returns the evaluator installed with SetEvaluator, STRATGY by default.
+func (g *GameState) ActiveEvaluator() Evaluator {
+	if g.evaluator == nil {
+		return StrategyEvaluator{}
+	}
+	return g.evaluator
+}
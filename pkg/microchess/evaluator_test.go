@@ -0,0 +1,126 @@
+// ABOUTME: This file contains tests for the Evaluator interface and the stock evaluators.
+// ABOUTME: It checks STRATGY equivalence, the position bonus, material+PST symmetry and weighting.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategyEvaluator_MatchesEvaluate(t *testing.T) {
+	for _, g := range playedPositions(t) {
+		want := int(g.Evaluate())
+		assert.Equal(t, want, StrategyEvaluator{}.Score(g, NoMoveContext))
+	}
+}
+
+func TestPositionBonus(t *testing.T) {
+	tests := []struct {
+		name string
+		move Move
+		want int
+	}{
+		{"to the centre", Move{From: 0x23, To: 0x33, Piece: PiecePawn8}, 2},
+		{"knight out of the back rank", Move{From: 0x06, To: 0x25, Piece: PieceKnight2}, 2},
+		{"bishop out of the back rank", Move{From: 0x05, To: 0x16, Piece: PieceBishop2}, 2},
+		{"king out of the back rank", Move{From: 0x03, To: 0x13, Piece: PieceKing}, 0},
+		{"quiet pawn move", Move{From: 0x10, To: 0x20, Piece: PiecePawn1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PositionBonus(tt.move))
+		})
+	}
+}
+
+func TestStrategyEvaluator_BonusOnlyForTheMover(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	m := Move{From: 0x06, To: 0x25, Piece: PieceKnight2}
+	base := StrategyEvaluator{}.Score(g, NoMoveContext)
+
+	assert.Equal(t, base+2, StrategyEvaluator{}.Score(g, MoveContext{Move: m, Mover: SideBoard}))
+	assert.Equal(t, base, StrategyEvaluator{}.Score(g, MoveContext{Move: m, Mover: SideBK}))
+}
+
+func TestMaterialPSTEvaluator_IsAntisymmetric(t *testing.T) {
+	evaluators := []Evaluator{MaterialPSTEvaluator{}, MaterialPSTEvaluator{PST: DefaultPST}}
+	for _, g := range playedPositions(t) {
+		for _, ev := range evaluators {
+			score := ev.Score(g, NoMoveContext)
+			g.Reverse()
+			assert.Equal(t, -score, ev.Score(g, NoMoveContext), ev.Name())
+			g.Reverse()
+		}
+	}
+
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	assert.Equal(t, 0, MaterialPSTEvaluator{PST: DefaultPST}.Score(g, NoMoveContext), "the start is balanced")
+	g.BK[PieceQueen] = 0xCC
+	assert.Equal(t, 5*PawnValue, MaterialPSTEvaluator{}.Score(g, NoMoveContext))
+}
+
+func TestWeightedEvaluator(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.BK[PieceQueen] = 0xCC
+	ev := WeightedEvaluator{Terms: []WeightedTerm{
+		{Evaluator: MaterialPSTEvaluator{}, Weight: 50},
+		{Evaluator: StrategyEvaluator{}, Weight: 200},
+	}}
+
+	want := (50*MaterialPSTEvaluator{}.Score(g, NoMoveContext) + 200*StrategyEvaluator{}.Score(g, NoMoveContext)) / 100
+	assert.Equal(t, want, ev.Score(g, NoMoveContext))
+	assert.Equal(t, "material*50+strategy*200", ev.Name())
+}
+
+func TestPieceValueOf(t *testing.T) {
+	v, ok := PieceValueOf(MaterialPSTEvaluator{PST: DefaultPST}, PiecePawn3)
+	assert.True(t, ok)
+	assert.Equal(t, PawnValue, v)
+	v, _ = PieceValueOf(MaterialPSTEvaluator{}, PieceQueen)
+	assert.Equal(t, int(POINTS[PieceQueen])*PawnValue/int(POINTS[PiecePawn1]), v)
+
+	_, ok = PieceValueOf(StrategyEvaluator{}, PiecePawn1)
+	assert.False(t, ok, "STRATGY has no material scale")
+
+	w := WeightedEvaluator{Terms: []WeightedTerm{{Evaluator: MaterialPSTEvaluator{}, Weight: 150}}}
+	v, ok = PieceValueOf(w, PiecePawn1)
+	assert.True(t, ok)
+	assert.Equal(t, 150, v)
+	w.Terms = append(w.Terms, WeightedTerm{Evaluator: StrategyEvaluator{}, Weight: 100})
+	_, ok = PieceValueOf(w, PiecePawn1)
+	assert.False(t, ok, "one term without a scale is enough")
+}
+
+func TestShowEvaluation_UsesActiveEvaluator(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+
+	g.HandleCharacter('S')
+	assert.Contains(t, buf.String(), "Position Evaluation: d0\r\n", "STRATGY by default, in hex")
+
+	buf.Reset()
+	g.SetEvaluator(MaterialPSTEvaluator{})
+	g.BK[PieceQueen] = 0xCC
+	g.HandleCharacter('S')
+	assert.Contains(t, buf.String(), "Position Evaluation: 500 (material)\r\n")
+}
+
+func TestFaithfulEngine_UsesItsEvaluator(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Board[PiecePawn7] = 0x34
+	g.BK[PieceQueen] = 0x43
+
+	result := FaithfulEngine{Eval: MaterialPSTEvaluator{PST: DefaultPST}}.Think(context.Background(), g, TimeControl{}, nil)
+	require.NotEqual(t, NoPiece, result.Move.Piece)
+	assert.Equal(t, Move{From: 0x34, To: 0x43, Piece: PiecePawn7}, result.Move)
+}
@@ -0,0 +1,238 @@
+// ABOUTME: This file converts between GameState and Forsyth-Edwards Notation (FEN/EPD).
+// ABOUTME: Pieces are assigned to the Board/BK slots of the 1976 setup so that POINTS values stay right.
+
+package microchess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// InitialFEN is the position set up by the C command.
+//
+// Squares are named like the board package does (column 0 is file a), so
+// the 1976 setup, with the king on column 3, reads RNBKQBNR. A standard
+// FEN start position loads with king and queen exchanged, which is the
+// same game mirrored, since MicroChess has no castling.
+const InitialFEN = "rnbkqbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKQBNR w - - 0 1"
+
+// fenLetters maps FEN piece letters (upper case) to kinds.
+var fenLetters = map[byte]Kind{
+	'K': KindKing, 'Q': KindQueen, 'R': KindRook,
+	'B': KindBishop, 'N': KindKnight, 'P': KindPawn,
+}
+
+// fenPiece is a piece read from a FEN placement field.
+type fenPiece struct {
+	kind Kind
+	sq   board.Square
+}
+
+// SetFEN replaces the position with the one described by fen. The
+// castling and en passant fields are ignored, as MicroChess has neither;
+// the move counters are kept for FEN and default to "0 1". EPD lines are
+// accepted too, their operations are ignored.
+//
+// White is put in the Board array and black in BK, then the board is
+// reversed if black is to move, so Reversed keeps meaning "black to move".
+// Each piece takes the slot whose 1976 setup square is on the same column
+// when it is free (so InitialFEN reproduces SetupBoard exactly), and the
+// first free slot of its kind otherwise. Unused slots are marked captured
+// ($CC). The move history is cleared.
+func (g *GameState) SetFEN(fen string) error {
+	fields := strings.Fields(fen)
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid FEN %q: want at least placement and side to move", fen)
+	}
+
+	var placed [2][]fenPiece
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("invalid FEN placement %q: want 8 ranks", fields[0])
+	}
+	for i, row := range ranks {
+		rank := 7 - i
+		file := 0
+		for j := 0; j < len(row); j++ {
+			c := row[j]
+			if c >= '1' && c <= '8' {
+				file += int(c - '0')
+				continue
+			}
+			side := SideBK
+			if c >= 'A' && c <= 'Z' {
+				side = SideBoard
+			}
+			kind, ok := fenLetters[strings.ToUpper(string(c))[0]]
+			if !ok {
+				return fmt.Errorf("invalid FEN piece %q", c)
+			}
+			if file > 7 {
+				return fmt.Errorf("invalid FEN rank %q: want 8 squares", row)
+			}
+			if kind == KindPawn && (rank == 0 || rank == 7) {
+				return fmt.Errorf("invalid FEN: pawn on rank %d", rank+1)
+			}
+			placed[side] = append(placed[side], fenPiece{kind, board.Square(rank<<4 | file)})
+			file++
+		}
+		if file != 8 {
+			return fmt.Errorf("invalid FEN rank %q: want 8 squares", row)
+		}
+	}
+
+	var black bool
+	switch fields[1] {
+	case "w":
+	case "b":
+		black = true
+	default:
+		return fmt.Errorf("invalid FEN side to move %q", fields[1])
+	}
+
+	var lists [2][16]board.Square
+	for side := range placed {
+		for i := range lists[side] {
+			lists[side][i] = 0xCC
+		}
+		// First the pieces standing on their setup column, then the others
+		var rest []int
+		for i, p := range placed[side] {
+			if slot, ok := setupSlot(p.kind, p.sq, &lists[side]); ok {
+				lists[side][slot] = p.sq
+			} else {
+				rest = append(rest, i)
+			}
+		}
+		for _, i := range rest {
+			p := placed[side][i]
+			slot, ok := freeSlot(p.kind, &lists[side])
+			if !ok {
+				return fmt.Errorf("invalid FEN: too many %s pieces of kind %s", []string{"white", "black"}[side], p.kind.Letter())
+			}
+			lists[side][slot] = p.sq
+		}
+		if lists[side][PieceKing] == 0xCC {
+			return fmt.Errorf("invalid FEN: %s has no king", []string{"white", "black"}[side])
+		}
+	}
+
+	// With white to move both lists hold absolute squares, as after SetupBoard
+	g.Board = lists[SideBoard]
+	g.BK = lists[SideBK]
+	g.Reversed = false
+	g.MoveHistory = nil
+	g.lastMove = Move{Piece: NoPiece}
+	g.Hash = g.ComputeHash()
+	if black {
+		g.Reverse()
+	}
+	g.recordClock, g.recordNumber = fenCounters(fields)
+	g.startRecord()
+	return nil
+}
+
+// fenCounters returns the halfmove clock and fullmove number of the FEN
+// fields, or 0 and 1 when they are missing (as in EPD) or out of range.
+func fenCounters(fields []string) (clock, number int) {
+	if len(fields) < 6 {
+		return 0, 1
+	}
+	clock, err1 := strconv.Atoi(fields[4])
+	number, err2 := strconv.Atoi(fields[5])
+	if err1 != nil || err2 != nil || clock < 0 || number < 1 {
+		return 0, 1
+	}
+	return clock, number
+}
+
+// setupSlot returns the free slot of the given kind whose InitialSetup
+// square is on the same column as sq.
+func setupSlot(kind Kind, sq board.Square, list *[16]board.Square) (Piece, bool) {
+	for p := Piece(0); p < 16; p++ {
+		if p.Kind() == kind && list[p] == 0xCC && InitialSetup[p]&0x07 == sq&0x07 {
+			return p, true
+		}
+	}
+	return NoPiece, false
+}
+
+// freeSlot returns the first free slot of the given kind.
+func freeSlot(kind Kind, list *[16]board.Square) (Piece, bool) {
+	for p := Piece(0); p < 16; p++ {
+		if p.Kind() == kind && list[p] == 0xCC {
+			return p, true
+		}
+	}
+	return NoPiece, false
+}
+
+// FEN returns the position in Forsyth-Edwards Notation, with the squares
+// named as in InitialFEN. Castling and en passant are always "-". The move
+// counters follow the game record from the position set up: the halfmove
+// clock restarts at pawn moves and captures, the fullmove number goes up
+// after each black move.
+func (g *GameState) FEN() string {
+	// Absolute squares: undo the reversal when black is to move
+	white, black := g.Board, g.BK
+	if g.Reversed {
+		white, black = g.BK, g.Board
+		for i := range white {
+			white[i], black[i] = 0x77-white[i], 0x77-black[i]
+		}
+	}
+
+	var grid [8][8]byte
+	for p := Piece(0); p < 16; p++ {
+		letter := p.Kind().Letter()[0]
+		if sq := white[p]; sq.IsValid() {
+			grid[sq>>4][sq&7] = letter
+		}
+		if sq := black[p]; sq.IsValid() {
+			grid[sq>>4][sq&7] = letter + 'a' - 'A'
+		}
+	}
+
+	var sb strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			if grid[rank][file] == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(grid[rank][file])
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	side := "w"
+	if g.Reversed {
+		side = "b"
+	}
+	clock, number := g.recordClock, g.recordNumber
+	for _, m := range g.record {
+		// SAN names pawn moves by their file, a-h, and captures with an x
+		if m.SAN[0] >= 'a' && m.SAN[0] <= 'h' || strings.Contains(m.SAN, "x") {
+			clock = 0
+		} else {
+			clock++
+		}
+		if !m.White {
+			number++
+		}
+	}
+	fmt.Fprintf(&sb, " %s - - %d %d", side, clock, number)
+	return sb.String()
+}
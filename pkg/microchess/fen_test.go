@@ -0,0 +1,111 @@
+// ABOUTME: This file contains tests for FEN import and export.
+// ABOUTME: It checks that InitialFEN matches SetupBoard, round trips, and rejects malformed input.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFEN_InitialMatchesSetup(t *testing.T) {
+	var buf bytes.Buffer
+	want := NewGame(&buf)
+	want.SetupBoard()
+
+	g := NewGame(&buf)
+	require.NoError(t, g.SetFEN(InitialFEN))
+	assert.Equal(t, want.Board, g.Board)
+	assert.Equal(t, want.BK, g.BK)
+	assert.Equal(t, want.Hash, g.Hash)
+	assert.Equal(t, InitialFEN, want.FEN())
+}
+
+func TestSetFEN_BlackToMove(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	require.NoError(t, g.SetFEN("rnbkqbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBKQBNR b - - 0 1"))
+
+	assert.True(t, g.IsReversed())
+	assert.Equal(t, g.ComputeHash(), g.Hash)
+	assert.Len(t, g.LegalMoves(), 20)
+	// Black's king is on d8: $73 absolute, $04 in black's frame
+	assert.Equal(t, uint8(0x04), uint8(g.Board[PieceKing]))
+}
+
+func TestFEN_RoundTrip(t *testing.T) {
+	fens := []string{
+		"4k3/8/8/3q4/8/8/8/4K3 b - - 0 1",
+		"r1bk1bnr/pp1ppppp/2n5/2p5/4P3/5N2/PPPP1PPP/RNBKQB1R w - - 0 1",
+		"8/3k4/8/8/8/8/PPPPPPPP/K7 w - - 0 1",
+		"4k3/8/8/3q4/8/8/8/4K3 b - - 7 23",
+	}
+	for _, fen := range fens {
+		var buf bytes.Buffer
+		g := NewGame(&buf)
+		require.NoError(t, g.SetFEN(fen), fen)
+		assert.Equal(t, fen, g.FEN())
+	}
+}
+
+func TestFEN_AfterMoves(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.HandleCharacter('C')
+	for _, c := range "1434\r" {
+		g.HandleCharacter(byte(c))
+	}
+	assert.Equal(t, "rnbkqbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBKQBNR w - - 0 1", g.FEN())
+}
+
+func TestFEN_MoveCounters(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	play := func(san string) error {
+		m, err := g.ParseMove(san)
+		if err == nil {
+			g.PlayMove(m)
+		}
+		return err
+	}
+	require.NoError(t, g.SetFEN(StandardFEN))
+	require.NoError(t, play("e4"))
+	require.NoError(t, play("e5"))
+	assert.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w - - 0 2", g.FEN())
+	require.NoError(t, play("Nf3"))
+	require.NoError(t, play("Nc6"))
+	assert.Equal(t, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w - - 2 3", g.FEN(), "piece moves advance the clock")
+
+	require.NoError(t, g.SetFEN("4k3/8/8/3q4/8/8/8/3QK3 w - - 7 23"))
+	require.NoError(t, play("Kf1"))
+	assert.Equal(t, "4k3/8/8/3q4/8/8/8/3Q1K2 b - - 8 23", g.FEN(), "the counters go on from the FEN")
+	require.NoError(t, play("Qxd1+"))
+	assert.Equal(t, "4k3/8/8/8/8/8/8/3q1K2 w - - 0 24", g.FEN(), "a capture restarts the clock")
+
+	require.NoError(t, g.SetFEN("4k3/8/8/8/8/8/8/4K3 w - - bm Kd2;"))
+	assert.Equal(t, "4k3/8/8/8/8/8/8/4K3 w - - 0 1", g.FEN(), "EPD has no counters")
+}
+
+func TestSetFEN_Errors(t *testing.T) {
+	tests := []struct {
+		name, fen string
+	}{
+		{"no side to move", "4k3/8/8/8/8/8/8/4K3"},
+		{"seven ranks", "4k3/8/8/8/8/8/4K3 w"},
+		{"long rank", "4k4/8/8/8/8/8/8/4K3 w"},
+		{"short rank", "4k2/8/8/8/8/8/8/4K3 w"},
+		{"bad piece", "4x3/8/8/8/8/8/8/4K3 w"},
+		{"bad side", "4k3/8/8/8/8/8/8/4K3 x"},
+		{"pawn on the back rank", "4k2P/8/8/8/8/8/8/4K3 w"},
+		{"no king", "8/8/8/8/8/8/8/4K3 w"},
+		{"two queens", "4k3/8/8/8/8/8/8/2QQK3 w"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Error(t, NewGame(&buf).SetFEN(tt.fen))
+		})
+	}
+}
@@ -53,6 +53,28 @@ func (g *GameState) janusCheckDetection() bool {
 	return true
 }
 
+// janus routes a legal move the way JANUS does (assembly lines 162-234):
+// check detection when STATE == -7, the caller's callback when one is given
+// (e.g. the 'L' command), and COUNTS for STATE 0-12.
+// While capturesOnly is set (see GNMCaptures) the callback only sees captures.
+func (g *GameState) janus(callback MoveCallback, from board.Square, capture bool) {
+	g.trace.janusRan(g.State) // Traced searches record the STATE walk (NEW)
+	if g.janusCheckDetection() {
+		// STATE == -7: Check detection mode
+		// janusCheckDetection() sets InChek if king can be captured
+	} else if callback != nil {
+		// User mode: call provided callback
+		// Check callback first so it takes priority over COUNTS
+		if capture || !g.capturesOnly {
+			callback(from, g.MoveSquare, g.MovePiece)
+		}
+	} else if g.State >= 0 && g.State <= 12 {
+		// STATE in range 0-12: Call COUNTS for evaluation
+		// This is the JANUS -> COUNTS path (assembly line 169)
+		g.COUNTS(capture)
+	}
+}
+
 // Reset restores MoveSquare to the current piece's board position.
 // This implements the RESET routine from assembly line 473.
 //
@@ -92,18 +114,7 @@ func (g *GameState) singleMove(callback MoveCallback) bool {
 	if !result.Illegal && !result.InCheck {
 		// JANUS routing (assembly line 162-234)
 		// Routes based on STATE value to different analysis functions
-		if g.janusCheckDetection() {
-			// STATE == -7: Check detection mode
-			// janusCheckDetection() sets InChek if king can be captured
-		} else if callback != nil {
-			// User mode (e.g., 'L' command): call provided callback
-			// Check callback first so it takes priority over COUNTS
-			callback(fromSquare, g.MoveSquare, g.MovePiece)
-		} else if g.State >= 0 && g.State <= 12 {
-			// STATE in range 0-12: Call COUNTS for evaluation
-			// This is the JANUS -> COUNTS path (assembly line 169)
-			g.COUNTS(result.Capture)
-		}
+		g.janus(callback, fromSquare, result.Capture)
 	}
 
 	// Restore piece position
@@ -155,15 +166,7 @@ func (g *GameState) slidingLine(callback MoveCallback) bool {
 		}
 
 		// Legal move - process it via JANUS routing
-		if g.janusCheckDetection() {
-			// STATE == -7: Check detection mode
-		} else if callback != nil {
-			// User mode: call provided callback (check callback first)
-			callback(fromSquare, g.MoveSquare, g.MovePiece)
-		} else if g.State >= 0 && g.State <= 12 {
-			// STATE in range 0-12: Call COUNTS for evaluation
-			g.COUNTS(result.Capture)
-		}
+		g.janus(callback, fromSquare, result.Capture)
 
 		// If capture, stop sliding (assembly: BVC LINE - branch if V clear)
 		if result.Capture {
@@ -308,14 +311,8 @@ func (g *GameState) generatePawnMoves(callback MoveCallback) {
 	g.MoveN = 6
 	result := g.CMOVE(g.MoveSquare, g.MoveN)
 	if result.Capture && !result.Illegal && !result.InCheck {
-		// JANUS routing
-		if g.janusCheckDetection() {
-			// Check detection mode
-		} else if callback != nil {
-			callback(fromSquare, g.MoveSquare, g.MovePiece)
-		} else if g.State >= 0 && g.State <= 12 {
-			g.COUNTS(result.Capture)
-		}
+		// JANUS routing (assembly line 162-234)
+		g.janus(callback, fromSquare, result.Capture)
 	}
 
 	// Try left diagonal capture (MOVEN=5)
@@ -323,14 +320,8 @@ func (g *GameState) generatePawnMoves(callback MoveCallback) {
 	g.MoveN = 5
 	result = g.CMOVE(g.MoveSquare, g.MoveN)
 	if result.Capture && !result.Illegal && !result.InCheck {
-		// JANUS routing
-		if g.janusCheckDetection() {
-			// Check detection mode
-		} else if callback != nil {
-			callback(fromSquare, g.MoveSquare, g.MovePiece)
-		} else if g.State >= 0 && g.State <= 12 {
-			g.COUNTS(result.Capture)
-		}
+		// JANUS routing (assembly line 162-234)
+		g.janus(callback, fromSquare, result.Capture)
 	}
 
 	// Try forward move(s) (MOVEN=4)
@@ -346,13 +337,7 @@ func (g *GameState) generatePawnMoves(callback MoveCallback) {
 		}
 
 		// Legal forward move - JANUS routing
-		if g.janusCheckDetection() {
-			// Check detection mode
-		} else if callback != nil {
-			callback(fromSquare, g.MoveSquare, g.MovePiece)
-		} else if g.State >= 0 && g.State <= 12 {
-			g.COUNTS(result.Capture)
-		}
+		g.janus(callback, fromSquare, result.Capture)
 
 		// Check if on rank 2 (can do double move)
 		// Assembly: AND #$F0 / CMP #$20
@@ -458,3 +443,13 @@ func (g *GameState) GNM(callback MoveCallback) {
 		}
 	}
 }
+
+// GNMCaptures runs GNM but only reports moves for which CMOVE set the
+// capture (V) flag. This is NEW (not in original): it is the move generator
+// of the quiescence search, which follows capture sequences the way TREE
+// does (assembly line 236) instead of stopping at a fixed depth.
+func (g *GameState) GNMCaptures(callback MoveCallback) {
+	g.capturesOnly = true
+	defer func() { g.capturesOnly = false }()
+	g.GNM(callback)
+}
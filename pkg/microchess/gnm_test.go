@@ -341,3 +341,39 @@ func TestGNM_BlockedPieces(t *testing.T) {
 		}
 	}
 }
+
+// TestGNMCaptures_OnlyCaptures verifies that GNMCaptures reports the two pawn
+// captures of TestGNM_PawnCapture but not the quiet push to e5.
+func TestGNMCaptures_OnlyCaptures(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+
+	for i := 0; i < 16; i++ {
+		g.Board[i] = 0xCC
+		g.BK[i] = 0xCC
+	}
+	g.Board[PiecePawn7] = 0x34 // e4
+	g.BK[8] = 0x43             // d5
+	g.BK[9] = 0x45             // f5
+
+	var moves []Move
+	g.GNMCaptures(func(from, to board.Square, piece Piece) {
+		moves = append(moves, Move{From: from, To: to, Piece: piece})
+	})
+
+	if len(moves) != 2 {
+		t.Fatalf("expected 2 captures, got %d: %v", len(moves), moves)
+	}
+	for _, m := range moves {
+		if m.To != 0x43 && m.To != 0x45 {
+			t.Errorf("unexpected non-capture %02X -> %02X", m.From, m.To)
+		}
+	}
+
+	// The filter must not leak into the next full generation
+	count := 0
+	g.GNM(func(from, to board.Square, piece Piece) { count++ })
+	if count != 3 {
+		t.Errorf("GNM after GNMCaptures should generate 3 moves, got %d", count)
+	}
+}
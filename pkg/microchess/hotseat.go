@@ -0,0 +1,82 @@
+// ABOUTME: This file implements hot-seat mode: two players share the keyboard and take turns.
+// ABOUTME: Enter accepts legal moves only and reverses the board, so each side moves from the bottom.
+
+package microchess
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetHotSeat turns hot-seat mode on or off (NEW - not in original). In
+// hot-seat mode two people play each other: Enter only plays a legal move
+// of the side to move (one of LegalMoves, so CHKCHK applies), then
+// reverses the board as E would, so that the other side moves next from
+// its own first ranks, squares 00-1x, as white does in 1976 (POUT draws
+// them at the top). Display shows whose turn it is, and the end of the
+// game.
+func (g *GameState) SetHotSeat(on bool) {
+	g.hotSeat = on
+}
+
+// HotSeat reports whether hot-seat mode is on.
+func (g *GameState) HotSeat() bool {
+	return g.hotSeat
+}
+
+// TurnStatus describes the side to move: "White to move", "Black to move,
+// check", "Checkmate, white wins" or "Stalemate, draw"; "" when no board
+// is set up.
+func (g *GameState) TurnStatus() string {
+	if g.recordStart == "" {
+		return ""
+	}
+	side, other := "White", "black"
+	if g.Reversed {
+		side, other = "Black", "white"
+	}
+	inCheck := g.InCheck()
+	if len(g.LegalMoves()) == 0 {
+		if inCheck {
+			return "Checkmate, " + other + " wins"
+		}
+		return "Stalemate, draw"
+	}
+	if inCheck {
+		return side + " to move, check"
+	}
+	return side + " to move"
+}
+
+// playTypedMove plays the move on the LEDs (DIS2 to DIS3, stored squares)
+// in hot-seat mode, if it is legal.
+func (g *GameState) playTypedMove() {
+	from, to := g.DIS2, g.DIS3
+	g.DigitCount = 0
+	for _, m := range g.LegalMoves() {
+		if uint8(m.From) == from && uint8(m.To) == to {
+			g.PlayMove(m)
+			return
+		}
+	}
+	g.DIS1 = 0xFF
+	_, _ = fmt.Fprintf(g.out, "Illegal move %02X %02X (L lists the legal moves)\r\n", from, to)
+}
+
+// cmdHotSeat implements :hotseat.
+func cmdHotSeat(_ context.Context, g *GameState, _ byte, args []string) error {
+	on, err := onOff(args, g.hotSeat, "usage: :hotseat [on|off]")
+	if err != nil {
+		return err
+	}
+	g.SetHotSeat(on)
+	if !on {
+		_, _ = fmt.Fprint(g.out, "Hot seat: off\r\n")
+		return nil
+	}
+	_, _ = fmt.Fprint(g.out, "Hot seat: on, legal moves only, the board turns after each\r\n")
+	if status := g.TurnStatus(); status != "" {
+		_, _ = fmt.Fprintf(g.out, "%s\r\n", status)
+	}
+	return nil
+}
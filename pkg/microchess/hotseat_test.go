@@ -0,0 +1,87 @@
+// ABOUTME: This file contains tests for hot-seat mode, where two players take turns.
+// ABOUTME: It checks legality, the reversal after each move, the turn shown and the end of the game.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotSeat_TakingTurns(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetHotSeat(true)
+	typeKeys(g, "C")
+	assert.Contains(t, buf.String(), "White to move\r\n")
+
+	// Each side types its move from the bottom of the board: d2-d4 is
+	// 13 33 for white, and d7-d5 is 14 34 for black on the reversed board
+	buf.Reset()
+	typeKeys(g, "1333\r")
+	assert.True(t, g.Reversed, "the board turns after a legal move")
+	assert.Contains(t, buf.String(), "Black to move\r\n")
+
+	typeKeys(g, "1434\r")
+	assert.False(t, g.Reversed)
+	assert.Equal(t, []string{"d4", "d5"}, []string{g.Record()[0].SAN, g.Record()[1].SAN})
+}
+
+func TestHotSeat_IllegalMoves(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetHotSeat(true)
+	typeKeys(g, "C")
+	fen := g.FEN()
+
+	for _, keys := range []string{"1343\r", "6353\r", "2030\r"} {
+		buf.Reset()
+		typeKeys(g, keys)
+		assert.Contains(t, buf.String(), "Illegal move", keys)
+		assert.Equal(t, fen, g.FEN(), "%s: nothing moved", keys)
+		assert.False(t, g.Reversed)
+	}
+	assert.Empty(t, g.Record())
+}
+
+func TestHotSeat_Off(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, "C6353\r")
+	assert.False(t, g.Reversed, "manual moves stay unchecked without hot seat")
+	assert.NotContains(t, buf.String(), "to move")
+}
+
+func TestTurnStatus(t *testing.T) {
+	tests := []struct{ fen, want string }{
+		{InitialFEN, "White to move"},
+		{"4k3/8/8/8/8/8/8/R3K3 b - - 0 1", "Black to move"},
+		{"R3k3/8/8/8/8/8/8/4K3 b - - 0 1", "Black to move, check"},
+		{"R3k3/8/4K3/8/8/8/8/8 b - - 0 1", "Checkmate, white wins"},
+		{"k7/2Q5/1K6/8/8/8/8/8 b - - 0 1", "Stalemate, draw"},
+	}
+	for _, tt := range tests {
+		g := NewGame(&bytes.Buffer{})
+		require.NoError(t, g.SetFEN(tt.fen))
+		assert.Equal(t, tt.want, g.TurnStatus(), tt.fen)
+	}
+	assert.Empty(t, NewGame(&bytes.Buffer{}).TurnStatus(), "no board set up")
+}
+
+func TestLine_HotSeat(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, "C:hotseat on\r")
+	assert.True(t, g.HotSeat())
+	assert.Contains(t, buf.String(), "Hot seat: on")
+	assert.Contains(t, buf.String(), "White to move")
+
+	typeKeys(g, ":hotseat off\r")
+	assert.False(t, g.HotSeat())
+	buf.Reset()
+	typeKeys(g, ":hotseat maybe\r")
+	assert.Contains(t, buf.String(), "usage: :hotseat [on|off]")
+}
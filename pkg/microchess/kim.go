@@ -0,0 +1,104 @@
+// ABOUTME: This file emulates the KIM-1 front panel: the LED bytes drawn as six 7-segment digits.
+// ABOUTME: It also maps the KIM-1 keypad keys (GO, PC, +, F, ST, ...) onto the serial commands.
+
+package microchess
+
+import (
+	"io"
+	"strings"
+)
+
+// sevenSegments holds the lit segments of each hex digit as the KIM-1
+// showed them (b and d in lower case), one bit per segment: bit 0 a (top),
+// 1 b (top right), 2 c (bottom right), 3 d (bottom), 4 e (bottom left),
+// 5 f (top left), 6 g (middle).
+var sevenSegments = [16]uint8{
+	0x3F, 0x06, 0x5B, 0x4F, 0x66, 0x6D, 0x7D, 0x07, // 0-7
+	0x7F, 0x6F, 0x77, 0x7C, 0x39, 0x5E, 0x79, 0x71, // 8-F
+}
+
+// segmentRows returns the three text rows, three columns wide, of a hex
+// digit drawn with '_' and '|'.
+func segmentRows(digit uint8) [3]string {
+	seg := sevenSegments[digit&0x0F]
+	lit := func(bit uint, on string) string {
+		if seg&(1<<bit) != 0 {
+			return on
+		}
+		return " "
+	}
+	return [3]string{
+		" " + lit(0, "_") + " ",
+		lit(5, "|") + lit(6, "_") + lit(1, "|"),
+		lit(4, "|") + lit(3, "_") + lit(2, "|"),
+	}
+}
+
+// KIMRenderer draws only what a KIM-1 player saw: the six LED digits of
+// DIS1 (piece), DIS2 (from square) and DIS3 (to square) as 7-segment
+// displays, like the KIM ROM's *OUT routine at 1F1F lit them. There is no
+// board; the player keeps the position on a real chessboard.
+type KIMRenderer struct{}
+
+// Name implements Renderer.
+func (KIMRenderer) Name() string {
+	return "kim"
+}
+
+// Render implements Renderer.
+func (KIMRenderer) Render(w io.Writer, s Snapshot) error {
+	var sb strings.Builder
+	for row := 0; row < 3; row++ {
+		sb.WriteString(" ")
+		for i, b := range s.LED {
+			if i > 0 {
+				sb.WriteString("   ")
+			}
+			sb.WriteString(segmentRows(b >> 4)[row])
+			sb.WriteString(" ")
+			sb.WriteString(segmentRows(b)[row])
+		}
+		sb.WriteString("\r\n")
+	}
+	sb.WriteString(" piece    from     to\r\n\r\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// KIMKeys maps the keys of a PC keyboard standing for the KIM-1 keypad to
+// the serial version's commands (microchess manual, "Commands"):
+//
+//	0-7    digits of the FROM and TO squares
+//	C      [C]  clear the board, computer plays white
+//	E      [E]  exchange the computer's men with yours
+//	P      [PC] play chess: the computer's move lights up as piece/from/to
+//	F      [F]  register the move on the display (Enter in the serial version)
+//	+      [+]  same as [F], as is Enter
+//	G      [GO] relight the display; GO has no other effect once running
+//	S      [ST] stop: leave the program
+//
+// The original makes the computer's move as soon as it is found (MV2);
+// here [PC] asks the active engine, like H, and [F] then makes the move.
+var KIMKeys = map[byte]byte{
+	'0': '0', '1': '1', '2': '2', '3': '3', '4': '4', '5': '5', '6': '6', '7': '7',
+	'C':  'C',
+	'E':  'E',
+	'P':  'H',
+	'F':  '\r',
+	'+':  '\r',
+	'\r': '\r',
+	'\n': '\r',
+	'G':  'P',
+	'S':  'Q',
+}
+
+// KIMKey translates a key pressed in KIM-1 mode to a command character.
+// It returns false for keys with no keypad counterpart, which are ignored
+// like the unused hex keys (8-F besides C, E and F) on the real keypad.
+func KIMKey(c byte) (byte, bool) {
+	if c >= 'a' && c <= 'z' {
+		c = c - 'a' + 'A'
+	}
+	cmd, ok := KIMKeys[c]
+	return cmd, ok
+}
@@ -0,0 +1,75 @@
+// ABOUTME: This file contains tests for the KIM-1 emulation: 7-segment LED output and keypad keys.
+// ABOUTME: It checks the digit shapes and that a keypad game goes through the serial commands.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKIMRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetRenderer(KIMRenderer{})
+	g.DIS1, g.DIS2, g.DIS3 = 0x0F, 0x13, 0x33
+
+	g.Display()
+
+	want := "  _   _         _     _   _ \r\n" +
+		" | | |_      |  _|    _|  _|\r\n" +
+		" |_| |       |  _|    _|  _|\r\n" +
+		" piece    from     to\r\n" +
+		"\r\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestSegmentRows(t *testing.T) {
+	assert.Equal(t, [3]string{" _ ", "|_|", "|_|"}, segmentRows(0x8))
+	assert.Equal(t, [3]string{"   ", "|_ ", "|_|"}, segmentRows(0xB), "b is lower case")
+	assert.Equal(t, [3]string{"   ", " _|", "|_|"}, segmentRows(0xD), "d is lower case")
+	assert.Equal(t, [3]string{" _ ", "|_ ", "|_ "}, segmentRows(0xE))
+	assert.Equal(t, segmentRows(0x1), segmentRows(0xF1), "only the low nibble is drawn")
+}
+
+func TestKIMKey(t *testing.T) {
+	tests := []struct {
+		key  byte
+		cmd  byte
+		want bool
+	}{
+		{'4', '4', true},
+		{'c', 'C', true},
+		{'E', 'E', true},
+		{'p', 'H', true},  // [PC] play chess
+		{'F', '\r', true}, // [F] register the move
+		{'+', '\r', true},
+		{'G', 'P', true}, // [GO] relights the display
+		{'S', 'Q', true}, // [ST] leaves the program
+		{'8', 0, false},
+		{'L', 0, false},
+	}
+	for _, tt := range tests {
+		cmd, ok := KIMKey(tt.key)
+		assert.Equal(t, tt.want, ok, "key %q", tt.key)
+		assert.Equal(t, tt.cmd, cmd, "key %q", tt.key)
+	}
+}
+
+func TestKIMKeys_Game(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetRenderer(KIMRenderer{})
+	for _, key := range []byte("c1333f") {
+		cmd, ok := KIMKey(key)
+		assert.True(t, ok)
+		assert.True(t, g.HandleCharacter(cmd))
+	}
+	assert.Equal(t, [3]uint8{0xFF, 0x13, 0x33}, [3]uint8{g.DIS1, g.DIS2, g.DIS3}, "the move was registered")
+	assert.NotContains(t, buf.String(), "MicroChess (c)", "no board is printed")
+
+	cmd, _ := KIMKey('s')
+	assert.False(t, g.HandleCharacter(cmd), "[ST] quits")
+}
@@ -0,0 +1,221 @@
+// ABOUTME: This file implements the ':' command line: a small line editor inside the one-key CLI.
+// ABOUTME: Enter runs the line as a named command from the registry; :fen, :pgn and the engine settings live here.
+
+package microchess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Control characters understood by the line editor.
+const (
+	keyCtrlC     = 0x03
+	keyBackspace = 0x08
+	keyCtrlN     = 0x0E // Next line in the history
+	keyCtrlP     = 0x10 // Previous line in the history
+	keyCtrlU     = 0x15 // Erase the line
+	keyEscape    = 0x1B
+	keyDelete    = 0x7F // What most terminals send for Backspace
+)
+
+// lineEditor is the state of the ':' command line (NEW - not in original).
+type lineEditor struct {
+	text   []byte
+	escape int // Bytes of an escape sequence read so far (ESC, then '[' or 'O')
+	recall int // History entry shown; len(history) for the line being typed
+}
+
+// EditingLine reports whether a ':' command line is being typed, and its
+// text so far (without the ':'). Front ends use it to echo the line.
+func (g *GameState) EditingLine() (string, bool) {
+	if g.line == nil {
+		return "", false
+	}
+	return string(g.line.text), true
+}
+
+// editLine handles one character typed on the ':' command line. Enter
+// runs the line; Backspace erases a character and Ctrl-U the whole line;
+// Up and Down (or Ctrl-P and Ctrl-N) recall earlier lines; Ctrl-C or Esc
+// followed by any other key abandons it. Other control characters are
+// ignored.
+func (g *GameState) editLine(ctx context.Context, char byte) bool {
+	l := g.line
+	switch l.escape {
+	case 1:
+		if char == '[' || char == 'O' {
+			l.escape = 2
+			return true
+		}
+		l.escape = 0
+		g.cancelLine()
+		return true
+	case 2:
+		l.escape = 0
+		switch char {
+		case 'A':
+			g.recallLine(-1)
+		case 'B':
+			g.recallLine(1)
+		}
+		return true
+	}
+
+	switch {
+	case char == '\r' || char == '\n':
+		text := strings.TrimSpace(string(l.text))
+		g.line = nil
+		_, _ = fmt.Fprint(g.out, "\r\n")
+		if text == "" {
+			return true
+		}
+		if n := len(g.lineHistory); n == 0 || g.lineHistory[n-1] != text {
+			g.lineHistory = append(g.lineHistory, text)
+		}
+		return g.RunLine(ctx, text)
+	case char == keyBackspace || char == keyDelete:
+		if len(l.text) > 0 {
+			_, size := utf8.DecodeLastRune(l.text)
+			l.text = l.text[:len(l.text)-size]
+		}
+	case char == keyCtrlU:
+		l.text = l.text[:0]
+	case char == keyCtrlP:
+		g.recallLine(-1)
+	case char == keyCtrlN:
+		g.recallLine(1)
+	case char == keyCtrlC:
+		g.cancelLine()
+	case char == keyEscape:
+		l.escape = 1
+	case char >= 0x20:
+		l.text = append(l.text, char)
+	}
+	return true
+}
+
+// cancelLine abandons the command line.
+func (g *GameState) cancelLine() {
+	g.line = nil
+	_, _ = fmt.Fprint(g.out, "\r\n")
+}
+
+// recallLine replaces the line with an earlier (delta -1) or later (+1)
+// one from the history; going past the last one gives an empty line.
+func (g *GameState) recallLine(delta int) {
+	l := g.line
+	l.recall = min(max(l.recall+delta, 0), len(g.lineHistory))
+	if l.recall == len(g.lineHistory) {
+		l.text = nil
+		return
+	}
+	l.text = []byte(g.lineHistory[l.recall])
+}
+
+// RunLine runs a ':' command line (without the ':'), such as "fen" or
+// "depth 3", and reports whether the program should continue. Command
+// names are case-insensitive; every registered command with a name can
+// be run this way (see RegisterCommand).
+func (g *GameState) RunLine(ctx context.Context, text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return true
+	}
+	c, ok := CommandByName(fields[0])
+	if !ok {
+		_, _ = fmt.Fprintf(g.out, "Unknown command: :%s (:help lists them)\r\n", fields[0])
+		return true
+	}
+	return g.run(ctx, c, 0, fields[1:])
+}
+
+// cmdFEN implements :fen.
+func cmdFEN(_ context.Context, g *GameState, _ byte, args []string) error {
+	if len(args) == 0 {
+		_, _ = fmt.Fprintf(g.out, "FEN: %s\r\n", g.FEN())
+		return nil
+	}
+	if err := g.SetFEN(strings.Join(args, " ")); err != nil {
+		return err
+	}
+	g.DigitCount = 0
+	g.Display()
+	return nil
+}
+
+// cmdPGN implements :pgn.
+func cmdPGN(_ context.Context, g *GameState, _ byte, args []string) error {
+	switch {
+	case len(args) == 0:
+		var sb strings.Builder
+		if err := g.WritePGN(&sb); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprint(g.out, strings.ReplaceAll(sb.String(), "\n", "\r\n"))
+		return nil
+	case len(args) == 2 && strings.EqualFold(args[0], "save"):
+		if err := g.SavePGN(args[1]); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(g.out, "PGN: %d moves written to %s\r\n", len(g.record), args[1])
+		return nil
+	}
+	return fmt.Errorf("usage: :pgn [save FILE]")
+}
+
+// cmdDepth implements :depth. The depth belongs to the engines that have
+// one (see DepthSetter); the faithful engine always looks one ply ahead.
+func cmdDepth(_ context.Context, g *GameState, _ byte, args []string) error {
+	return engineSetting(g, "depth", "Depth: %d", args, DepthSetter.Depth, DepthSetter.SetDepth,
+		"no engine with a search depth (the faithful engine looks one ply ahead)")
+}
+
+// cmdHash implements :hash, the size of the transposition table of the
+// engines that have one (see HashSetter). A new size empties the table.
+func cmdHash(_ context.Context, g *GameState, _ byte, args []string) error {
+	return engineSetting(g, "hash", "Hash: %d MB", args, HashSetter.HashMB, HashSetter.SetHashMB,
+		"no engine with a transposition table (the faithful engine has none)")
+}
+
+// cmdThreads implements :threads, the threads of the engines that can use
+// several (see ThreadSetter).
+func cmdThreads(_ context.Context, g *GameState, _ byte, args []string) error {
+	return engineSetting(g, "threads", "Threads: %d", args, ThreadSetter.ThreadCount, ThreadSetter.SetThreadCount,
+		"no engine that can use several threads")
+}
+
+// engineSetting shows a numeric setting of every installed engine of type
+// T, formatted with label, after setting it to args[0] if given. none is
+// the error when no engine has the setting.
+func engineSetting[T Engine](g *GameState, name, label string, args []string, get func(T) int, set func(T, int) error, none string) error {
+	var engines []T
+	for _, e := range g.engines {
+		if s, ok := e.(T); ok {
+			engines = append(engines, s)
+		}
+	}
+	if len(engines) == 0 {
+		return errors.New(none)
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: :%s [N]", name)
+	}
+	for _, e := range engines {
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("%s %q is not a number", name, args[0])
+			}
+			if err := set(e, n); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprintf(g.out, label+" (%s)\r\n", get(e), e.Name())
+	}
+	return nil
+}
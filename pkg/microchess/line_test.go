@@ -0,0 +1,224 @@
+// ABOUTME: This file contains tests for the ':' command line and its named commands.
+// ABOUTME: It checks editing keys, history recall, :fen, :pgn, :depth and that single keys still work.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// typeKeys sends every byte of keys to the game and returns the last result.
+func typeKeys(g *GameState, keys string) bool {
+	ok := true
+	for i := 0; i < len(keys); i++ {
+		ok = g.HandleCharacter(keys[i])
+	}
+	return ok
+}
+
+// depthEngine is an engine with a settable depth, for :depth.
+type depthEngine struct {
+	FaithfulEngine
+	depth int
+}
+
+func (e *depthEngine) Name() string { return "deep" }
+func (e *depthEngine) Depth() int   { return e.depth }
+func (e *depthEngine) SetDepth(plies int) error {
+	e.depth = plies
+	return nil
+}
+
+// hashEngine is an engine with a settable table size, for :hash.
+type hashEngine struct {
+	FaithfulEngine
+	mb int
+}
+
+func (e *hashEngine) Name() string { return "hashed" }
+func (e *hashEngine) HashMB() int  { return e.mb }
+func (e *hashEngine) SetHashMB(mb int) error {
+	if mb < 1 {
+		return fmt.Errorf("option Hash: %d out of range", mb)
+	}
+	e.mb = mb
+	return nil
+}
+
+func TestLine_Editing(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+
+	typeKeys(g, ":fex")
+	text, editing := g.EditingLine()
+	assert.True(t, editing)
+	assert.Equal(t, "fex", text, "letters keep their case and are not commands")
+
+	typeKeys(g, "\x7fn")
+	text, _ = g.EditingLine()
+	assert.Equal(t, "fen", text, "backspace")
+
+	typeKeys(g, "\x15fen")
+	text, _ = g.EditingLine()
+	assert.Equal(t, "fen", text, "Ctrl-U erases the line")
+
+	buf.Reset()
+	assert.True(t, typeKeys(g, "\r"))
+	_, editing = g.EditingLine()
+	assert.False(t, editing)
+	assert.Contains(t, buf.String(), "FEN: "+InitialFEN)
+}
+
+func TestLine_Cancel(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+
+	typeKeys(g, ":quit\x03")
+	_, editing := g.EditingLine()
+	assert.False(t, editing, "Ctrl-C abandons the line")
+
+	typeKeys(g, ":quit\x1bx")
+	_, editing = g.EditingLine()
+	assert.False(t, editing, "Esc abandons the line")
+
+	assert.False(t, g.Reversed)
+	assert.True(t, typeKeys(g, "E"), "single keys work again")
+	assert.True(t, g.Reversed)
+}
+
+func TestLine_History(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	typeKeys(g, ":fen\r:help\r:help\r")
+	assert.Equal(t, []string{"fen", "help"}, g.lineHistory, "repeated lines are kept once")
+
+	typeKeys(g, ":\x1b[A")
+	text, _ := g.EditingLine()
+	assert.Equal(t, "help", text, "Up recalls the last line")
+	typeKeys(g, "\x10\x10")
+	text, _ = g.EditingLine()
+	assert.Equal(t, "fen", text, "Ctrl-P stops at the oldest line")
+	typeKeys(g, "\x1b[B\x0e")
+	text, _ = g.EditingLine()
+	assert.Equal(t, "", text, "Down past the newest line gives an empty line")
+}
+
+func TestRunLine(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+
+	assert.True(t, g.RunLine(context.Background(), "HELP"))
+	for _, name := range []string{"fen", "pgn", "depth", "help", "quit"} {
+		assert.Contains(t, buf.String(), ":"+name)
+	}
+
+	buf.Reset()
+	assert.True(t, g.RunLine(context.Background(), "nope"))
+	assert.Contains(t, buf.String(), "Unknown command: :nope")
+
+	buf.Reset()
+	assert.True(t, g.RunLine(context.Background(), "fen 8/8/8/8/8/8/8/7K b"))
+	assert.Contains(t, buf.String(), "Error:", "a FEN without both kings is refused")
+
+	assert.True(t, g.RunLine(context.Background(), "fen 4k3/8/8/8/8/8/8/4K3 b - - 0 1"))
+	assert.True(t, g.Reversed, "black to move")
+	assert.Equal(t, "4k3/8/8/8/8/8/8/4K3 b - - 0 1", g.FEN())
+
+	assert.False(t, g.RunLine(context.Background(), "quit"))
+	assert.False(t, typeKeys(g, ":quit\r"))
+}
+
+func TestLine_PGN(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	typeKeys(g, "C1333\r6343\r")
+	path := filepath.Join(t.TempDir(), "game.pgn")
+
+	buf.Reset()
+	typeKeys(g, ":pgn save "+path+"\r")
+	assert.Contains(t, buf.String(), "PGN: 2 moves written to "+path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), "\n1. d4 d5 *\n"))
+
+	buf.Reset()
+	typeKeys(g, ":pgn load x\r")
+	assert.Contains(t, buf.String(), "usage: :pgn [save FILE]")
+}
+
+func TestLine_Depth(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetEngines(FaithfulEngine{})
+	typeKeys(g, ":depth 3\r")
+	assert.Contains(t, buf.String(), "Error: no engine with a search depth")
+
+	deep := &depthEngine{depth: 4}
+	g.SetEngines(FaithfulEngine{}, deep)
+	buf.Reset()
+	typeKeys(g, ":depth\r")
+	assert.Contains(t, buf.String(), "Depth: 4 (deep)")
+
+	buf.Reset()
+	typeKeys(g, ":depth 6\r")
+	assert.Equal(t, 6, deep.depth)
+	assert.Contains(t, buf.String(), "Depth: 6 (deep)")
+
+	buf.Reset()
+	typeKeys(g, ":depth six\r")
+	assert.Contains(t, buf.String(), `Error: depth "six" is not a number`)
+}
+
+func TestLine_Threads(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetEngines(FaithfulEngine{})
+	typeKeys(g, ":threads\r")
+	assert.Contains(t, buf.String(), "Error: no engine that can use several threads")
+
+	faithful := &FaithfulEngine{}
+	g.SetEngines(faithful)
+	buf.Reset()
+	typeKeys(g, ":threads\r:threads 4\r")
+	assert.Contains(t, buf.String(), "Threads: 1 (faithful)")
+	assert.Contains(t, buf.String(), "Threads: 4 (faithful)")
+	assert.Equal(t, 4, faithful.Workers)
+
+	buf.Reset()
+	typeKeys(g, ":threads 0\r")
+	assert.Contains(t, buf.String(), "Error: threads: 0 is not a positive number")
+}
+
+func TestLine_Hash(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetEngines(FaithfulEngine{})
+	typeKeys(g, ":hash\r")
+	assert.Contains(t, buf.String(), "Error: no engine with a transposition table")
+
+	hashed := &hashEngine{mb: 16}
+	g.SetEngines(hashed, FaithfulEngine{})
+	buf.Reset()
+	typeKeys(g, ":hash 64\r")
+	assert.Equal(t, 64, hashed.mb)
+	assert.Contains(t, buf.String(), "Hash: 64 MB (hashed)")
+
+	buf.Reset()
+	typeKeys(g, ":hash 0\r:hash 1 2\r")
+	assert.Contains(t, buf.String(), "Error: option Hash: 0 out of range")
+	assert.Contains(t, buf.String(), "Error: usage: :hash [N]")
+	assert.Equal(t, 64, hashed.mb)
+}
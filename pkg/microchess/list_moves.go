@@ -3,39 +3,17 @@
 
 package microchess
 
-import (
-	"fmt"
-
-	"github.com/matteo/microchess-go/pkg/board"
-)
+import "fmt"
 
 // ListLegalMoves generates and displays all legal moves for the current position.
 // This is the handler for the 'L' command (NEW - not in original).
 //
 // Output format matches original's LED display style: hex coordinates (14 34 for e2-e4)
 func (g *GameState) ListLegalMoves() {
-	// Set STATE = 4 to enable CHKCHK during move generation
-	// This ensures moves that expose the king to check are filtered out
-	// Assembly reference: In GO routine (line 601), STATE is set to 4 before GNMZ
-	savedState := g.State
-	g.State = 4
-
-	// Collect all moves
-	var moves []Move
-
-	// Use GNM with a callback that collects moves
-	// Moves are collected in GNM's natural generation order (piece 15 -> 0)
-	// CHKCHK will run for each move since STATE=4 (0 <= STATE < 8)
-	g.GNM(func(from, to board.Square, piece Piece) {
-		moves = append(moves, Move{
-			From:  from,
-			To:    to,
-			Piece: piece,
-		})
-	})
-
-	// Restore STATE
-	g.State = savedState
+	// LegalMoves runs GNM with STATE = 4 so that CHKCHK filters out moves
+	// which expose the king to check (assembly: GO routine, line 601).
+	// Moves come back in GNM's natural generation order (piece 15 -> 0).
+	moves := g.LegalMoves()
 
 	// Display moves in hex format matching LED display style
 	// Format: "- FF TT" where FF is from square, TT is to square (both in hex)
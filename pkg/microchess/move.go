@@ -85,6 +85,7 @@ func (g *GameState) MOVE() {
 
 			// Mark as captured by setting position to 0xCC (off-board)
 			// Assembly line 523-524: LDA #$CC / STA BOARD,X
+			g.Hash ^= g.pieceKey(sideOf(pieceIndex), Piece(pieceIndex&0x0F), pieceSquare)
 			if pieceIndex < 16 {
 				g.Board[pieceIndex] = 0xCC
 			} else {
@@ -110,6 +111,21 @@ func (g *GameState) MOVE() {
 	// Move the piece to target square
 	// Assembly line 529: STY BOARD,X (stores SQUARE into PIECE's position)
 	g.Board[g.MovePiece] = g.MoveSquare
+
+	// Update the Zobrist key: piece leaves fromSquare, lands on MoveSquare
+	g.Hash ^= g.pieceKey(SideBoard, g.MovePiece, fromSquare) ^ g.pieceKey(SideBoard, g.MovePiece, g.MoveSquare)
+	if DebugHash {
+		g.assertHash("MOVE")
+	}
+}
+
+// sideOf returns the side of a 0-31 piece index as used by the 32-entry scans
+// in CMOVE and MOVE (BOARD at $50-$5F followed by BK at $60-$6F).
+func sideOf(pieceIndex int) Side {
+	if pieceIndex >= 16 {
+		return SideBK
+	}
+	return SideBoard
 }
 
 // UMOVE unmakes the last move by popping from the move history stack.
@@ -163,6 +179,8 @@ func (g *GameState) UMOVE() {
 	// Restore moving piece to its original square
 	// Assembly line 497-498: PLA / STA BOARD,X
 	g.Board[record.MovingPiece] = record.FromSquare
+	g.Hash ^= g.pieceKey(SideBoard, record.MovingPiece, record.ToSquare) ^
+		g.pieceKey(SideBoard, record.MovingPiece, record.FromSquare)
 
 	// Restore captured piece (if any)
 	if record.CapturedPiece != NoPiece {
@@ -173,11 +191,16 @@ func (g *GameState) UMOVE() {
 			// BK array piece
 			g.BK[record.CapturedPiece-16] = record.CapturedSquare
 		}
+		g.Hash ^= g.pieceKey(sideOf(int(record.CapturedPiece)), record.CapturedPiece&0x0F, record.CapturedSquare)
 	}
 
 	// Restore SQUARE (working square) to the destination
 	// Assembly line 501-503: PLA / STA SQUARE / STA BOARD,X
 	g.MoveSquare = record.ToSquare
+
+	if DebugHash {
+		g.assertHash("UMOVE")
+	}
 }
 
 // RUM reverses the board and unmakes the last move.
@@ -0,0 +1,123 @@
+// ABOUTME: This file implements GameState cloning and a parallel root-move splitter.
+// ABOUTME: Each root move from GNM is scored on a private clone; results are merged in GNM order.
+
+package microchess
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Clone returns a deep copy of the game state.
+//
+// All search state (Board/BK, the GNM registers, STATE, the COUNT arrays,
+// the best-move registers and the MoveHistory stack) is copied, so the
+// clone can be searched on another goroutine while g is used elsewhere.
+// The output writer, the installed engines and the observer are shared
+// with g: a clone is meant for analysis, not for running the UI. The clock
+// is not, so that moves played on a clone are never charged to the game.
+func (g *GameState) Clone() *GameState {
+	c := *g
+	c.MoveHistory = append([]MoveRecord(nil), g.MoveHistory...)
+	c.engines = append([]Engine(nil), g.engines...)
+	c.trace = nil // A Trace is recorded by one goroutine only
+	c.record = append([]RecordedMove(nil), g.record...)
+	c.line = nil
+	c.clock = nil
+	c.announce = false // Moves played on a clone are not the game's
+	return &c
+}
+
+// ScoredMove is a root move together with the score given to it.
+type ScoredMove struct {
+	Move  Move
+	Score int
+}
+
+// RootScorer scores one root move of g (the move is not yet played).
+// It may use any GameState routine but must leave g as it found it,
+// since a worker scores several moves on the same clone.
+type RootScorer func(g *GameState, m Move) int
+
+// EvaluatorScorer returns the faithful way of scoring a root move with ev:
+// MOVE, then score the resulting position with the move as context (like
+// STRATGY at the end of ON4), then UMOVE.
+func EvaluatorScorer(ev Evaluator) RootScorer {
+	return func(g *GameState, m Move) int {
+		g.MovePiece = m.Piece
+		g.MoveSquare = m.To
+		g.MOVE()
+		score := ev.Score(g, MoveContext{Move: m, Mover: SideBoard})
+		g.UMOVE()
+		return score
+	}
+}
+
+// ScoreRootMoves scores every legal move of the side to move, using up to
+// workers goroutines, as the faithful engine does when it has Workers. Each worker gets its own clone of g and its own
+// scorer from newScorer, so scorers may keep private state (such as a
+// search engine with its own transposition table).
+//
+// Results are returned in GNM order regardless of which worker finished
+// first, so the outcome is identical to a sequential run (workers = 1)
+// as long as each score depends only on the position and the move.
+// If ctx is cancelled, moves not yet scored are left out and ctx.Err()
+// is returned.
+func (g *GameState) ScoreRootMoves(ctx context.Context, workers int, newScorer func() RootScorer) ([]ScoredMove, error) {
+	moves := g.LegalMoves()
+	scores := make([]int, len(moves))
+	scored := make([]bool, len(moves))
+	workers = max(1, min(workers, len(moves)))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		clone := g.Clone()
+		clone.out = io.Discard
+		score := newScorer()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				scores[i] = score(clone, moves[i])
+				scored[i] = true
+			}
+		}()
+	}
+
+	for i := range moves {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	results := make([]ScoredMove, 0, len(moves))
+	for i, m := range moves {
+		if scored[i] {
+			results = append(results, ScoredMove{Move: m, Score: scores[i]})
+		}
+	}
+	return results, ctx.Err()
+}
+
+// BestScored returns the highest-scoring move, the first one in GNM order
+// on ties (like PUSH, which only replaces the best move on a higher score).
+func BestScored(moves []ScoredMove) (ScoredMove, bool) {
+	if len(moves) == 0 {
+		return ScoredMove{Move: Move{Piece: NoPiece}}, false
+	}
+	best := moves[0]
+	for _, sm := range moves[1:] {
+		if sm.Score > best.Score {
+			best = sm
+		}
+	}
+	return best, true
+}
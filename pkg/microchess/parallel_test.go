@@ -0,0 +1,122 @@
+// ABOUTME: This file contains tests for GameState.Clone and the parallel root splitter.
+// ABOUTME: It checks that clones are independent and that parallel scoring equals sequential scoring.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone_IsIndependent(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.MovePiece = PiecePawn7
+	g.MoveSquare = 0x34
+	g.MOVE()
+
+	c := g.Clone()
+	require.Equal(t, g.Board, c.Board)
+	require.Equal(t, g.MoveHistory, c.MoveHistory)
+
+	c.UMOVE()
+	c.Reverse()
+	c.MaxCapture[4] = 9
+
+	assert.Equal(t, board.Square(0x34), g.Board[PiecePawn7], "undoing on the clone leaves the original alone")
+	assert.Len(t, g.MoveHistory, 1)
+	assert.False(t, g.Reversed)
+	assert.Equal(t, uint8(0), g.MaxCapture[4])
+	assert.Equal(t, g.ComputeHash(), g.Hash)
+	assert.Equal(t, c.ComputeHash(), c.Hash)
+}
+
+// playedPositions returns a few positions reached by playing the first
+// legal move a number of times from the start, for both sides.
+func playedPositions(t *testing.T) []*GameState {
+	t.Helper()
+	var positions []*GameState
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	for ply := 0; ply < 8; ply++ {
+		positions = append(positions, g.Clone())
+		moves := g.LegalMoves()
+		require.NotEmpty(t, moves)
+		m := moves[ply%len(moves)]
+		g.MovePiece = m.Piece
+		g.MoveSquare = m.To
+		g.MOVE()
+		g.Reverse()
+	}
+	return positions
+}
+
+func TestScoreRootMoves_ParallelEqualsSequential(t *testing.T) {
+	strategy := func() RootScorer { return EvaluatorScorer(StrategyEvaluator{}) }
+
+	for _, g := range playedPositions(t) {
+		before := *g
+		sequential, err := g.ScoreRootMoves(context.Background(), 1, strategy)
+		require.NoError(t, err)
+		parallel, err := g.ScoreRootMoves(context.Background(), 8, strategy)
+		require.NoError(t, err)
+
+		assert.Equal(t, sequential, parallel)
+		assert.Len(t, sequential, len(g.LegalMoves()))
+		assert.Equal(t, before.Board, g.Board)
+		assert.Equal(t, before.BK, g.BK)
+
+		best, ok := BestScored(parallel)
+		require.True(t, ok)
+		assert.Equal(t, FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil).Move, best.Move,
+			"the splitter agrees with the faithful engine")
+	}
+}
+
+func TestFaithfulEngine_WorkersEqualSequential(t *testing.T) {
+	for _, g := range playedPositions(t) {
+		before := *g
+		sequential := FaithfulEngine{}.Analyze(context.Background(), g, TimeControl{}, 5, nil)
+		var reports []Progress
+		parallel := FaithfulEngine{Workers: 8}.Analyze(context.Background(), g, TimeControl{}, 5, func(p Progress) {
+			reports = append(reports, p)
+		})
+
+		assert.Equal(t, sequential, parallel)
+		assert.Len(t, reports, len(g.LegalMoves()), "one report per root move")
+		if len(reports) > 0 {
+			assert.Equal(t, parallel.Move, reports[len(reports)-1].Move, "ties go to GNM order in the reports too")
+		}
+		assert.Equal(t, before.Board, g.Board)
+		assert.Equal(t, before.BK, g.BK)
+	}
+}
+
+func TestScoreRootMoves_Cancelled(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scored, err := g.ScoreRootMoves(ctx, 4, func() RootScorer { return EvaluatorScorer(StrategyEvaluator{}) })
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, len(scored), 20)
+}
+
+func TestBestScored_FirstOnTies(t *testing.T) {
+	a := Move{From: 0x14, To: 0x24, Piece: PiecePawn7}
+	b := Move{From: 0x14, To: 0x34, Piece: PiecePawn7}
+
+	best, ok := BestScored([]ScoredMove{{a, 5}, {b, 5}})
+	assert.True(t, ok)
+	assert.Equal(t, a, best.Move)
+
+	_, ok = BestScored(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,36 @@
+// ABOUTME: This file contains tests for perft, the count of the legal move tree.
+// ABOUTME: It checks the published counts of the start position and that divide adds up.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerft_StandardStart(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN(StandardFEN))
+	hash := g.Hash
+	for depth, want := range []uint64{1, 20, 400, 8902} {
+		assert.Equal(t, want, g.Perft(depth), "depth %d", depth)
+	}
+	assert.Equal(t, StandardFEN, g.FEN(), "the position is restored")
+	assert.Equal(t, hash, g.Hash)
+}
+
+func TestPerftDivide(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("4k3/8/8/8/8/8/8/R3K3 b - - 0 1"))
+	counts := g.PerftDivide(3)
+	require.Len(t, counts, len(g.LegalMoves()))
+	var sum uint64
+	for _, c := range counts {
+		sum += c.Nodes
+	}
+	assert.Equal(t, g.Perft(3), sum)
+	assert.True(t, g.Reversed, "still black to move")
+}
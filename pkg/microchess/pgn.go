@@ -0,0 +1,453 @@
+// ABOUTME: This file keeps the record of the moves played with Enter and reads and writes it as PGN.
+// ABOUTME: Moves are named and parsed in standard algebraic notation (SAN) with the port's square names.
+
+package microchess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// StandardFEN is the start position of standard chess, which PGN assumes
+// when a game has no FEN tag.
+const StandardFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"
+
+// RecordedMove is one move of the game record (NEW - not in original).
+type RecordedMove struct {
+	From, To board.Square // Absolute squares (white's first rank is rank 1)
+	White    bool         // White moved, whichever side was to move
+	SAN      string       // Standard algebraic notation, like "Nf3" or "exd5+"
+}
+
+// Record returns the moves played with Enter since the board was set up
+// (C or SetFEN), oldest first. Moves found by H are recorded once Enter
+// plays them.
+func (g *GameState) Record() []RecordedMove {
+	return append([]RecordedMove(nil), g.record...)
+}
+
+// startRecord begins a new game record from the current position.
+func (g *GameState) startRecord() {
+	g.record = nil
+	g.recordStart = g.FEN()
+	g.thinking = 0
+}
+
+// recordMove adds the move of piece (0-31, numbered like FindPieceAtSquare)
+// from from to to, in stored squares, before ExecuteMove plays it, charges
+// the engine time spent on it to the mover's clock, and announces it when
+// announcements are on.
+func (g *GameState) recordMove(piece Piece, from, to board.Square) {
+	white := !g.Reversed // The Board array is white unless reversed
+	if piece >= 16 {
+		white = !white
+	}
+	abs := func(sq board.Square) board.Square {
+		if g.Reversed {
+			return 0x77 - sq
+		}
+		return sq
+	}
+	m := Move{Piece: piece, From: from, To: to}
+	g.record = append(g.record, RecordedMove{
+		From:  abs(from),
+		To:    abs(to),
+		White: white,
+		SAN:   g.SAN(m),
+	})
+	g.punchClock(!white)
+	if g.announce {
+		_, _ = fmt.Fprintf(g.out, "%s\r\n", g.Announcement(m))
+	}
+}
+
+// SAN returns a move in standard algebraic notation: the piece letter (none
+// for pawns), the file and/or rank of the from square when another piece
+// of the same kind can reach the same square, "x" for a capture, the to
+// square, and "+" or "#" when the move gives check or mate.
+//
+// m is in stored squares; m.Piece numbers pieces like FindPieceAtSquare
+// (16-31 for the BK array), so the side not to move can be named too, as
+// manual moves allow. Squares are named like board.Square.String, so with
+// the 1976 setup (king on d1) SAN reads as the mirrored standard game.
+// MicroChess has no castling, en passant or promotion, so neither has SAN.
+func (g *GameState) SAN(m Move) string {
+	c := g.Clone()
+	if m.Piece >= 16 {
+		c.Reverse()
+		m = Move{Piece: m.Piece - 16, From: 0x77 - m.From, To: 0x77 - m.To}
+	}
+	abs := func(sq board.Square) board.Square {
+		if c.Reversed {
+			return 0x77 - sq
+		}
+		return sq
+	}
+
+	var sb strings.Builder
+	capture := c.FindPieceAtSquare(m.To) != NoPiece
+	kind := m.Piece.Kind()
+	if kind == KindPawn {
+		if capture {
+			sb.WriteString(abs(m.From).String()[:1])
+		}
+	} else {
+		sb.WriteString(kind.Letter())
+		sb.WriteString(c.disambiguation(m, abs))
+	}
+	if capture {
+		sb.WriteString("x")
+	}
+	sb.WriteString(abs(m.To).String())
+
+	// Play it on the clone and look at the opponent's king
+	if victim := c.FindPieceAtSquare(m.To); victim != NoPiece {
+		if victim < 16 {
+			c.Board[victim] = 0xCC
+		} else {
+			c.BK[victim-16] = 0xCC
+		}
+	}
+	c.Board[m.Piece] = m.To
+	c.Hash = c.ComputeHash() // Edited directly: refresh the key before Reverse checks it
+	c.Reverse()
+	if c.InCheck() {
+		if len(c.LegalMoves()) == 0 {
+			sb.WriteString("#")
+		} else {
+			sb.WriteString("+")
+		}
+	}
+	return sb.String()
+}
+
+// UCI returns a move of the side to move (m in stored squares) in the
+// long algebraic notation of UCI: the absolute from and to squares, like
+// "g1f3".
+func (g *GameState) UCI(m Move) string {
+	return g.absSquare(m.From).String() + g.absSquare(m.To).String()
+}
+
+// NamePV names the moves of a principal variation (each in the frame of
+// the side playing it, as engines report them) with name, such as
+// (*GameState).SAN or (*GameState).UCI, playing them on a clone.
+func (g *GameState) NamePV(pv []Move, name func(*GameState, Move) string) []string {
+	c := g.Clone()
+	names := make([]string, len(pv))
+	for i, m := range pv {
+		names[i] = name(c, m)
+		c.PlayMove(m)
+	}
+	return names
+}
+
+// disambiguation returns the file, rank or square of m's from square when
+// other legal moves of the same kind go to the same square.
+func (g *GameState) disambiguation(m Move, abs func(board.Square) board.Square) string {
+	from := abs(m.From)
+	var others []board.Square
+	for _, o := range g.LegalMoves() {
+		if o.To == m.To && o.Piece != m.Piece && o.Piece.Kind() == m.Piece.Kind() {
+			others = append(others, abs(o.From))
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	sameFile, sameRank := false, false
+	for _, o := range others {
+		sameFile = sameFile || o.File() == from.File()
+		sameRank = sameRank || o.Rank() == from.Rank()
+	}
+	switch {
+	case !sameFile:
+		return from.String()[:1]
+	case !sameRank:
+		return from.String()[1:]
+	}
+	return from.String()
+}
+
+// WritePGN writes the game record as a PGN game. The start position goes
+// into SetUp and FEN tags unless it is the standard one, which the 1976
+// setup is not. The result is always "*": MicroChess does not adjudicate.
+//
+// PGN needs the sides to alternate, but manual moves do not enforce it; a
+// side that moved twice in a row gets a null move ("--") in between.
+func (g *GameState) WritePGN(w io.Writer) error {
+	if g.recordStart == "" {
+		return fmt.Errorf("no game to write: set up the board first")
+	}
+	var sb strings.Builder
+	tag := func(name, value string) {
+		fmt.Fprintf(&sb, "[%s %q]\n", name, value)
+	}
+	tag("Event", "MicroChess game")
+	tag("Site", "?")
+	tag("Date", time.Now().Format("2006.01.02"))
+	tag("Round", "-")
+	tag("White", "?")
+	tag("Black", "?")
+	tag("Result", "*")
+	if g.recordStart != StandardFEN {
+		tag("SetUp", "1")
+		tag("FEN", g.recordStart)
+	}
+	sb.WriteString("\n")
+
+	var tokens []string
+	fields := strings.Fields(g.recordStart)
+	white := fields[1] != "b"
+	_, number := fenCounters(fields)
+	emit := func(san string) {
+		switch {
+		case white:
+			tokens = append(tokens, fmt.Sprintf("%d.", number))
+		case len(tokens) == 0:
+			tokens = append(tokens, fmt.Sprintf("%d...", number))
+		}
+		tokens = append(tokens, san)
+		if !white {
+			number++
+		}
+		white = !white
+	}
+	for _, m := range g.record {
+		if m.White != white {
+			emit("--") // The other side did not move
+		}
+		emit(m.SAN)
+	}
+	tokens = append(tokens, "*")
+
+	// Movetext lines of at most 79 characters
+	line := 0
+	for i, t := range tokens {
+		switch {
+		case i == 0:
+		case line+1+len(t) > 79:
+			sb.WriteString("\n")
+			line = 0
+		default:
+			sb.WriteString(" ")
+			line++
+		}
+		sb.WriteString(t)
+		line += len(t)
+	}
+	sb.WriteString("\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// SavePGN writes the game record to the file at path.
+func (g *GameState) SavePGN(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := g.WritePGN(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// PlayMove plays a legal move of the side to move (m in stored squares,
+// as LegalMoves returns it) the way Enter does, records it, and reverses
+// the board so that the other side is to move. Unlike manual moves, the
+// sides alternate, as in a real game.
+func (g *GameState) PlayMove(m Move) {
+	g.SelectedPiece = m.Piece
+	g.DIS1, g.DIS2, g.DIS3 = uint8(m.Piece), uint8(m.From), uint8(m.To)
+	g.DigitCount = 4
+	g.ExecuteMove()
+	g.Reverse()
+}
+
+// ParseMove finds the legal move of the side to move written as SAN, like
+// "Nf3", "exd5" or "Rad1+", or as the from and to squares, like "g1f3" or
+// "g1-f3". Squares are absolute, as in SAN; "x", "+", "#", "!" and "?" are
+// optional, and so is a disambiguation that is not needed. Castling and
+// promotion do not exist in MicroChess and are refused.
+func (g *GameState) ParseMove(s string) (Move, error) {
+	text := strings.TrimRight(s, "+#!?")
+	switch {
+	case text == "":
+		return Move{Piece: NoPiece}, fmt.Errorf("empty move")
+	case strings.HasPrefix(text, "O-O") || strings.HasPrefix(text, "0-0"):
+		return Move{Piece: NoPiece}, fmt.Errorf("move %q: MicroChess has no castling", s)
+	case strings.Contains(text, "="):
+		return Move{Piece: NoPiece}, fmt.Errorf("move %q: MicroChess has no promotion", s)
+	}
+	text = strings.NewReplacer("x", "", "-", "").Replace(text)
+
+	var found []Move
+	for _, m := range g.LegalMoves() {
+		from, to := g.absSquare(m.From).String(), g.absSquare(m.To).String()
+		letter := ""
+		if kind := m.Piece.Kind(); kind != KindPawn {
+			letter = kind.Letter()
+		}
+		forms := []string{letter + from[:1] + to, letter + from[1:] + to, letter + from + to, from + to}
+		if letter != "" || g.FindPieceAtSquare(m.To) == NoPiece {
+			forms = append(forms, letter+to) // Pawns name the file they capture from
+		}
+		for _, f := range forms {
+			if f == text {
+				found = append(found, m)
+				break
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Move{Piece: NoPiece}, fmt.Errorf("move %q is not legal", s)
+	case 1:
+		return found[0], nil
+	}
+	return Move{Piece: NoPiece}, fmt.Errorf("move %q is ambiguous", s)
+}
+
+// absSquare converts a stored square to the absolute one (white's first
+// rank is rank 1).
+func (g *GameState) absSquare(sq board.Square) board.Square {
+	if g.Reversed {
+		return 0x77 - sq
+	}
+	return sq
+}
+
+// ReadPGN sets up the start position of the first game in r (its FEN tag,
+// or the standard start) and plays its moves with PlayMove, so that the
+// game record continues from it. Comments, variations, NAGs and the
+// result are skipped; a null move ("--") only passes the turn.
+func (g *GameState) ReadPGN(r io.Reader) error {
+	fen := StandardFEN
+	var movetext strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			if movetext.Len() > 0 {
+				break // The next game's tags
+			}
+			name, value, ok := strings.Cut(strings.Trim(line, "[]"), " ")
+			if !ok {
+				return fmt.Errorf("invalid PGN tag %s", line)
+			}
+			if name == "FEN" {
+				v, err := strconv.Unquote(strings.TrimSpace(value))
+				if err != nil {
+					return fmt.Errorf("invalid PGN tag %s", line)
+				}
+				fen = v
+			}
+			continue
+		}
+		movetext.WriteString(line)
+		movetext.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	tokens, err := pgnTokens(movetext.String())
+	if err != nil {
+		return err
+	}
+	if err := g.SetFEN(fen); err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if t == "--" {
+			g.Reverse()
+			continue
+		}
+		m, err := g.ParseMove(t)
+		if err != nil {
+			return fmt.Errorf("PGN move %d: %w", len(g.record)+1, err)
+		}
+		g.PlayMove(m)
+	}
+	return nil
+}
+
+// LoadPGN reads the first game of the PGN file at path (see ReadPGN).
+func (g *GameState) LoadPGN(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return g.ReadPGN(f)
+}
+
+// pgnTokens returns the moves of PGN movetext, without move numbers,
+// comments ({...} and ; to the end of the line), variations, NAGs and
+// the game result.
+func pgnTokens(movetext string) ([]string, error) {
+	var tokens []string
+	depth := 0 // Nesting of variations
+	for i := 0; i < len(movetext); {
+		c := movetext[i]
+		switch {
+		case c == '{':
+			end := strings.IndexByte(movetext[i:], '}')
+			if end < 0 {
+				return nil, errors.New("PGN comment is not closed")
+			}
+			i += end + 1
+			continue
+		case c == ';':
+			end := strings.IndexByte(movetext[i:], '\n')
+			if end < 0 {
+				end = len(movetext) - i
+			}
+			i += end
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return nil, errors.New("PGN variation closed but not opened")
+			}
+			depth--
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			end := i
+			for end < len(movetext) && !strings.ContainsRune(" \t\r\n{;()", rune(movetext[end])) {
+				end++
+			}
+			token := movetext[i:end]
+			i = end
+			switch {
+			case depth > 0, strings.HasPrefix(token, "$"):
+				continue // A move of a variation, or a NAG
+			case token == "1-0" || token == "0-1" || token == "1/2-1/2" || token == "*":
+				continue
+			}
+			if rest := strings.TrimLeft(token, "0123456789"); strings.HasPrefix(rest, ".") {
+				token = strings.TrimLeft(rest, ".") // "12." or "12...e5"
+			}
+			if token == "" {
+				continue
+			}
+			tokens = append(tokens, token)
+			continue
+		}
+		i++
+	}
+	if depth > 0 {
+		return nil, errors.New("PGN variation is not closed")
+	}
+	return tokens, nil
+}
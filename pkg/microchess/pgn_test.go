@@ -0,0 +1,200 @@
+// ABOUTME: This file contains tests for the game record, SAN move names and PGN output.
+// ABOUTME: It covers captures, disambiguation, check and mate, black moves, null moves and reading PGN back.
+
+package microchess
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sanOf returns the SAN of the move from from to to, in absolute squares,
+// of the piece standing there (either side).
+func sanOf(t *testing.T, g *GameState, from, to board.Square) string {
+	t.Helper()
+	if g.Reversed {
+		from, to = 0x77-from, 0x77-to
+	}
+	piece := g.FindPieceAtSquare(from)
+	require.NotEqual(t, NoPiece, piece, "no piece on %s", from)
+	return g.SAN(Move{Piece: piece, From: from, To: to})
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		from, to board.Square
+		want     string
+	}{
+		{"pawn push", InitialFEN, 0x13, 0x33, "d4"},
+		{"knight", InitialFEN, 0x06, 0x25, "Nf3"},
+		{"black pawn", "rnbkqbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBKQBNR b - - 0 1", 0x64, 0x44, "e5"},
+		{"pawn capture", "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", 0x34, 0x43, "exd5"},
+		{"piece capture", "4k3/8/8/3p4/8/8/8/3RK3 w - - 0 1", 0x03, 0x43, "Rxd5"},
+		{"file disambiguation", "4k3/8/8/8/8/8/K7/R6R w - - 0 1", 0x00, 0x03, "Rad1"},
+		{"rank disambiguation", "4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", 0x00, 0x30, "R1a4"},
+		{"check", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", 0x00, 0x70, "Ra8+"},
+		{"mate", "4k3/8/4K3/8/8/8/8/R7 w - - 0 1", 0x00, 0x70, "Ra8#"},
+		{"side not to move", InitialFEN, 0x64, 0x44, "e5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGame(&bytes.Buffer{})
+			require.NoError(t, g.SetFEN(tt.fen))
+			assert.Equal(t, tt.want, sanOf(t, g, tt.from, tt.to))
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	typeKeys(g, "C1333\r6343\r")
+	assert.Equal(t, []RecordedMove{
+		{From: 0x13, To: 0x33, White: true, SAN: "d4"},
+		{From: 0x63, To: 0x43, White: false, SAN: "d5"},
+	}, g.Record())
+
+	typeKeys(g, "C")
+	assert.Empty(t, g.Record(), "C starts a new record")
+}
+
+func TestWritePGN(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	var sb strings.Builder
+	assert.Error(t, g.WritePGN(&sb), "no board set up")
+
+	typeKeys(g, "C1333\r6343\r1434\r")
+	sb.Reset()
+	require.NoError(t, g.WritePGN(&sb))
+	pgn := sb.String()
+	assert.Contains(t, pgn, "[Event \"MicroChess game\"]\n")
+	assert.Contains(t, pgn, "[Result \"*\"]\n")
+	assert.Contains(t, pgn, "[SetUp \"1\"]\n[FEN \""+InitialFEN+"\"]\n\n")
+	assert.True(t, strings.HasSuffix(pgn, "\n1. d4 d5 2. e4 *\n"), pgn)
+}
+
+func TestWritePGN_BlackStartsAndNullMoves(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("4k3/8/8/8/8/8/8/4K3 b - - 0 1"))
+	// Black moves twice in a row, since manual moves do not reverse the
+	// board; digits are stored squares, so e8 is 03 with black to move
+	typeKeys(g, "0304\r0405\r")
+
+	var sb strings.Builder
+	require.NoError(t, g.WritePGN(&sb))
+	assert.True(t, strings.HasSuffix(sb.String(), "\n1... Kd8 2. -- Kc8 *\n"), sb.String())
+}
+
+func TestWritePGN_StandardStart(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN(StandardFEN))
+	var sb strings.Builder
+	require.NoError(t, g.WritePGN(&sb))
+	assert.NotContains(t, sb.String(), "[FEN", "the standard start needs no FEN tag")
+}
+
+func TestParseMove(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("4k3/8/8/3p4/4P3/8/K7/R6R w - - 0 1"))
+	tests := []struct {
+		text     string
+		from, to board.Square
+		err      string
+	}{
+		{"e5", 0x34, 0x44, ""},
+		{"exd5", 0x34, 0x43, ""},
+		{"ed5", 0x34, 0x43, ""},
+		{"e4d5", 0x34, 0x43, ""},
+		{"Rad1+", 0x00, 0x03, ""},
+		{"Rhh2", 0x07, 0x17, ""},
+		{"h1-h8!", 0x07, 0x77, ""},
+		{"d5", 0, 0, "not legal"},
+		{"Rd1", 0, 0, "ambiguous"},
+		{"O-O", 0, 0, "no castling"},
+		{"e8=Q", 0, 0, "no promotion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := g.ParseMove(tt.text)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.from, m.From)
+			assert.Equal(t, tt.to, m.To)
+		})
+	}
+}
+
+func TestPlayMove(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN(StandardFEN))
+	for _, san := range []string{"e4", "e5", "Nf3"} {
+		m, err := g.ParseMove(san)
+		require.NoError(t, err)
+		g.PlayMove(m)
+	}
+	assert.True(t, g.Reversed, "black to move after three moves")
+	assert.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b - - 1 2", g.FEN())
+	assert.Equal(t, "Nf3", g.Record()[2].SAN)
+}
+
+func TestReadPGN(t *testing.T) {
+	pgn := `[Event "Test"]
+[White "A"]
+
+1. e4 {best by test} e5 2. Nf3 $1 (2. f4 exf4) Nc6 3. Bb5 a6 ; Ruy Lopez
+4. Bxc6 dxc6 1-0
+
+[Event "Second"]
+
+1. d4 *
+`
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.ReadPGN(strings.NewReader(pgn)))
+	assert.Len(t, g.Record(), 8, "only the first game, without the variation")
+	assert.Equal(t, "r1bqkbnr/1pp2ppp/p1p5/4p3/4P3/5N2/PPPP1PPP/RNBQK2R w - - 0 5", g.FEN())
+
+	var sb strings.Builder
+	require.NoError(t, g.WritePGN(&sb))
+	assert.True(t, strings.HasSuffix(sb.String(), "\n1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Bxc6 dxc6 *\n"), sb.String())
+
+	err := g.ReadPGN(strings.NewReader("1. e4 e5 2. Ke3 *"))
+	assert.ErrorContains(t, err, `PGN move 3: move "Ke3" is not legal`)
+	assert.ErrorContains(t, g.ReadPGN(strings.NewReader("1. e4 {open")), "comment is not closed")
+}
+
+func TestReadPGN_FENAndNullMove(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	pgn := "[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/8/4K3 b - - 0 1\"]\n\n1... Kd8 2. -- Kc8 *\n"
+	require.NoError(t, g.ReadPGN(strings.NewReader(pgn)))
+	assert.Equal(t, "2k5/8/8/8/8/8/8/4K3 w - - 2 3", g.FEN())
+
+	var sb strings.Builder
+	require.NoError(t, g.WritePGN(&sb))
+	assert.True(t, strings.HasSuffix(sb.String(), "\n1... Kd8 2. -- Kc8 *\n"), "round trip: %s", sb.String())
+}
+
+func TestUCIAndNamePV(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w - - 0 1"))
+	var pv []Move
+	c := g.Clone()
+	for _, san := range []string{"Nf3", "Nc6", "Bb5"} {
+		m, err := c.ParseMove(san)
+		require.NoError(t, err)
+		pv = append(pv, m)
+		c.PlayMove(m)
+	}
+	assert.Equal(t, "g1f3", g.UCI(pv[0]))
+	assert.Equal(t, []string{"g1f3", "b8c6", "f1b5"}, g.NamePV(pv, (*GameState).UCI))
+	assert.Equal(t, []string{"Nf3", "Nc6", "Bb5"}, g.NamePV(pv, (*GameState).SAN))
+	assert.Empty(t, g.Record(), "the PV is played on a clone")
+}
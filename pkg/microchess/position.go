@@ -0,0 +1,198 @@
+// ABOUTME: This file defines the Position interface shared by all board representations.
+// ABOUTME: GameState implements it with the original Board/BK piece lists; pkg/bitboard provides a fast backend.
+
+package microchess
+
+import (
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// Side identifies one of the two piece lists of a MicroChess position.
+//
+// The original program never talks about "white" and "black" internally:
+// the BOARD array always holds the side to move (drawn at the bottom,
+// pawns moving towards rank 7) and BK holds the opponent. REVERSE swaps them.
+type Side uint8
+
+const (
+	SideBoard Side = 0 // Pieces in the Board array (side to move)
+	SideBK    Side = 1 // Pieces in the BK array (opponent)
+)
+
+// Kind is the type of a piece, independent of which of the two rooks,
+// bishops, knights or eight pawns it is.
+type Kind uint8
+
+const (
+	KindKing Kind = iota
+	KindQueen
+	KindRook
+	KindBishop
+	KindKnight
+	KindPawn
+
+	NoKind Kind = 0xFF
+)
+
+// Kind returns the type of the piece at index p.
+// Indices 16-31 (BK pieces as returned by FindPieceAtSquare) are accepted too.
+//
+// The mapping follows the piece ordering used by GNM (assembly line 286):
+// 0 king, 1 queen, 2-3 rooks, 4-5 bishops, 6-7 knights, 8-15 pawns.
+func (p Piece) Kind() Kind {
+	if p == NoPiece {
+		return NoKind
+	}
+	switch i := p & 0x0F; {
+	case i >= 8:
+		return KindPawn
+	case i >= 6:
+		return KindKnight
+	case i >= 4:
+		return KindBishop
+	case i >= 2:
+		return KindRook
+	case i == 1:
+		return KindQueen
+	default:
+		return KindKing
+	}
+}
+
+// Letter returns the upper-case letter used for the kind in the board display.
+func (k Kind) Letter() string {
+	switch k {
+	case KindKing:
+		return "K"
+	case KindQueen:
+		return "Q"
+	case KindRook:
+		return "R"
+	case KindBishop:
+		return "B"
+	case KindKnight:
+		return "N"
+	case KindPawn:
+		return "P"
+	default:
+		return "?"
+	}
+}
+
+// kindNames are the English names of the kinds, in Kind order.
+var kindNames = [...]string{"king", "queen", "rook", "bishop", "knight", "pawn"}
+
+// Name returns the lower-case English name of the kind, like "knight".
+func (k Kind) Name() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "?"
+}
+
+// Position is a read-only view of a MicroChess position.
+//
+// Squares are always expressed in the frame of the side to move, exactly as
+// they are stored in the Board/BK arrays: after REVERSE every coordinate has
+// been transformed to $77 - square and IsReversed reports true.
+//
+// Two implementations exist: GameState itself (the faithful piece-list
+// representation) and bitboard.Position (64-bit occupancy sets), so the
+// faithful engine and a fast engine can be cross-checked on the same positions.
+type Position interface {
+	// PieceAt returns the piece on sq and the side it belongs to.
+	// found is false for empty or off-board squares.
+	PieceAt(sq board.Square) (piece Piece, side Side, found bool)
+
+	// PieceSquare returns the square of piece p (0-15) of the given side.
+	// Captured pieces report $CC, never-placed pieces report $FF.
+	PieceSquare(side Side, p Piece) board.Square
+
+	// IsReversed reports whether the REV flag is set.
+	IsReversed() bool
+
+	// LegalMoves returns every legal move for the side to move.
+	LegalMoves() []Move
+}
+
+// Compile-time check that GameState satisfies Position.
+var _ Position = (*GameState)(nil)
+
+// PieceAt implements Position using the same 32-entry scan as FindPieceAtSquare.
+func (g *GameState) PieceAt(sq board.Square) (Piece, Side, bool) {
+	if !sq.IsValid() {
+		return NoPiece, SideBoard, false
+	}
+	piece := g.FindPieceAtSquare(sq)
+	switch {
+	case piece == NoPiece:
+		return NoPiece, SideBoard, false
+	case piece >= 16:
+		return piece - 16, SideBK, true
+	default:
+		return piece, SideBoard, true
+	}
+}
+
+// PieceSquare implements Position by reading the Board or BK array.
+func (g *GameState) PieceSquare(side Side, p Piece) board.Square {
+	if side == SideBK {
+		return g.BK[p]
+	}
+	return g.Board[p]
+}
+
+// IsReversed implements Position.
+func (g *GameState) IsReversed() bool {
+	return g.Reversed
+}
+
+// LegalMoves implements Position by running GNM with STATE=4, so that CHKCHK
+// filters out moves which would leave the king capturable.
+// Moves are returned in GNM's natural generation order (piece 15 down to 0).
+func (g *GameState) LegalMoves() []Move {
+	return g.collectMoves(g.GNM)
+}
+
+// LegalCaptures returns the legal moves which capture an opponent piece,
+// generated by GNMCaptures with STATE=4 so that CHKCHK still applies.
+func (g *GameState) LegalCaptures() []Move {
+	return g.collectMoves(g.GNMCaptures)
+}
+
+// PseudoLegalMoves appends to dst the moves GNM generates without CHKCHK
+// (with STATE=8, which CMOVE does not check), in GNM order, and returns
+// it. Those that
+// leave the king capturable are still there: IsLegal tells them apart, so
+// a search only pays for the check test on the moves it actually plays
+// (NEW - not in original).
+func (g *GameState) PseudoLegalMoves(dst []Move) []Move {
+	return g.appendMoves(dst, 8, g.GNM)
+}
+
+// PseudoLegalCaptures is PseudoLegalMoves for captures only (GNMCaptures).
+func (g *GameState) PseudoLegalCaptures(dst []Move) []Move {
+	return g.appendMoves(dst, 8, g.GNMCaptures)
+}
+
+// collectMoves runs a generator with STATE=4 and collects the moves it reports.
+func (g *GameState) collectMoves(generate func(MoveCallback)) []Move {
+	return g.appendMoves(nil, 4, generate)
+}
+
+// appendMoves runs a generator with the given STATE and appends the moves
+// it reports to dst. The search registers (MovePiece, MoveSquare, MoveN,
+// State) are preserved.
+func (g *GameState) appendMoves(dst []Move, state int8, generate func(MoveCallback)) []Move {
+	savedState := g.State
+	savedMovePiece, savedMoveSquare, savedMoveN := g.MovePiece, g.MoveSquare, g.MoveN
+	g.State = state
+
+	generate(func(from, to board.Square, piece Piece) {
+		dst = append(dst, Move{From: from, To: to, Piece: piece})
+	})
+
+	g.State = savedState
+	g.MovePiece, g.MoveSquare, g.MoveN = savedMovePiece, savedMoveSquare, savedMoveN
+	return dst
+}
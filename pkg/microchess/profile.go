@@ -0,0 +1,212 @@
+// ABOUTME: This file implements evaluation profiles: piece values and STRATGY weights loaded from YAML or JSON.
+// ABOUTME: The default profile reproduces the 1976 POINTS table and STRATGY constants exactly.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PieceValues are the POINTS entries per piece kind.
+type PieceValues struct {
+	King   uint8 `yaml:"king" json:"king"`
+	Queen  uint8 `yaml:"queen" json:"queen"`
+	Rook   uint8 `yaml:"rook" json:"rook"`
+	Bishop uint8 `yaml:"bishop" json:"bishop"`
+	Knight uint8 `yaml:"knight" json:"knight"`
+	Pawn   uint8 `yaml:"pawn" json:"pawn"`
+}
+
+// StrategyWeights are the constants of the STRATGY formula.
+//
+// Assembly reference: lines 641-678 (LDA #$80 ... ADC #$40 ... ADC #$90,
+// WCAP0 added four times, BMAXC and BMCC subtracted twice each).
+type StrategyWeights struct {
+	Phase1Base uint8 `yaml:"phase1_base" json:"phase1_base"` // $80
+	Phase2Base uint8 `yaml:"phase2_base" json:"phase2_base"` // $40
+	Phase3Base uint8 `yaml:"phase3_base" json:"phase3_base"` // $90
+	WCAP0      uint8 `yaml:"wcap0" json:"wcap0"`             // 4 x WCAP0
+	BMAXC      uint8 `yaml:"bmaxc" json:"bmaxc"`             // 2 x BMAXC
+	BMCC       uint8 `yaml:"bmcc" json:"bmcc"`               // 2 x BMCC
+}
+
+// Profile is an engine personality: the piece values used by COUNTS and
+// the material evaluators, and the weights of the STRATGY formula.
+//
+// A profile file only needs the fields it changes, e.g.
+//
+//	name: aggressive
+//	strategy:
+//	  wcap0: 6
+type Profile struct {
+	Name     string          `yaml:"name" json:"name"`
+	Points   PieceValues     `yaml:"points" json:"points"`
+	Strategy StrategyWeights `yaml:"strategy" json:"strategy"`
+}
+
+// DefaultProfile returns the 1976 numbers.
+func DefaultProfile() Profile {
+	return Profile{
+		Name:   "1976",
+		Points: PieceValues{King: 11, Queen: 10, Rook: 6, Bishop: 4, Knight: 4, Pawn: 2},
+		Strategy: StrategyWeights{
+			Phase1Base: 0x80,
+			Phase2Base: 0x40,
+			Phase3Base: 0x90,
+			WCAP0:      4,
+			BMAXC:      2,
+			BMCC:       2,
+		},
+	}
+}
+
+// activeProfile is the profile POINTS and strategyWeights were built from.
+// Like POINTS it is shared by every game: set it at startup, not while a
+// search is running.
+var activeProfile = DefaultProfile()
+
+// strategyWeights are the weights STRATGY uses.
+var strategyWeights = activeProfile.Strategy
+
+// ActiveProfile returns the profile in use.
+func ActiveProfile() Profile {
+	return activeProfile
+}
+
+// Validate checks that a profile can be used: every piece must be worth
+// something and a pawn must be the cheapest piece, since the modern
+// evaluators express scores in pawns.
+func (p Profile) Validate() error {
+	v := p.Points
+	for _, pv := range []struct {
+		name  string
+		value uint8
+	}{{"king", v.King}, {"queen", v.Queen}, {"rook", v.Rook}, {"bishop", v.Bishop}, {"knight", v.Knight}, {"pawn", v.Pawn}} {
+		if pv.value == 0 {
+			return fmt.Errorf("profile %q: %s value must be positive", p.Name, pv.name)
+		}
+		if pv.value < v.Pawn {
+			return fmt.Errorf("profile %q: %s value %d is below the pawn value %d", p.Name, pv.name, pv.value, v.Pawn)
+		}
+	}
+	return nil
+}
+
+// UseProfile validates p and makes it the active profile, rewriting POINTS
+// and the STRATGY weights. An unnamed profile is called "custom".
+func UseProfile(p Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.Name == "" {
+		p.Name = "custom"
+	}
+	v := p.Points
+	POINTS = [16]uint8{
+		v.King,
+		v.Queen,
+		v.Rook, v.Rook,
+		v.Bishop, v.Bishop,
+		v.Knight, v.Knight,
+		v.Pawn, v.Pawn, v.Pawn, v.Pawn, v.Pawn, v.Pawn, v.Pawn, v.Pawn,
+	}
+	strategyWeights = p.Strategy
+	activeProfile = p
+	return nil
+}
+
+// ParseProfile decodes a profile from YAML or JSON (format "yaml" or
+// "json"). Fields missing from the document keep their 1976 values, except
+// the name, and unknown fields are an error so that typos do not go unnoticed.
+func ParseProfile(data []byte, format string) (Profile, error) {
+	p := DefaultProfile()
+	p.Name = ""
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			return Profile{}, fmt.Errorf("invalid JSON profile: %w", err)
+		}
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&p); err != nil {
+			return Profile{}, fmt.Errorf("invalid YAML profile: %w", err)
+		}
+	default:
+		return Profile{}, fmt.Errorf("unknown profile format %q (want yaml or json)", format)
+	}
+	if err := p.Validate(); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// LoadProfile reads a profile file; the format comes from its extension
+// (.json, .yaml or .yml). A profile without a name is named after the file.
+func LoadProfile(path string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format == "yml" {
+		format = "yaml"
+	}
+	p, err := ParseProfile(data, format)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return p, nil
+}
+
+// cmdProfile implements :profile: it shows the active profile, or loads
+// the one in FILE. A file that cannot be read or used changes nothing.
+func cmdProfile(_ context.Context, g *GameState, _ byte, args []string) error {
+	switch len(args) {
+	case 0:
+	case 1:
+		p, err := LoadProfile(args[0])
+		if err == nil {
+			err = UseProfile(p)
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("usage: :profile [FILE]")
+	}
+	_, _ = fmt.Fprintf(g.out, "Profile: %s\r\n", ActiveProfile().Name)
+	return nil
+}
+
+// SaveProfile writes p to path as JSON or YAML, depending on the extension.
+func SaveProfile(path string, p Profile) error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err = json.MarshalIndent(p, "", "  ")
+		data = append(data, '\n')
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(p)
+	default:
+		return fmt.Errorf("%s: unknown profile format (want .yaml, .yml or .json)", path)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
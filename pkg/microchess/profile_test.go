@@ -0,0 +1,102 @@
+// ABOUTME: This file contains tests for evaluation profiles.
+// ABOUTME: It checks that the default reproduces 1976, that YAML/JSON load, and that weights change scores.
+
+package microchess
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useProfile installs p for the duration of the test.
+func useProfile(t *testing.T, p Profile) {
+	t.Helper()
+	require.NoError(t, UseProfile(p))
+	t.Cleanup(func() { require.NoError(t, UseProfile(DefaultProfile())) })
+}
+
+func TestDefaultProfile_Reproduces1976(t *testing.T) {
+	saved := POINTS
+	useProfile(t, DefaultProfile())
+	assert.Equal(t, saved, POINTS)
+
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	assert.Equal(t, uint8(0xD0), g.Evaluate())
+	assert.Equal(t, "1976", ActiveProfile().Name)
+}
+
+func TestParseProfile(t *testing.T) {
+	yamlDoc := "name: greedy\npoints:\n  queen: 12\nstrategy:\n  wcap0: 6\n"
+	jsonDoc := `{"name": "greedy", "points": {"queen": 12}, "strategy": {"wcap0": 6}}`
+
+	for format, doc := range map[string]string{"yaml": yamlDoc, "json": jsonDoc} {
+		t.Run(format, func(t *testing.T) {
+			p, err := ParseProfile([]byte(doc), format)
+			require.NoError(t, err)
+
+			want := DefaultProfile()
+			want.Name = "greedy"
+			want.Points.Queen = 12
+			want.Strategy.WCAP0 = 6
+			assert.Equal(t, want, p)
+		})
+	}
+}
+
+func TestParseProfile_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		format string
+	}{
+		{"unknown field", "points:\n  quen: 12\n", "yaml"},
+		{"unknown JSON field", `{"strategy": {"wcap": 6}}`, "json"},
+		{"worthless piece", "points:\n  knight: 0\n", "yaml"},
+		{"piece cheaper than a pawn", "points:\n  pawn: 5\n", "yaml"},
+		{"value out of range", "points:\n  queen: 300\n", "yaml"},
+		{"unknown format", "name: x\n", "toml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProfile([]byte(tt.doc), tt.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadProfile_NamesAfterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "defensive.yml")
+	require.NoError(t, os.WriteFile(path, []byte("strategy:\n  bmaxc: 4\n"), 0o644))
+
+	p, err := LoadProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "defensive", p.Name)
+	assert.Equal(t, uint8(4), p.Strategy.BMAXC)
+}
+
+func TestUseProfile_ChangesScores(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+
+	p := DefaultProfile()
+	p.Name = "optimist"
+	p.Strategy.Phase3Base = 0x98
+	useProfile(t, p)
+	assert.Equal(t, uint8(0xD8), g.Evaluate(), "Phase 3 is not halved")
+
+	p.Points = PieceValues{King: 20, Queen: 18, Rook: 10, Bishop: 6, Knight: 6, Pawn: 2}
+	useProfile(t, p)
+	assert.Equal(t, uint8(18), POINTS[PieceQueen])
+	assert.Equal(t, uint8(2), POINTS[PiecePawn8])
+
+	g.ShowEvaluation()
+	assert.Contains(t, buf.String(), "Profile: optimist\r\n")
+}
@@ -0,0 +1,254 @@
+// ABOUTME: This file decouples board output from GameState: Display renders a Snapshot with a Renderer.
+// ABOUTME: It ships the faithful POUT renderer and a modern one with a-h/1-8 labels.
+
+package microchess
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// SquarePiece is the content of a square in a Snapshot.
+type SquarePiece struct {
+	Piece Piece // NoPiece for an empty square
+	White bool
+}
+
+// Snapshot is everything a Renderer may show: the pieces, the LED digits,
+// the orientation, the last move entered and the kings in check. It is a
+// copy, so renderers cannot change the game.
+type Snapshot struct {
+	// Squares holds the board as stored, indexed [rank][file] of the 0x88
+	// square (rank<<4 | file), which is also the POUT layout. When Reversed
+	// is set, stored square s is the absolute square $77-s.
+	Squares  [8][8]SquarePiece
+	LED      [3]uint8       // DIS1, DIS2, DIS3
+	Reversed bool           // The board is reversed (black to move)
+	LastMove Move           // Last move played with Enter, in stored squares; Piece is NoPiece if none
+	Checks   []board.Square // Stored squares of the kings that can be captured
+}
+
+// InCheck reports whether a king in check stands on the stored square sq.
+func (s *Snapshot) InCheck(sq board.Square) bool {
+	return slices.Contains(s.Checks, sq)
+}
+
+// At returns the content of the stored square sq.
+func (s *Snapshot) At(sq board.Square) SquarePiece {
+	return s.Squares[sq>>4][sq&0x07]
+}
+
+// Absolute converts a stored square to the absolute square, as named by
+// board.Square.String (white's first rank is rank 1).
+func (s *Snapshot) Absolute(sq board.Square) board.Square {
+	if s.Reversed {
+		return 0x77 - sq
+	}
+	return sq
+}
+
+// Snapshot returns what the board display shows now, kings in check included.
+func (g *GameState) Snapshot() Snapshot {
+	return g.snapshot(true)
+}
+
+// snapshot fills Checks only when checks is set: finding them costs an
+// attack scan that renderers without a check marker do not need.
+func (g *GameState) snapshot(checks bool) Snapshot {
+	s := Snapshot{LED: [3]uint8{g.DIS1, g.DIS2, g.DIS3}, Reversed: g.Reversed, LastMove: Move{Piece: NoPiece}}
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			piece, found, isWhite := g.FindPieceAt(board.Square(rank<<4 | file))
+			if !found {
+				piece = NoPiece
+			}
+			s.Squares[rank][file] = SquarePiece{Piece: piece, White: isWhite}
+		}
+	}
+	if g.lastMove.Piece != NoPiece {
+		s.LastMove = g.lastMove
+		if g.lastMoveReversed != g.Reversed {
+			s.LastMove.From, s.LastMove.To = 0x77-s.LastMove.From, 0x77-s.LastMove.To
+		}
+	}
+
+	if checks {
+		s.Checks = g.kingsInCheck()
+	}
+	return s
+}
+
+// kingsInCheck returns the stored squares of the kings attacked by the other
+// side, in one pass over both piece lists. Manual moves do not reverse the
+// board, so either king may be in check.
+func (g *GameState) kingsInCheck() []board.Square {
+	lists := [2][16]board.Square{g.Board, g.BK}
+	var kings []board.Square
+	for side := SideBoard; side <= SideBK; side++ {
+		king := lists[side][PieceKing]
+		if !king.IsValid() {
+			continue
+		}
+		for i, from := range lists[1-side] {
+			if from.IsValid() && attacks(&lists, 1-side, Piece(i), from, king) {
+				kings = append(kings, king)
+				break
+			}
+		}
+	}
+	return kings
+}
+
+// CheckRenderer is implemented by renderers that mark kings in check;
+// Display only looks for checks when ShowsCheck is true.
+type CheckRenderer interface {
+	ShowsCheck() bool
+}
+
+// showsCheck reports whether r marks kings in check.
+func showsCheck(r Renderer) bool {
+	c, ok := r.(CheckRenderer)
+	return ok && c.ShowsCheck()
+}
+
+// Renderer draws a Snapshot. Display uses the game's renderer for every
+// board it prints (C, E, P, Enter, H, ...); text around the board, such as
+// evaluation lines, is printed by the commands themselves.
+type Renderer interface {
+	Name() string
+	Render(w io.Writer, s Snapshot) error
+}
+
+// RendererNames lists the renderers RendererByName knows, default first.
+var RendererNames = []string{"pout", "modern", "unicode", "kim"}
+
+// RendererByName returns the renderer with the given name.
+func RendererByName(name string) (Renderer, error) {
+	switch name {
+	case "pout":
+		return POUTRenderer{}, nil
+	case "modern":
+		return ModernRenderer{}, nil
+	case "unicode":
+		return UnicodeRenderer{Color: true}, nil
+	case "kim":
+		return KIMRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown renderer %q (want %s)", name, strings.Join(RendererNames, ", "))
+}
+
+// SetRenderer installs the renderer used by Display (nil restores POUT).
+func (g *GameState) SetRenderer(r Renderer) {
+	g.renderer = r
+}
+
+// ActiveRenderer returns the renderer used by Display.
+func (g *GameState) ActiveRenderer() Renderer {
+	if g.renderer == nil {
+		return POUTRenderer{}
+	}
+	return g.renderer
+}
+
+// POUTRenderer prints the board exactly like the POUT routine of the 2002
+// serial version (assembly line 702): hex coordinates, WP/BP piece codes,
+// ** on odd squares and the three LED bytes underneath. The board is shown
+// as stored, so it turns over when reversed, and the last move is not marked.
+type POUTRenderer struct{}
+
+// Name implements Renderer.
+func (POUTRenderer) Name() string {
+	return "pout"
+}
+
+// Render implements Renderer.
+func (POUTRenderer) Render(w io.Writer, s Snapshot) error {
+	var sb strings.Builder
+	sb.WriteString("MicroChess (c) 1996-2005 Peter Jennings, www.benlo.com\r\n")
+	sb.WriteString(" 00 01 02 03 04 05 06 07\r\n")
+	sb.WriteString("-------------------------\r\n")
+
+	// Display ranks 0 to 7 (original displays 00-70)
+	// The original scans Y from 0x00 to 0x77 in 0x88 format
+	for rank := 0; rank <= 7; rank++ {
+		sb.WriteString("|")
+		for file := 0; file < 8; file++ {
+			sp := s.Squares[rank][file]
+			switch {
+			case sp.Piece != NoPiece:
+				sb.WriteString(GetPieceChar(sp.Piece, sp.White))
+			case (rank+file)%2 == 1:
+				// Checkerboard pattern for empty squares
+				// Original: check if (file + rank) is odd for asterisk
+				sb.WriteString("**")
+			default:
+				sb.WriteString("  ")
+			}
+			sb.WriteString("|")
+		}
+		// Print rank number in hex on the right (00, 10, 20, ...)
+		fmt.Fprintf(&sb, "%X0\r\n", rank)
+	}
+
+	sb.WriteString("-------------------------\r\n")
+	sb.WriteString(" 00 01 02 03 04 05 06 07\r\n")
+
+	// Print LED display (DIS1 DIS2 DIS3)
+	fmt.Fprintf(&sb, "%02X %02X %02X\r\n", s.LED[0], s.LED[1], s.LED[2])
+	sb.WriteString("\r\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ModernRenderer prints the board the way chess books do: white at the
+// bottom whatever the orientation, files a-h and ranks 1-8 around it,
+// FEN letters (upper case for white, "." for empty squares), then the LED
+// bytes and the last move in algebraic squares.
+type ModernRenderer struct{}
+
+// Name implements Renderer.
+func (ModernRenderer) Name() string {
+	return "modern"
+}
+
+// Render implements Renderer.
+func (ModernRenderer) Render(w io.Writer, s Snapshot) error {
+	var sb strings.Builder
+	sb.WriteString("    a b c d e f g h\r\n")
+	sb.WriteString("  +-----------------+\r\n")
+	for rank := 7; rank >= 0; rank-- {
+		fmt.Fprintf(&sb, "%d |", rank+1)
+		for file := 0; file < 8; file++ {
+			sp := s.At(s.Absolute(board.Square(rank<<4 | file))) // Absolute is its own inverse
+			sb.WriteString(" ")
+			sb.WriteString(pieceLetter(sp))
+		}
+		fmt.Fprintf(&sb, " | %d\r\n", rank+1)
+	}
+	sb.WriteString("  +-----------------+\r\n")
+	sb.WriteString("    a b c d e f g h\r\n")
+
+	fmt.Fprintf(&sb, "LED %02X %02X %02X", s.LED[0], s.LED[1], s.LED[2])
+	if s.LastMove.Piece != NoPiece {
+		fmt.Fprintf(&sb, "   last move %s-%s", s.Absolute(s.LastMove.From), s.Absolute(s.LastMove.To))
+	}
+	sb.WriteString("\r\n\r\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// pieceLetter returns the FEN letter of a square's piece, or "." if empty.
+func pieceLetter(sp SquarePiece) string {
+	if sp.Piece == NoPiece {
+		return "."
+	}
+	letter := sp.Piece.Kind().Letter()
+	if !sp.White {
+		return strings.ToLower(letter)
+	}
+	return letter
+}
@@ -0,0 +1,184 @@
+// ABOUTME: This file contains tests for board snapshots and the POUT and modern renderers.
+// ABOUTME: It checks that POUT output is unchanged and that the modern board is labelled and oriented.
+
+package microchess
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPOUTRenderer_SetupBoard(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	g.DIS1, g.DIS2, g.DIS3 = 0xCC, 0xCC, 0xCC
+
+	g.Display()
+
+	want := "MicroChess (c) 1996-2005 Peter Jennings, www.benlo.com\r\n" +
+		" 00 01 02 03 04 05 06 07\r\n" +
+		"-------------------------\r\n" +
+		"|WR|WN|WB|WK|WQ|WB|WN|WR|00\r\n" +
+		"|WP|WP|WP|WP|WP|WP|WP|WP|10\r\n" +
+		"|  |**|  |**|  |**|  |**|20\r\n" +
+		"|**|  |**|  |**|  |**|  |30\r\n" +
+		"|  |**|  |**|  |**|  |**|40\r\n" +
+		"|**|  |**|  |**|  |**|  |50\r\n" +
+		"|BP|BP|BP|BP|BP|BP|BP|BP|60\r\n" +
+		"|BR|BN|BB|BK|BQ|BB|BN|BR|70\r\n" +
+		"-------------------------\r\n" +
+		" 00 01 02 03 04 05 06 07\r\n" +
+		"CC CC CC\r\n" +
+		"\r\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestSnapshot(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	s := g.Snapshot()
+	assert.Equal(t, SquarePiece{Piece: PieceKing, White: true}, s.At(0x03))
+	assert.Equal(t, SquarePiece{Piece: PieceQueen, White: false}, s.At(0x74))
+	assert.Equal(t, NoPiece, s.At(0x33).Piece)
+	assert.Equal(t, NoPiece, s.LastMove.Piece)
+
+	for _, c := range "1333\r" {
+		g.HandleCharacter(byte(c))
+	}
+	s = g.Snapshot()
+	assert.Equal(t, Move{From: 0x13, To: 0x33, Piece: g.SelectedPiece}, s.LastMove)
+	assert.Equal(t, [3]uint8{0xFF, 0x13, 0x33}, s.LED)
+
+	g.Reverse()
+	s = g.Snapshot()
+	assert.True(t, s.Reversed)
+	assert.Equal(t, Move{From: 0x64, To: 0x44, Piece: g.SelectedPiece}, s.LastMove, "the last move follows the board")
+	assert.Equal(t, "d4", s.Absolute(s.LastMove.To).String())
+
+	g.SetupBoard()
+	assert.Equal(t, NoPiece, g.Snapshot().LastMove.Piece)
+}
+
+func TestModernRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.SetupBoard()
+	g.SetRenderer(ModernRenderer{})
+	for _, c := range "1333\r" {
+		g.HandleCharacter(byte(c))
+	}
+
+	buf.Reset()
+	g.Display()
+	lines := strings.Split(buf.String(), "\r\n")
+	require.GreaterOrEqual(t, len(lines), 13)
+	assert.Equal(t, "    a b c d e f g h", lines[0])
+	assert.Equal(t, "8 | r n b k q b n r | 8", lines[2])
+	assert.Equal(t, "4 | . . . P . . . . | 4", lines[6])
+	assert.Equal(t, "1 | R N B K Q B N R | 1", lines[9])
+	assert.Equal(t, "LED FF 13 33   last move d2-d4", lines[12])
+
+	// Reversing turns the POUT board over but not this one
+	before := lines[:12]
+	buf.Reset()
+	g.HandleCharacter('E')
+	lines = strings.Split(buf.String(), "\r\n")
+	require.GreaterOrEqual(t, len(lines), 14)
+	assert.Equal(t, before, lines[1:13])
+	assert.Equal(t, "LED EE EE EE   last move d2-d4", lines[13])
+}
+
+func TestRendererByName(t *testing.T) {
+	for _, name := range RendererNames {
+		r, err := RendererByName(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, r.Name())
+	}
+	_, err := RendererByName("teletype")
+	assert.ErrorContains(t, err, "unknown renderer")
+
+	g := NewGame(&bytes.Buffer{})
+	assert.Equal(t, "pout", g.ActiveRenderer().Name())
+}
+
+func TestSnapshot_Checks(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("R2k4/8/8/8/8/8/8/3K3r w")) // Both kings attacked along their rank
+	s := g.Snapshot()
+	assert.ElementsMatch(t, []board.Square{0x03, 0x73}, s.Checks)
+
+	require.NoError(t, g.SetFEN("R2k4/8/8/8/8/8/8/3K4 b"))
+	s = g.Snapshot()
+	assert.Equal(t, []board.Square{0x77 - 0x73}, s.Checks, "stored squares, the board is reversed")
+	assert.True(t, s.InCheck(s.Absolute(0x73)))
+	assert.Equal(t, "R2k4/8/8/8/8/8/8/3K4 b - - 0 1", g.FEN(), "the position is left as it was")
+}
+
+func TestUnicodeRenderer(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("3k4/8/8/8/8/8/8/3K3r w"))
+	s := g.Snapshot()
+	s.LastMove = Move{From: 0x67, To: 0x07, Piece: PieceRook1}
+
+	var plain bytes.Buffer
+	require.NoError(t, UnicodeRenderer{}.Render(&plain, s))
+	out := plain.String()
+	assert.NotContains(t, out, "\x1b", "no escape sequences without colour")
+	lines := strings.Split(out, "\r\n")
+	assert.Equal(t, " 8     ·     ♚     ·     ·  8", lines[1])
+	assert.Equal(t, " 7  ·     ·     ·     · [ ] 7", lines[2])
+	assert.Equal(t, " 1  ·     · !♔! ·     · [♜] 1", lines[8])
+	assert.Equal(t, "LED 00 00 00   last move h7-h1   check", lines[10])
+
+	var color bytes.Buffer
+	require.NoError(t, UnicodeRenderer{Color: true}.Render(&color, s))
+	out = color.String()
+	assert.Contains(t, out, ansiCheck+ansiWhiteMan+" ♚ "+ansiReset, "the white king in check is on red")
+	assert.Contains(t, out, ansiLastMove+ansiBlackMan+" ♜ "+ansiReset)
+	assert.Contains(t, out, ansiLastMove+"   "+ansiReset)
+	assert.Contains(t, out, ansiDark+"   "+ansiReset)
+	assert.Contains(t, out, ansiLight+"   "+ansiReset)
+}
+
+func TestSnapshot_ChecksOnlyForCheckRenderers(t *testing.T) {
+	assert.False(t, showsCheck(POUTRenderer{}))
+	assert.False(t, showsCheck(KIMRenderer{}))
+	assert.True(t, showsCheck(UnicodeRenderer{}))
+
+	g := NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("R2k4/8/8/8/8/8/8/3K3r w"))
+	assert.Empty(t, g.snapshot(false).Checks)
+	assert.Len(t, g.snapshot(true).Checks, 2)
+}
+
+func TestKingsInCheck_AgreesWithInCheck(t *testing.T) {
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/3p4/4K3 w", // Pawn check
+		"4k3/8/5N2/8/8/8/8/4K3 b", // Knight check
+		"4k3/8/8/1B6/8/8/8/4K3 b", // Bishop check
+		"4k3/8/8/8/8/8/3P4/4K3 b", // Pawn behind, no check
+		"4k3/4r3/8/8/8/8/4P3/4K3 w",
+		"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR w",
+	} {
+		g := NewGame(&bytes.Buffer{})
+		require.NoError(t, g.SetFEN(fen), fen)
+		var want []board.Square
+		if g.InCheck() {
+			want = append(want, g.Board[PieceKing])
+		}
+		king := g.BK[PieceKing]
+		g.Reverse()
+		if g.InCheck() {
+			want = append(want, king)
+		}
+		g.Reverse()
+		assert.Equal(t, want, g.kingsInCheck(), fen)
+	}
+}
@@ -0,0 +1,223 @@
+// ABOUTME: This file implements static exchange evaluation (SEE) and the T command that explains it.
+// ABOUTME: Attackers are found with the MOVEX directions GNM uses; the exchange is played out least valuable first.
+
+package microchess
+
+import (
+	"fmt"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// ExchangeStep is one capture of an exchange sequence.
+type ExchangeStep struct {
+	Side     Side         // SideBoard for the side to move, SideBK for the opponent
+	Piece    Piece        // The capturing piece (0-15 in its side's list)
+	From     board.Square // Where the capturing piece came from
+	Captured Piece        // The piece taken on the target square
+	Balance  int          // Material won by the side to move if the exchange stops here
+}
+
+// Exchange is the result of a static exchange evaluation on one square.
+type Exchange struct {
+	Square board.Square
+	Steps  []ExchangeStep // Every capture available, least valuable attacker first
+	Played int            // How many of Steps are worth playing; the rest would lose material
+	Score  int            // Material won by the side to move, in POINTS units
+}
+
+// SEE returns the material won (or lost, if negative) by the capture m
+// once the exchange on its target square is played out, in POINTS units.
+// It is 0 for a move that captures nothing.
+func (g *GameState) SEE(m Move) int {
+	return g.Exchange(m).Score
+}
+
+// Exchange plays out the captures on the target square of m.
+//
+// COUNTS only looks at the victim's POINTS value, so a queen taking a
+// defended pawn looks like a pawn won. Here m is played first, then each
+// side recaptures with its least valuable attacker, and either side may
+// stop capturing when continuing would lose material (the swap algorithm).
+//
+// Attackers are found the way GNM finds moves: single steps along the
+// MOVEX directions for the king and knights, repeated steps for the
+// sliders, and the two diagonal steps forward for pawns. A piece that
+// joins the exchange leaves its square, so pieces behind it (x-rays) join
+// in turn. A king only recaptures if the square is no longer defended.
+// The position is not changed.
+func (g *GameState) Exchange(m Move) Exchange {
+	ex := Exchange{Square: m.To}
+	victim, side, found := g.PieceAt(m.To)
+	if !found || side != SideBK || m.Piece > PiecePawn8 {
+		return ex
+	}
+
+	lists := [2][16]board.Square{g.Board, g.BK}
+	onSquare := m.Piece // The piece standing on the target square, of side mover
+	mover := SideBoard
+	balance := int(POINTS[victim])
+	lists[SideBK][victim] = 0xCC
+	lists[SideBoard][m.Piece] = m.To
+	ex.Steps = append(ex.Steps, ExchangeStep{Side: SideBoard, Piece: m.Piece, From: m.From, Captured: victim, Balance: balance})
+
+	for {
+		next := mover ^ 1
+		attacker, ok := leastValuableAttacker(&lists, next, m.To)
+		if !ok {
+			break
+		}
+		if attacker == PieceKing {
+			if _, defended := leastValuableAttacker(&lists, mover, m.To); defended {
+				break // The king cannot take a defended piece
+			}
+		}
+		if next == SideBoard {
+			balance += int(POINTS[onSquare])
+		} else {
+			balance -= int(POINTS[onSquare])
+		}
+		ex.Steps = append(ex.Steps, ExchangeStep{Side: next, Piece: attacker, From: lists[next][attacker], Captured: onSquare, Balance: balance})
+		lists[mover][onSquare] = 0xCC
+		lists[next][attacker] = m.To
+		onSquare, mover = attacker, next
+	}
+
+	// Minimax from the end: the side making capture i plays it only if the
+	// best outcome after it beats stopping before it
+	n := len(ex.Steps)
+	value := make([]int, n) // value[i]: outcome for the side to move if capture i is played
+	value[n-1] = ex.Steps[n-1].Balance
+	for i := n - 1; i > 0; i-- {
+		stop := ex.Steps[i-1].Balance
+		if ex.Steps[i].Side == SideBoard {
+			value[i-1] = max(stop, value[i]) // The side to move chooses capture i
+		} else {
+			value[i-1] = min(stop, value[i]) // The opponent chooses
+		}
+	}
+	ex.Score = value[0]
+	ex.Played = 1
+	for i := 1; i < n; i++ {
+		stop := ex.Steps[i-1].Balance
+		if (ex.Steps[i].Side == SideBoard && value[i] <= stop) || (ex.Steps[i].Side == SideBK && value[i] >= stop) {
+			break
+		}
+		ex.Played++
+	}
+	return ex
+}
+
+// leastValuableAttacker returns the cheapest piece of side (by POINTS)
+// attacking sq, given the piece squares in lists. On equal values the
+// higher index wins, so pawns go before knights before bishops, as in GNM
+// order.
+func leastValuableAttacker(lists *[2][16]board.Square, side Side, sq board.Square) (Piece, bool) {
+	best, found := NoPiece, false
+	for i := 15; i >= 0; i-- {
+		p := Piece(i)
+		from := lists[side][p]
+		if !from.IsValid() || from == sq || (found && POINTS[p] >= POINTS[best]) {
+			continue
+		}
+		if attacks(lists, side, p, from, sq) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// attacks reports whether piece p of side, standing on from, attacks sq.
+// The MOVEX index ranges are the ones GNM loops over for each piece.
+func attacks(lists *[2][16]board.Square, side Side, p Piece, from, sq board.Square) bool {
+	var first, last uint8
+	slides := true
+	switch p.Kind() {
+	case KindKing:
+		first, last, slides = 1, 8, false
+	case KindQueen:
+		first, last = 1, 8
+	case KindRook:
+		first, last = 1, 4
+	case KindBishop:
+		first, last = 5, 8
+	case KindKnight:
+		first, last, slides = 9, 16, false
+	case KindPawn:
+		// Board pawns capture up (MOVEX 5 and 6), BK pawns down (7 and 8)
+		first, last, slides = 5, 6, false
+		if side == SideBK {
+			first, last = 7, 8
+		}
+	}
+
+	for moven := first; moven <= last; moven++ {
+		to := int(from)
+		for {
+			to += int(MOVEX[moven])
+			if to&0x88 != 0 {
+				break
+			}
+			if board.Square(to) == sq {
+				return true
+			}
+			if !slides || occupied(lists, board.Square(to)) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// occupied reports whether any piece in lists stands on sq.
+func occupied(lists *[2][16]board.Square, sq board.Square) bool {
+	for side := range lists {
+		for _, s := range lists[side] {
+			if s == sq {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExplainCapture prints the exchange started by the move entered with the
+// digit keys (DIS2 to DIS3), as for the T command: every capture in
+// order, the material balance after it, and where the exchange should stop.
+func (g *GameState) ExplainCapture() {
+	from, to := board.Square(g.DIS2), board.Square(g.DIS3)
+	piece, side, found := g.PieceAt(from)
+	if g.DigitCount < 4 || !found || side != SideBoard {
+		_, _ = fmt.Fprintf(g.out, "Enter a capture with the digit keys first (from and to square), then T\r\n")
+		return
+	}
+	ex := g.Exchange(Move{From: from, To: to, Piece: piece})
+	if len(ex.Steps) == 0 {
+		_, _ = fmt.Fprintf(g.out, "No capture on %02X\r\n", uint8(to))
+		return
+	}
+
+	_, _ = fmt.Fprintf(g.out, "Exchange on %02X:\r\n", uint8(to))
+	for i, s := range ex.Steps {
+		white := (s.Side == SideBoard) != g.Reversed
+		note := ""
+		if i == ex.Played {
+			note = "  <- stop before this"
+		}
+		_, _ = fmt.Fprintf(g.out, "%2d. %s %02X takes %s  balance %+d%s\r\n", i+1,
+			GetPieceChar(s.Piece, white), uint8(s.From), GetPieceChar(s.Captured, !white), s.Balance, note)
+	}
+	_, _ = fmt.Fprintf(g.out, "SEE %+d (%s)\r\n", ex.Score, seeVerdict(ex.Score))
+}
+
+// seeVerdict describes an exchange score in words.
+func seeVerdict(score int) string {
+	switch {
+	case score > 0:
+		return "wins material"
+	case score < 0:
+		return "loses material"
+	default:
+		return "even trade"
+	}
+}
@@ -0,0 +1,106 @@
+// ABOUTME: This file contains tests for static exchange evaluation.
+// ABOUTME: It covers defended and undefended victims, x-rays, king recaptures and the T command.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gameFromFEN returns a game set up from fen, writing to out.
+func gameFromFEN(t *testing.T, fen string, out *bytes.Buffer) *GameState {
+	t.Helper()
+	g := NewGame(out)
+	require.NoError(t, g.SetFEN(fen))
+	return g
+}
+
+// moveFrom returns the legal move from one square to another.
+func moveFrom(t *testing.T, g *GameState, from, to board.Square) Move {
+	t.Helper()
+	for _, m := range g.LegalMoves() {
+		if m.From == from && m.To == to {
+			return m
+		}
+	}
+	require.Failf(t, "no such move", "%02X-%02X", uint8(from), uint8(to))
+	return Move{}
+}
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		from, to board.Square
+		want     int
+		steps    int
+		played   int
+	}{
+		{"undefended pawn", "7k/8/8/3p4/8/8/8/K2Q4 w", 0x03, 0x43, 2, 1, 1},
+		{"queen takes defended pawn", "7k/8/4p3/3p4/8/8/8/K2Q4 w", 0x03, 0x43, -8, 2, 2},
+		{"rook battery x-ray", "3r3k/8/8/3p4/8/8/3R4/K2R4 w", 0x13, 0x43, 2, 3, 1},
+		{"king cannot take a defended pawn", "4r2k/8/5p2/4n3/3PK3/8/8/8 w", 0x33, 0x44, 2, 2, 2},
+		{"king takes an undefended pawn", "7k/8/5p2/4n3/3PK3/8/8/8 w", 0x33, 0x44, 4, 3, 1}, // Recapturing gains black nothing
+		{"quiet move", "7k/8/8/3p4/8/8/8/K2Q4 w", 0x03, 0x13, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gameFromFEN(t, tt.fen, &bytes.Buffer{})
+			before := *g
+			m := moveFrom(t, g, tt.from, tt.to)
+
+			ex := g.Exchange(m)
+			assert.Equal(t, tt.want, ex.Score)
+			assert.Len(t, ex.Steps, tt.steps)
+			assert.Equal(t, tt.played, ex.Played)
+			assert.Equal(t, tt.want, g.SEE(m))
+			assert.Equal(t, before.Board, g.Board, "the position is not changed")
+			assert.Equal(t, before.BK, g.BK)
+		})
+	}
+}
+
+func TestSEE_UsesProfileValues(t *testing.T) {
+	g := gameFromFEN(t, "7k/8/4p3/3p4/8/8/8/K2Q4 w", &bytes.Buffer{})
+	m := moveFrom(t, g, 0x03, 0x43)
+
+	p := DefaultProfile()
+	p.Points.Queen = 20
+	useProfile(t, p)
+	assert.Equal(t, -18, g.SEE(m))
+}
+
+func TestExplainCapture(t *testing.T) {
+	var out bytes.Buffer
+	g := gameFromFEN(t, "3r3k/8/8/3p4/8/8/3R4/K2R4 w", &out)
+	for _, c := range []byte("1343") {
+		g.HandleCharacter(c)
+	}
+	out.Reset()
+	g.HandleCharacter('T')
+
+	assert.Contains(t, out.String(), "Exchange on 43:\r\n")
+	assert.Contains(t, out.String(), " 1. WR 13 takes BP  balance +2\r\n")
+	assert.Contains(t, out.String(), " 2. BR 73 takes WR  balance -4  <- stop before this\r\n")
+	assert.Contains(t, out.String(), " 3. WR 03 takes BR  balance +2\r\n")
+	assert.Contains(t, out.String(), "SEE +2 (wins material)\r\n")
+}
+
+func TestExplainCapture_NeedsACapture(t *testing.T) {
+	var out bytes.Buffer
+	g := gameFromFEN(t, "3r3k/8/8/3p4/8/8/3R4/K2R4 w", &out)
+	g.HandleCharacter('T')
+	assert.Contains(t, out.String(), "Enter a capture with the digit keys first")
+
+	for _, c := range []byte("1323") {
+		g.HandleCharacter(c)
+	}
+	out.Reset()
+	g.HandleCharacter('T')
+	assert.Contains(t, out.String(), "No capture on 23")
+}
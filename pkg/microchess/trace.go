@@ -0,0 +1,399 @@
+// ABOUTME: This file records the tree an engine searches (moves, STATE, GNMZ counters, scores) for inspection.
+// ABOUTME: A Trace is exported as a Graphviz DOT graph or as JSON after the H and A commands.
+
+package microchess
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TraceKind tells which part of a search visited a node.
+type TraceKind string
+
+const (
+	// TraceScan is a candidate scored by the faithful engine's one-ply STRATGY scan.
+	TraceScan TraceKind = "scan"
+	// TraceSearch is an alpha-beta node.
+	TraceSearch TraceKind = "search"
+	// TraceQuiesce is a node of the capture-only quiescence search.
+	TraceQuiesce TraceKind = "quiesce"
+)
+
+// TraceEval is one Evaluate call made at a node: the STATE values JANUS
+// ran under while the counters were generated and what GNMZ wrote into
+// the W and B registers. Evaluators that score both sides (such as
+// search.Symmetric) evaluate a node twice, once from each side.
+type TraceEval struct {
+	States   []TraceState `json:"states"`
+	Black    bool         `json:"black"` // The W registers are black's (the board was reversed)
+	WMOB     uint8        `json:"wmob"`
+	WMAXC    uint8        `json:"wmaxc"`
+	WCC      uint8        `json:"wcc"`
+	BMOB     uint8        `json:"bmob"`
+	BMAXC    uint8        `json:"bmaxc"`
+	BMCC     uint8        `json:"bmcc"`
+	Strategy uint8        `json:"strategy"` // STRATGY of these counters
+}
+
+// TraceState counts the JANUS calls made at one STATE value. GNMZ runs
+// with STATE 4, where JANUS calls COUNTS for each move; every one of
+// those moves is first checked by CHKCHK, whose GNM of the replies runs
+// with STATE -7, where JANUS looks for a capture of the king.
+type TraceState struct {
+	State int8 `json:"state"`
+	Janus int  `json:"janus"`
+}
+
+// TraceNode is a position visited by a search.
+type TraceNode struct {
+	ID     int
+	Parent int // -1 for a root
+	Ply    int
+	Kind   TraceKind
+	Move   Move // The move leading here; Piece is NoPiece at a root
+	Black  bool // Black to move in this position
+
+	// Search window, for engines that have one
+	Window      bool
+	Depth       int // Remaining depth
+	Alpha, Beta int
+
+	Score int  // From the point of view of the side to move
+	PV    bool // On the principal variation of the move chosen
+	Evals []TraceEval
+}
+
+// Trace records the nodes a search visits, up to MaxDepth plies below the
+// root and MaxNodes nodes in all (0 means no limit). Nodes past either
+// limit are counted in Omitted; the subtree below an omitted node is
+// omitted too.
+//
+// Engines report nodes with Enter and Leave, which must be paired, and
+// Evaluate attaches its counters to the innermost open node. All methods
+// do nothing on a nil *Trace, so engines call them unconditionally.
+// A Trace is not safe for concurrent use: only the main search thread
+// records (Clone drops the trace, so helper threads never see it).
+type Trace struct {
+	MaxDepth int
+	MaxNodes int
+	Engine   string // Engine that searched
+	FEN      string // Position searched
+
+	Nodes   []TraceNode
+	Omitted int
+
+	stack []int    // Open nodes, -1 for omitted ones
+	janus [256]int // JANUS calls per STATE (as uint8) since the evaluation under way began
+}
+
+// NewTrace returns an empty trace with the given limits.
+func NewTrace(maxDepth, maxNodes int) *Trace {
+	return &Trace{MaxDepth: maxDepth, MaxNodes: maxNodes}
+}
+
+// Reset drops the recorded nodes, keeping the limits. The alpha-beta
+// engine resets the trace at each iteration, so only the last one is kept.
+func (t *Trace) Reset() {
+	if t == nil {
+		return
+	}
+	t.Nodes = t.Nodes[:0]
+	t.Omitted = 0
+	t.stack = t.stack[:0]
+}
+
+// Enter opens a node for the position of g, reached by m (NoPiece for a
+// root). It becomes a child of the innermost open node.
+func (t *Trace) Enter(g *GameState, kind TraceKind, m Move) {
+	if t == nil {
+		return
+	}
+	parent, ply := -1, len(t.stack)
+	if ply > 0 {
+		parent = t.stack[ply-1]
+	}
+	if (ply > 0 && parent < 0) || (t.MaxDepth > 0 && ply > t.MaxDepth) || (t.MaxNodes > 0 && len(t.Nodes) >= t.MaxNodes) {
+		t.Omitted++
+		t.stack = append(t.stack, -1)
+		return
+	}
+	id := len(t.Nodes)
+	t.Nodes = append(t.Nodes, TraceNode{ID: id, Parent: parent, Ply: ply, Kind: kind, Move: m, Black: g.Reversed})
+	t.stack = append(t.stack, id)
+}
+
+// Window records the search window of the innermost open node.
+func (t *Trace) Window(depth, alpha, beta int) {
+	if n := t.current(); n != nil {
+		n.Window, n.Depth, n.Alpha, n.Beta = true, depth, alpha, beta
+	}
+}
+
+// Leave closes the innermost open node with its score.
+func (t *Trace) Leave(score int) {
+	if t == nil || len(t.stack) == 0 {
+		return
+	}
+	if n := t.current(); n != nil {
+		n.Score = score
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// beginEval forgets the JANUS calls made before an evaluation, so that
+// evaluated only reports those of the evaluation itself.
+func (t *Trace) beginEval() {
+	if t != nil {
+		t.janus = [256]int{}
+	}
+}
+
+// janusRan counts a call of JANUS at the given STATE.
+func (t *Trace) janusRan(state int8) {
+	if t != nil {
+		t.janus[uint8(state)]++
+	}
+}
+
+// evaluated attaches the counters Evaluate has just computed, and the
+// STATE values JANUS ran under since beginEval, to the innermost open
+// node. Evaluations outside a search (the S command) are not recorded.
+func (t *Trace) evaluated(g *GameState, score uint8) {
+	n := t.current()
+	if n == nil {
+		return
+	}
+	var states []TraceState
+	for s := -128; s < 128; s++ {
+		if calls := t.janus[uint8(s)]; calls > 0 {
+			states = append(states, TraceState{State: int8(s), Janus: calls})
+		}
+	}
+	n.Evals = append(n.Evals, TraceEval{
+		States: states, Black: g.Reversed,
+		WMOB: g.WMOB, WMAXC: g.WMAXC, WCC: g.WCC,
+		BMOB: g.BMOB, BMAXC: g.BMAXC, BMCC: g.BMCC,
+		Strategy: score,
+	})
+}
+
+// current returns the innermost open node, or nil if there is none or it
+// was omitted.
+func (t *Trace) current() *TraceNode {
+	if t == nil || len(t.stack) == 0 || t.stack[len(t.stack)-1] < 0 {
+		return nil
+	}
+	return &t.Nodes[t.stack[len(t.stack)-1]]
+}
+
+// MarkPV flags the nodes of the principal variation pv. The last root
+// whose children include pv[0] is followed (with multi-PV there is one
+// root per line), as deep as the recorded nodes go.
+func (t *Trace) MarkPV(pv []Move) {
+	if t == nil || len(pv) == 0 {
+		return
+	}
+	for root := len(t.Nodes) - 1; root >= 0; root-- {
+		if t.Nodes[root].Parent >= 0 || t.child(root, pv[0]) < 0 {
+			continue
+		}
+		t.Nodes[root].PV = true
+		node := root
+		for _, m := range pv {
+			if node = t.child(node, m); node < 0 {
+				break
+			}
+			t.Nodes[node].PV = true
+		}
+		return
+	}
+}
+
+// child returns the last recorded child of node reached by m, or -1.
+func (t *Trace) child(node int, m Move) int {
+	for i := len(t.Nodes) - 1; i > node; i-- {
+		if t.Nodes[i].Parent == node && t.Nodes[i].Move == m {
+			return i
+		}
+	}
+	return -1
+}
+
+// label describes a node in a few lines, for the DOT graph.
+func (n *TraceNode) label() string {
+	var lines []string
+	side := "white"
+	if n.Black {
+		side = "black"
+	}
+	if n.Parent < 0 {
+		lines = append(lines, fmt.Sprintf("root, %s to move", side))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s %02X %02X", n.Move.Piece.Kind().Letter(), uint8(n.Move.From), uint8(n.Move.To)))
+	}
+	if n.Window {
+		lines = append(lines, fmt.Sprintf("%s ply %d depth %d [%d, %d]", n.Kind, n.Ply, n.Depth, n.Alpha, n.Beta))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s ply %d", n.Kind, n.Ply))
+	}
+	lines = append(lines, fmt.Sprintf("score %d", n.Score))
+	for _, e := range n.Evals {
+		states := make([]string, len(e.States))
+		for i, s := range e.States {
+			states[i] = fmt.Sprintf("%d x%d", s.State, s.Janus)
+		}
+		lines = append(lines, fmt.Sprintf("STATE %s", strings.Join(states, ", ")))
+		lines = append(lines, fmt.Sprintf("W %d/%d/%d B %d/%d/%d = %02X",
+			e.WMOB, e.WMAXC, e.WCC, e.BMOB, e.BMAXC, e.BMCC, e.Strategy))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteDOT writes the trace as a Graphviz digraph. Each node shows the
+// move, the kind of search, ply, depth and window, the score, and two lines
+// per evaluation: the STATE values JANUS ran under, with the number of
+// calls at each, then the W and B registers (MOB/MAXC/CC) and the STRATGY
+// value. The principal variation is drawn in bold red and
+// quiescence nodes are dashed.
+func (t *Trace) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	title := fmt.Sprintf("%s: %s\n%d nodes, %d omitted", t.Engine, t.FEN, len(t.Nodes), t.Omitted)
+	fmt.Fprintf(&sb, "digraph search {\n")
+	fmt.Fprintf(&sb, "\tgraph [label=%q, labelloc=t];\n", title)
+	fmt.Fprintf(&sb, "\tnode [shape=box, fontname=\"monospace\"];\n")
+	for i := range t.Nodes {
+		n := &t.Nodes[i]
+		var attrs []string
+		if n.PV {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		if n.Kind == TraceQuiesce {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&sb, "\tn%d [label=%q%s];\n", n.ID, n.label(), prefixJoin(", ", attrs))
+		if n.Parent >= 0 {
+			edge := ""
+			if n.PV {
+				edge = " [color=red, penwidth=2]"
+			}
+			fmt.Fprintf(&sb, "\tn%d -> n%d%s;\n", n.Parent, n.ID, edge)
+		}
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// prefixJoin joins items with sep, starting with sep unless items is empty.
+func prefixJoin(sep string, items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return sep + strings.Join(items, sep)
+}
+
+// traceJSON is the JSON form of a trace.
+type traceJSON struct {
+	Engine   string          `json:"engine"`
+	FEN      string          `json:"fen"`
+	MaxDepth int             `json:"max_depth"`
+	MaxNodes int             `json:"max_nodes"`
+	Omitted  int             `json:"omitted"`
+	Nodes    []traceNodeJSON `json:"nodes"`
+}
+
+// traceNodeJSON is the JSON form of a node. Squares are written in hex as
+// on the LED display, the move as in the Engine line of the H command.
+type traceNodeJSON struct {
+	ID     int         `json:"id"`
+	Parent int         `json:"parent"`
+	Ply    int         `json:"ply"`
+	Kind   TraceKind   `json:"kind"`
+	Move   string      `json:"move,omitempty"`
+	Piece  string      `json:"piece,omitempty"`
+	Black  bool        `json:"black"`
+	Depth  *int        `json:"depth,omitempty"`
+	Alpha  *int        `json:"alpha,omitempty"`
+	Beta   *int        `json:"beta,omitempty"`
+	Score  int         `json:"score"`
+	PV     bool        `json:"pv,omitempty"`
+	Evals  []TraceEval `json:"evals,omitempty"`
+}
+
+// WriteJSON writes the trace as an indented JSON object with the engine,
+// the position, the limits and the nodes in the order they were visited.
+func (t *Trace) WriteJSON(w io.Writer) error {
+	out := traceJSON{Engine: t.Engine, FEN: t.FEN, MaxDepth: t.MaxDepth, MaxNodes: t.MaxNodes, Omitted: t.Omitted, Nodes: []traceNodeJSON{}}
+	for _, n := range t.Nodes {
+		j := traceNodeJSON{ID: n.ID, Parent: n.Parent, Ply: n.Ply, Kind: n.Kind, Black: n.Black, Score: n.Score, PV: n.PV, Evals: n.Evals}
+		if n.Parent >= 0 {
+			j.Move = fmt.Sprintf("%02X%02X", uint8(n.Move.From), uint8(n.Move.To))
+			j.Piece = n.Move.Piece.Kind().Letter()
+		}
+		if n.Window {
+			j.Depth, j.Alpha, j.Beta = &n.Depth, &n.Alpha, &n.Beta
+		}
+		out.Nodes = append(out.Nodes, j)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Save writes the trace to path, as JSON if it ends in .json and as DOT
+// otherwise (.dot, .gv).
+func (t *Trace) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = t.WriteJSON(f)
+	} else {
+		err = t.WriteDOT(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// SetTrace makes the H and A commands record their search in t and save
+// it to path afterwards (see Trace.Save); a nil t stops tracing.
+func (g *GameState) SetTrace(t *Trace, path string) {
+	g.trace = t
+	g.tracePath = path
+}
+
+// ActiveTrace returns the trace engines should record into, or nil.
+func (g *GameState) ActiveTrace() *Trace {
+	return g.trace
+}
+
+// beginTrace clears the trace before engine searches the position.
+func (g *GameState) beginTrace(engine Engine) {
+	if g.trace == nil {
+		return
+	}
+	g.trace.Reset()
+	g.trace.Engine = engine.Name()
+	g.trace.FEN = g.FEN()
+}
+
+// endTrace marks the principal variation of result and saves the trace.
+func (g *GameState) endTrace(result SearchResult) {
+	if g.trace == nil || g.tracePath == "" {
+		return
+	}
+	g.trace.MarkPV(result.PV)
+	if err := g.trace.Save(g.tracePath); err != nil {
+		_, _ = fmt.Fprintf(g.out, "Trace not saved: %v\r\n", err)
+		return
+	}
+	_, _ = fmt.Fprintf(g.out, "Trace: %d nodes (%d omitted) written to %s\r\n", len(g.trace.Nodes), g.trace.Omitted, g.tracePath)
+}
@@ -0,0 +1,163 @@
+// ABOUTME: This file contains tests for search tracing and its DOT and JSON export.
+// ABOUTME: It traces the faithful scan and checks the recorded counters, limits and saved files.
+
+package microchess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace_FaithfulScan(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	trace := NewTrace(0, 0)
+	g.SetTrace(trace, "")
+
+	result := FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil)
+	trace.MarkPV(result.PV)
+
+	require.Len(t, trace.Nodes, 21, "the root and its 20 candidates")
+	root := trace.Nodes[0]
+	assert.Equal(t, -1, root.Parent)
+	assert.Equal(t, result.Score, root.Score)
+	assert.True(t, root.PV)
+	for _, n := range trace.Nodes[1:] {
+		assert.Equal(t, 0, n.Parent)
+		assert.Equal(t, 1, n.Ply)
+		assert.Equal(t, TraceScan, n.Kind)
+		require.Len(t, n.Evals, 1, "STRATGY evaluates each candidate once")
+		states := n.Evals[0].States
+		require.Len(t, states, 2, "CHKCHK at STATE -7 for each move counted at STATE 4")
+		assert.Equal(t, int8(-7), states[0].State)
+		assert.Equal(t, int8(4), states[1].State)
+		assert.Positive(t, states[1].Janus)
+		assert.Greater(t, states[0].Janus, states[1].Janus)
+		assert.Equal(t, n.Move == result.Move, n.PV)
+	}
+
+	// The counters are those Evaluate leaves after the candidate is played
+	m := trace.Nodes[1].Move
+	g.MovePiece, g.MoveSquare = m.Piece, m.To
+	g.MOVE()
+	strategy := g.Evaluate()
+	g.UMOVE()
+	e := trace.Nodes[1].Evals[0]
+	assert.Equal(t, strategy, e.Strategy)
+	assert.Equal(t, [6]uint8{g.WMOB, g.WMAXC, g.WCC, g.BMOB, g.BMAXC, g.BMCC}, [6]uint8{e.WMOB, e.WMAXC, e.WCC, e.BMOB, e.BMAXC, e.BMCC})
+}
+
+func TestTrace_Limits(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	trace := NewTrace(0, 5)
+	g.SetTrace(trace, "")
+	FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil)
+	assert.Len(t, trace.Nodes, 5)
+	assert.Equal(t, 16, trace.Omitted)
+
+	trace = NewTrace(1, 0)
+	trace.Enter(g, TraceSearch, Move{Piece: NoPiece})
+	trace.Enter(g, TraceSearch, Move{From: 0x13, To: 0x23, Piece: PiecePawn4})
+	trace.Enter(g, TraceSearch, Move{From: 0x64, To: 0x54, Piece: PiecePawn5})
+	g.Evaluate() // Attached to nothing: the node was omitted
+	trace.Leave(1)
+	trace.Leave(2)
+	trace.Leave(3)
+	require.Len(t, trace.Nodes, 2)
+	assert.Equal(t, 1, trace.Omitted)
+	assert.Empty(t, trace.Nodes[1].Evals)
+	assert.Equal(t, 2, trace.Nodes[1].Score)
+	assert.Equal(t, 3, trace.Nodes[0].Score)
+}
+
+func TestTrace_NilIsOff(t *testing.T) {
+	var trace *Trace
+	g := NewGame(&bytes.Buffer{})
+	assert.NotPanics(t, func() {
+		trace.Reset()
+		trace.Enter(g, TraceSearch, Move{Piece: NoPiece})
+		trace.Window(1, -1, 1)
+		trace.Leave(0)
+		trace.MarkPV([]Move{{}})
+	})
+	g.SetTrace(NewTrace(0, 0), "")
+	assert.Nil(t, g.Clone().ActiveTrace(), "clones searched by other goroutines do not record")
+}
+
+func TestTrace_Export(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	trace := NewTrace(0, 0)
+	trace.Engine, trace.FEN = "faithful", InitialFEN
+	g.SetTrace(trace, "")
+	result := FaithfulEngine{}.Think(context.Background(), g, TimeControl{}, nil)
+	trace.MarkPV(result.PV)
+
+	var dot bytes.Buffer
+	require.NoError(t, trace.WriteDOT(&dot))
+	assert.True(t, strings.HasPrefix(dot.String(), "digraph search {\n"))
+	assert.Contains(t, dot.String(), "21 nodes, 0 omitted")
+	assert.Contains(t, dot.String(), "root, white to move")
+	assert.Contains(t, dot.String(), `STATE -7 x`)
+	assert.Contains(t, dot.String(), `, 4 x`)
+	assert.Contains(t, dot.String(), `\nW `)
+	assert.Equal(t, 20, strings.Count(dot.String(), "n0 -> "))
+	assert.Equal(t, 1, strings.Count(dot.String(), " [color=red, penwidth=2];"), "exactly one edge is on the PV")
+
+	var js bytes.Buffer
+	require.NoError(t, trace.WriteJSON(&js))
+	var decoded struct {
+		Engine string
+		FEN    string
+		Nodes  []struct {
+			ID     int
+			Parent int
+			Move   string
+			Piece  string
+			PV     bool
+			Depth  *int
+			Evals  []TraceEval
+		}
+	}
+	require.NoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assert.Equal(t, "faithful", decoded.Engine)
+	assert.Equal(t, InitialFEN, decoded.FEN)
+	require.Len(t, decoded.Nodes, 21)
+	assert.Empty(t, decoded.Nodes[0].Move)
+	assert.Nil(t, decoded.Nodes[0].Depth, "the faithful scan has no window")
+	assert.Len(t, decoded.Nodes[1].Move, 4)
+	assert.Equal(t, "P", decoded.Nodes[1].Piece)
+	assert.Equal(t, trace.Nodes[1].Evals, decoded.Nodes[1].Evals)
+}
+
+func TestTrace_SavedByHintCommand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"tree.dot", "tree.json"} {
+		var buf bytes.Buffer
+		g := NewGame(&buf)
+		g.SetupBoard()
+		path := filepath.Join(dir, name)
+		g.SetTrace(NewTrace(2, 100), path)
+
+		g.HandleCharacter('H')
+
+		assert.Contains(t, buf.String(), "Trace: 21 nodes (0 omitted) written to "+path)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		if name == "tree.json" {
+			assert.True(t, json.Valid(data))
+		} else {
+			assert.Contains(t, string(data), "digraph search")
+		}
+	}
+}
@@ -4,8 +4,10 @@
 package microchess
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/matteo/microchess-go/pkg/board"
 )
@@ -80,6 +82,11 @@ type GameState struct {
 	State  int8  // STATE machine value for analysis depth control (assembly: STATE at $B5)
 	InChek uint8 // Check detection flag: 0xF9=safe, 0x00=king capturable (assembly: INCHEK at $B4)
 
+	// Hash is the 64-bit Zobrist key of the position (pieces + side to move).
+	// Not in the original: MOVE, UMOVE and Reverse keep it up to date
+	// incrementally; code that edits Board/BK directly must call ComputeHash.
+	Hash uint64
+
 	// Move history stack (replaces assembly's SP2 dual-stack mechanism)
 	// Used by MOVE/UMOVE to make and unmake trial moves during CHKCHK
 	MoveHistory []MoveRecord
@@ -96,12 +103,12 @@ type GameState struct {
 	// Named counter instances for key positions (assembly: $EB-$EE, $E3-$E6, $EF-$F2)
 	// These are aliases into the arrays above for specific STATE values
 	// Assembly reference: doc/DATA_STRUCTURES.md lines 143-158
-	WMOB, WMAXC, WCC uint8 // White's mobility/captures/count (STATE=11)
-	WMAXP            Piece // White's best capturable piece
+	WMOB, WMAXC, WCC  uint8 // White's mobility/captures/count (STATE=11)
+	WMAXP             Piece // White's best capturable piece
 	BMOB, BMAXC, BMCC uint8 // Black's mobility/captures/count (STATE=3)
 	BMAXP             Piece // Black's best capturable piece
-	PMOB, PMAXC, PCC uint8 // Position mobility/captures/count (STATE=15)
-	PCP              Piece // Position captured piece
+	PMOB, PMAXC, PCC  uint8 // Position mobility/captures/count (STATE=15)
+	PCP               Piece // Position captured piece
 
 	// Capture depth counters (assembly: $DD-$E2)
 	// Track captured piece values at different search depths
@@ -117,6 +124,61 @@ type GameState struct {
 	BestValue  uint8        // Best move evaluation score
 	BestSquare board.Square // Best destination square
 
+	// capturesOnly restricts GNM callbacks to captures (see GNMCaptures)
+	capturesOnly bool
+
+	// Engines available to the 'H' (hint) and 'M' (mode) commands (NEW - not in original)
+	engines     []Engine
+	engineIndex int
+
+	// clock budgets the engine's time when a level is set; thinking is the
+	// engine time spent on the move not yet played (NEW - not in original)
+	clock    *Clock
+	thinking time.Duration
+
+	// observer receives engine progress during 'H' (NEW - not in original)
+	observer Observer
+
+	// evaluator scores positions for 'S' and the faithful engine (NEW - nil means STRATGY)
+	evaluator Evaluator
+
+	// multiPV is how many moves 'A' ranks (NEW - 0 means DefaultMultiPV)
+	multiPV int
+
+	// trace records the searches of 'H' and 'A', saved to tracePath (NEW - nil means off)
+	trace     *Trace
+	tracePath string
+
+	// renderer draws the board for Display (NEW - nil means POUT)
+	renderer Renderer
+
+	// lastMove is the last move played with Enter, in the squares of the
+	// orientation it was played in (NEW - Piece is NoPiece if none)
+	lastMove         Move
+	lastMoveReversed bool
+
+	// record holds the moves played since the board was set up from the
+	// FEN recordStart, for PGN; recordClock and recordNumber are its
+	// halfmove clock and fullmove number (NEW - not in original)
+	record       []RecordedMove
+	recordStart  string
+	recordClock  int
+	recordNumber int
+
+	// line is the ':' command line being typed, nil when none (NEW - not in original)
+	line        *lineEditor
+	lineHistory []string
+
+	// hotSeat makes Enter play legal moves only, for two players taking
+	// turns, and reverse the board after each one (NEW - not in original)
+	hotSeat bool
+
+	// announce describes every move played in words, and boardHidden
+	// replaces the board with whose turn it is, for blindfold play and
+	// screen readers (NEW - not in original)
+	announce    bool
+	boardHidden bool
+
 	// I/O for display and input
 	out io.Writer
 }
@@ -188,11 +250,11 @@ var MOVEX = [17]int8{
 //
 // Assembly reference: line 878-879 (POINTS table)
 var POINTS = [16]uint8{
-	11,              // 0: King (special - should never be captured)
-	10,              // 1: Queen
-	6, 6,            // 2-3: Rooks
-	4, 4,            // 4-5: Bishops
-	4, 4,            // 6-7: Knights
+	11,   // 0: King (special - should never be captured)
+	10,   // 1: Queen
+	6, 6, // 2-3: Rooks
+	4, 4, // 4-5: Bishops
+	4, 4, // 6-7: Knights
 	2, 2, 2, 2, 2, 2, 2, 2, // 8-15: Pawns
 }
 
@@ -220,7 +282,8 @@ var InitialSetup = [32]board.Square{
 // The out Writer is used for all display output (board, messages, etc.)
 func NewGame(out io.Writer) *GameState {
 	g := &GameState{
-		out: out,
+		out:      out,
+		lastMove: Move{Piece: NoPiece},
 	}
 	// Initialize boards with off-board sentinel values (0xFF)
 	// This simulates the uninitialized state of the original
@@ -238,9 +301,17 @@ func NewGame(out io.Writer) *GameState {
 	g.DIS2 = 0x00
 	g.DIS3 = 0x00
 
+	g.Hash = g.ComputeHash()
+
 	return g
 }
 
+// SetOutput redirects everything the commands print (boards included,
+// through the renderer) to out.
+func (g *GameState) SetOutput(out io.Writer) {
+	g.out = out
+}
+
 // SetupBoard initializes the board to the starting position.
 // This is equivalent to the SETUP routine (assembly line 665).
 func (g *GameState) SetupBoard() {
@@ -252,6 +323,10 @@ func (g *GameState) SetupBoard() {
 	for i := 0; i < 16; i++ {
 		g.BK[i] = InitialSetup[i+16]
 	}
+	g.Hash = g.ComputeHash()
+	g.lastMove = Move{Piece: NoPiece}
+	g.recordClock, g.recordNumber = 0, 1
+	g.startRecord()
 	// NOTE: The Reversed flag is NOT reset here. The original assembly SETUP routine
 	// (line 116-126) does not modify the REV flag. Only the REVERSE routine toggles it.
 }
@@ -332,6 +407,12 @@ func (g *GameState) Reverse() {
 
 	// Toggle the reversed flag
 	g.Reversed = !g.Reversed
+
+	// The Zobrist key is taken in the absolute frame, so only the side to move changes
+	g.Hash ^= zobristBlackToMove
+	if DebugHash {
+		g.assertHash("Reverse")
+	}
 }
 
 // HandleCharacter processes a single character input and returns true if the program should continue.
@@ -340,120 +421,30 @@ func (g *GameState) Reverse() {
 //
 // Reference: Assembly lines 110-152 (main input loop), 812-816 (KIN routine)
 func (g *GameState) HandleCharacter(char byte) bool {
-	// Mask to handle both upper and lowercase (original: AND #$4F masks bits)
-	// Convert lowercase to uppercase for simplicity
-	if char >= 'a' && char <= 'z' {
-		char = char - 'a' + 'A'
-	}
-
-	switch char {
-	case 'Q':
-		// Quit program (assembly line 148)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline
-		return false
-
-	case 'C':
-		// Setup board (SETUP routine, line 665, called at line 116)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed 'C'
-		g.SetupBoard()
-		// Set LED display to "CC CC CC" to indicate setup
-		g.DIS1 = 0xCC
-		g.DIS2 = 0xCC
-		g.DIS3 = 0xCC
-		g.Display()
-		return true
-
-	case 'E':
-		// Reverse board perspective (REVERSE routine, line 382, called at line 126)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed 'E'
-		g.Reverse()
-		// Set LED display to "EE EE EE" to indicate reversal
-		g.DIS1 = 0xEE
-		g.DIS2 = 0xEE
-		g.DIS3 = 0xEE
-		g.Display()
-		return true
-
-	case 'P':
-		// Print board (POUT routine, line 702, called at line 140)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed 'P'
-		g.Display()
-		return true
-
-	case 'L':
-		// List legal moves (NEW command - not in original)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed 'L'
-		g.ListLegalMoves()
-		return true
-
-	case 'S':
-		// Show position evaluation (NEW command - not in original)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed 'S'
-		g.ShowEvaluation()
-		return true
-
-	case '\r', '\n':
-		// Enter/Return key
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed char
-
-		// If we have 4 or more digits entered, execute the move using the last 4 digits
-		// The last 4 digits are stored in DIS2 (from square) and DIS3 (to square)
-		// This allows users to enter extra digits (5, 6, 7, 8, ...) and still execute
-		if g.DigitCount >= 4 {
-			g.ExecuteMove()
-		}
-		// Always display board after carriage return (even if no move executed)
-		// This matches 6502 behavior
-		g.Display()
-		return true
-
-	case '0', '1', '2', '3', '4', '5', '6', '7':
-		// Digit input for move entry (INPUT routine, assembly line 262)
-		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed char
-
-		digit := uint8(char - '0')
-
-		// On first digit, reset DIS1 to 0xFF (no piece selected yet)
-		// This matches observed 6502 behavior where DIS1 shows FF during digit entry
-		if g.DigitCount == 0 {
-			g.DIS1 = 0xFF
-		}
-
-		// If we've completed 4 digits previously (DigitCount >= 4),
-		// reset DIS1 to FF but keep the digit rotation going (don't reset DigitCount)
-		// This allows entering 5+ digits - they keep rotating through DIS2/DIS3
-		if g.DigitCount >= 4 {
-			g.DIS1 = 0xFF
-			// Don't reset DigitCount - just let it keep incrementing
-		}
-
-		// Rotate digit into move display (DISMV routine, line 625)
-		g.RotateDigitIntoMove(digit)
-		g.DigitCount++
-
-		// After 4 or more digits, always find piece at from square (DIS2)
-		// The last 4 digits in the rolling buffer define the current move
-		// Assembly lines 266-272 (SEARCH loop in INPUT)
-		if g.DigitCount >= 4 {
-			fromSquare := board.Square(g.DIS2)
-			piece := g.FindPieceAtSquare(fromSquare)
-
-			// Store piece index in DIS1 and SelectedPiece
-			// Assembly line 271-272: STX DIS1 / STX PIECE
-			g.DIS1 = uint8(piece)
-			g.SelectedPiece = piece
-		}
+	return g.HandleCharacterContext(context.Background(), char)
+}
 
-		// Display board to show updated LED display
-		g.Display()
-		return true
+// HandleCharacterContext is HandleCharacter with a context that cancels
+// long-running commands (the engine search started by 'H').
+func (g *GameState) HandleCharacterContext(ctx context.Context, char byte) bool {
+	// A ':' command line gets every character, in either case (NEW)
+	if g.line != nil {
+		return g.editLine(ctx, char)
+	}
 
-	default:
+	// Commands are looked up in the registry (see commands.go); letters
+	// match in either case (original: AND #$4F masks bits)
+	c, ok := CommandByKey(char)
+	if !ok {
 		// Unknown command - print error
 		_, _ = fmt.Fprintf(g.out, "\r\nUnknown command: %c\r\n", char)
-		_, _ = fmt.Fprintln(g.out, "Available commands: C (setup), E (reverse), P (print), Q (quit), 0-7 (move)")
+		_, _ = fmt.Fprintf(g.out, "%s\r\n", availableCommands())
 		return true
 	}
+	if !c.Quiet {
+		_, _ = fmt.Fprintln(g.out, "\r") // Clean newline after echoed char
+	}
+	return g.run(ctx, c, upper(char), nil)
 }
 
 // ExecuteMove executes the move stored in SelectedPiece and DIS3 (target square).
@@ -483,6 +474,7 @@ func (g *GameState) ExecuteMove() {
 	}
 
 	targetSquare := board.Square(g.DIS3)
+	g.recordMove(g.SelectedPiece, board.Square(g.DIS2), targetSquare)
 
 	// Check if there's a piece at the target square (capture)
 	capturedPiece := g.FindPieceAtSquare(targetSquare)
@@ -508,6 +500,11 @@ func (g *GameState) ExecuteMove() {
 		g.BK[g.SelectedPiece-16] = targetSquare
 	}
 
+	// The manual move bypasses MOVE, so refresh the Zobrist key from scratch
+	g.Hash = g.ComputeHash()
+	g.lastMove = Move{From: board.Square(g.DIS2), To: targetSquare, Piece: g.SelectedPiece & 0x0F}
+	g.lastMoveReversed = g.Reversed
+
 	// Reset DIS1 to 0xFF (no piece selected)
 	// DIS2 and DIS3 keep showing the last move
 	g.DIS1 = 0xFF
@@ -583,45 +580,19 @@ func (g *GameState) RotateDigitIntoMove(digit uint8) {
 	g.DIS3 = g.DIS3 | digit
 }
 
-// Display prints the chess board in the style of the original POUT routine (line 702).
-// The display shows coordinates and piece positions using the 0x88 encoding.
+// Display prints the chess board with the active renderer; by default in
+// the style of the original POUT routine (line 702, see POUTRenderer).
+// In hot-seat mode the side to move is shown below it; when the board is
+// hidden (SetBoardHidden), only the side to move is shown.
 func (g *GameState) Display() {
-	_, _ = fmt.Fprintf(g.out, "MicroChess (c) 1996-2005 Peter Jennings, www.benlo.com\r\n")
-	_, _ = fmt.Fprintf(g.out, " 00 01 02 03 04 05 06 07\r\n")
-	_, _ = fmt.Fprintf(g.out, "-------------------------\r\n")
-
-	// Display ranks 0 to 7 (original displays 00-70)
-	// The original scans Y from 0x00 to 0x77 in 0x88 format
-	for rank := 0; rank <= 7; rank++ {
-		_, _ = fmt.Fprint(g.out, "|")
-
-		for file := 0; file < 8; file++ {
-			sq := board.Square((rank << 4) | file)
-			piece, found, isWhite := g.FindPieceAt(sq)
-
-			if found {
-				_, _ = fmt.Fprint(g.out, GetPieceChar(piece, isWhite))
-			} else {
-				// Checkerboard pattern for empty squares
-				// Original: check if (file + rank) is odd for asterisk
-				if (rank+file)%2 == 1 {
-					_, _ = fmt.Fprint(g.out, "**")
-				} else {
-					_, _ = fmt.Fprint(g.out, "  ")
-				}
-			}
-
-			_, _ = fmt.Fprint(g.out, "|")
-		}
-
-		// Print rank number in hex on the right (00, 10, 20, ...)
-		_, _ = fmt.Fprintf(g.out, "%X0\r\n", rank)
+	if !g.boardHidden {
+		r := g.ActiveRenderer()
+		_ = r.Render(g.out, g.snapshot(showsCheck(r)))
+	}
+	if !g.hotSeat && !g.boardHidden {
+		return
+	}
+	if status := g.TurnStatus(); status != "" {
+		_, _ = fmt.Fprintf(g.out, "%s\r\n", status)
 	}
-
-	_, _ = fmt.Fprintf(g.out, "-------------------------\r\n")
-	_, _ = fmt.Fprintf(g.out, " 00 01 02 03 04 05 06 07\r\n")
-
-	// Print LED display (DIS1 DIS2 DIS3)
-	_, _ = fmt.Fprintf(g.out, "%02X %02X %02X\r\n", g.DIS1, g.DIS2, g.DIS3)
-	_, _ = fmt.Fprintf(g.out, "\r\n")
 }
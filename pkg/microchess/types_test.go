@@ -272,3 +272,23 @@ func TestHandleCommandE(t *testing.T) {
 		assert.False(t, game.Reversed, "Reversed flag should be false after double E")
 	})
 }
+
+func TestPieceKind(t *testing.T) {
+	tests := []struct {
+		piece Piece
+		kind  Kind
+	}{
+		{PieceKing, KindKing},
+		{PieceQueen, KindQueen},
+		{PieceRook2, KindRook},
+		{PieceBishop1, KindBishop},
+		{PieceKnight2, KindKnight},
+		{PiecePawn8, KindPawn},
+		{PieceQueen + 16, KindQueen}, // BK index as returned by FindPieceAtSquare
+		{NoPiece, NoKind},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.kind, tt.piece.Kind(), "piece %d", tt.piece)
+	}
+}
@@ -0,0 +1,154 @@
+// ABOUTME: This file implements the rich terminal renderer: Unicode chess glyphs on ANSI coloured squares.
+// ABOUTME: The last move and a king in check are highlighted; without colour it falls back to plain markers.
+
+package microchess
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// ANSI SGR sequences used by UnicodeRenderer (256-colour palette).
+const (
+	ansiReset     = "\x1b[0m"
+	ansiLight     = "\x1b[48;5;223m" // Light squares: wheat
+	ansiDark      = "\x1b[48;5;137m" // Dark squares: brown
+	ansiLastMove  = "\x1b[48;5;186m" // From and to squares of the last move: yellow
+	ansiCheck     = "\x1b[48;5;160m" // King in check: red
+	ansiWhiteMan  = "\x1b[1;38;5;231m"
+	ansiBlackMan  = "\x1b[38;5;16m"
+	ansiLabelText = "\x1b[38;5;244m"
+)
+
+// unicodeGlyphs are the chess symbols for each kind, white then black.
+var unicodeGlyphs = map[Kind][2]string{
+	KindKing:   {"♔", "♚"},
+	KindQueen:  {"♕", "♛"},
+	KindRook:   {"♖", "♜"},
+	KindBishop: {"♗", "♝"},
+	KindKnight: {"♘", "♞"},
+	KindPawn:   {"♙", "♟"},
+}
+
+// UnicodeRenderer prints the board with Unicode chess glyphs, white at the
+// bottom and a-h/1-8 labels like ModernRenderer.
+//
+// With Color, squares get ANSI background colours: light and dark squares,
+// yellow for the from and to squares of the last move, and red under a king
+// that can be captured. Both sides use the solid glyphs, told apart by
+// their foreground colour, which reads better than outline glyphs on a
+// coloured background.
+//
+// Without Color (output is not a colour terminal) no escape sequence is
+// written: white pieces use the outline glyphs and black the solid ones,
+// dark empty squares show a dot, the last move is bracketed [ ] and a king
+// in check is marked ! !.
+type UnicodeRenderer struct {
+	Color bool
+}
+
+// Name implements Renderer.
+func (UnicodeRenderer) Name() string {
+	return "unicode"
+}
+
+// ShowsCheck implements CheckRenderer.
+func (UnicodeRenderer) ShowsCheck() bool {
+	return true
+}
+
+// Render implements Renderer.
+func (u UnicodeRenderer) Render(w io.Writer, s Snapshot) error {
+	var sb strings.Builder
+	files := "    a  b  c  d  e  f  g  h\r\n"
+	sb.WriteString(u.label(files))
+	for rank := 7; rank >= 0; rank-- {
+		sb.WriteString(u.label(fmt.Sprintf(" %d ", rank+1)))
+		for file := 0; file < 8; file++ {
+			sq := s.Absolute(board.Square(rank<<4 | file)) // Stored square (Absolute is its own inverse)
+			sb.WriteString(u.cell(&s, sq, (rank+file)%2 == 0))
+		}
+		sb.WriteString(u.label(fmt.Sprintf(" %d", rank+1)))
+		sb.WriteString("\r\n")
+	}
+	sb.WriteString(u.label(files))
+
+	fmt.Fprintf(&sb, "LED %02X %02X %02X", s.LED[0], s.LED[1], s.LED[2])
+	if s.LastMove.Piece != NoPiece {
+		fmt.Fprintf(&sb, "   last move %s-%s", s.Absolute(s.LastMove.From), s.Absolute(s.LastMove.To))
+	}
+	if len(s.Checks) > 0 {
+		sb.WriteString("   check")
+	}
+	sb.WriteString("\r\n\r\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// cell returns the three columns of one square.
+func (u UnicodeRenderer) cell(s *Snapshot, sq board.Square, dark bool) string {
+	sp := s.At(sq)
+	lastMove := s.LastMove.Piece != NoPiece && (sq == s.LastMove.From || sq == s.LastMove.To)
+	check := sp.Piece == PieceKing && s.InCheck(sq)
+
+	if !u.Color {
+		glyph := " "
+		switch {
+		case sp.Piece != NoPiece:
+			glyph = PieceGlyph(sp.Piece.Kind(), sp.White)
+		case dark:
+			glyph = "·"
+		}
+		switch {
+		case check:
+			return "!" + glyph + "!"
+		case lastMove:
+			return "[" + glyph + "]"
+		}
+		return " " + glyph + " "
+	}
+
+	bg := ansiLight
+	switch {
+	case check:
+		bg = ansiCheck
+	case lastMove:
+		bg = ansiLastMove
+	case dark:
+		bg = ansiDark
+	}
+	if sp.Piece == NoPiece {
+		return bg + "   " + ansiReset
+	}
+	fg := ansiBlackMan
+	if sp.White {
+		fg = ansiWhiteMan
+	}
+	return bg + fg + " " + PieceGlyph(sp.Piece.Kind(), false) + " " + ansiReset
+}
+
+// label dims coordinate text when colour is on.
+func (u UnicodeRenderer) label(text string) string {
+	if !u.Color {
+		return text
+	}
+	body := strings.TrimSuffix(text, "\r\n")
+	return ansiLabelText + body + ansiReset + text[len(body):]
+}
+
+// colorIndex selects the white (0) or black (1) glyph.
+func colorIndex(white bool) int {
+	if white {
+		return 0
+	}
+	return 1
+}
+
+// PieceGlyph returns the Unicode chess symbol of a kind: the outline glyph
+// for white, the solid one for black.
+func PieceGlyph(k Kind, white bool) string {
+	return unicodeGlyphs[k][colorIndex(white)]
+}
@@ -0,0 +1,93 @@
+// ABOUTME: This file implements 64-bit Zobrist position keys for GameState.
+// ABOUTME: Keys are updated incrementally by MOVE, UMOVE and Reverse and can be recomputed from scratch.
+
+package microchess
+
+import (
+	"fmt"
+
+	"github.com/matteo/microchess-go/pkg/board"
+)
+
+// DebugHash enables consistency checks of the incremental Zobrist key.
+// When set, MOVE, UMOVE and Reverse recompute the key from scratch after
+// updating it and panic if the two disagree. It is meant for tests and
+// debugging sessions; leave it off in normal play, as it is slow.
+var DebugHash = false
+
+// zobristSeed fixes the key tables so that hashes are identical across runs
+// and machines, which allows storing them on disk (opening books, position databases).
+// Changing it invalidates every stored key.
+const zobristSeed uint64 = 0x4D6963726F436873 // "MicroChs"
+
+var (
+	// zobristPieces holds one key per colour (0 = white, 1 = black), Kind and
+	// absolute square (rank*8 + file, as seen with REV = 0).
+	zobristPieces [2][6][64]uint64
+
+	// zobristBlackToMove is XORed in when black is the side to move (REV set).
+	zobristBlackToMove uint64
+)
+
+func init() {
+	state := zobristSeed
+	for color := range zobristPieces {
+		for kind := range zobristPieces[color] {
+			for sq := range zobristPieces[color][kind] {
+				zobristPieces[color][kind][sq] = splitMix64(&state)
+			}
+		}
+	}
+	zobristBlackToMove = splitMix64(&state)
+}
+
+// splitMix64 is a small, well-distributed PRNG. Using our own generator rather
+// than math/rand guarantees the key tables never change between Go releases.
+func splitMix64(state *uint64) uint64 {
+	*state += 0x9E3779B97F4A7C15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// pieceKey returns the Zobrist key of piece p of the given side standing on sq,
+// or 0 when the piece is not on the board.
+//
+// The Board and BK arrays are relative to the side to move, so the key is
+// taken in the absolute frame: with REV set, Board holds black's pieces and
+// every square has been transformed to $77 - square by REVERSE. This makes
+// Reverse a pure side-to-move toggle for the hash.
+func (g *GameState) pieceKey(side Side, p Piece, sq board.Square) uint64 {
+	if !sq.IsValid() {
+		return 0
+	}
+	color := int(side)
+	if g.Reversed {
+		color = 1 - color
+		sq = 0x77 - sq
+	}
+	return zobristPieces[color][p.Kind()][sq.Rank()*8+sq.File()]
+}
+
+// ComputeHash computes the Zobrist key of the current position from scratch.
+// It is used to initialise Hash and, with DebugHash, to verify incremental updates.
+func (g *GameState) ComputeHash() uint64 {
+	var h uint64
+	for p := Piece(0); p < 16; p++ {
+		h ^= g.pieceKey(SideBoard, p, g.Board[p])
+		h ^= g.pieceKey(SideBK, p, g.BK[p])
+	}
+	if g.Reversed {
+		h ^= zobristBlackToMove
+	}
+	return h
+}
+
+// assertHash panics if the incremental key has drifted from the recomputed one.
+// Only called when DebugHash is set.
+func (g *GameState) assertHash(routine string) {
+	if want := g.ComputeHash(); g.Hash != want {
+		panic(fmt.Sprintf("zobrist: %s left hash %016X, recomputed %016X", routine, g.Hash, want))
+	}
+}
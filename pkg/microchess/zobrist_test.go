@@ -0,0 +1,120 @@
+// ABOUTME: This file contains tests for the Zobrist position keys.
+// ABOUTME: It checks stability across runs and that incremental updates match a full recompute.
+
+package microchess
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withDebugHash enables the incremental-key assertions for the duration of a test.
+func withDebugHash(t *testing.T) {
+	DebugHash = true
+	t.Cleanup(func() { DebugHash = false })
+}
+
+// playMove makes a move with MOVE, the way CHKCHK and the search do.
+func playMove(g *GameState, piece Piece, to board.Square) {
+	g.MovePiece = piece
+	g.MoveSquare = to
+	g.MOVE()
+}
+
+func TestZobrist_StableAcrossRuns(t *testing.T) {
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	// Pinned value: if this changes, every key stored on disk becomes invalid.
+	assert.Equal(t, uint64(0x3772FD6A6B4D1C15), g.Hash)
+	assert.Equal(t, g.ComputeHash(), g.Hash)
+}
+
+func TestZobrist_MoveUmoveRestoresKey(t *testing.T) {
+	withDebugHash(t)
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Board[PiecePawn7] = 0x54
+	g.Hash = g.ComputeHash()
+	start := g.Hash
+
+	playMove(g, PiecePawn7, 0x63) // capture the d7 pawn
+	assert.NotEqual(t, start, g.Hash)
+
+	g.UMOVE()
+	assert.Equal(t, start, g.Hash)
+}
+
+func TestZobrist_ReverseTogglesSideToMove(t *testing.T) {
+	withDebugHash(t)
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	start := g.Hash
+
+	g.Reverse()
+	assert.Equal(t, start^zobristBlackToMove, g.Hash, "pieces are hashed in the absolute frame")
+
+	g.Reverse()
+	assert.Equal(t, start, g.Hash)
+}
+
+func TestZobrist_TranspositionsShareKey(t *testing.T) {
+	a := NewGame(&bytes.Buffer{})
+	a.SetupBoard()
+	playMove(a, PieceKnight1, 0x20) // Nb1-a3
+	a.Reverse()
+	playMove(a, PiecePawn7, 0x23) // ...e7-e6, seen from black ($64 -> $13 -> $23)
+	a.Reverse()
+	playMove(a, PieceKnight2, 0x25) // Ng1-f3
+
+	b := NewGame(&bytes.Buffer{})
+	b.SetupBoard()
+	playMove(b, PieceKnight2, 0x25)
+	b.Reverse()
+	playMove(b, PiecePawn7, 0x23)
+	b.Reverse()
+	playMove(b, PieceKnight1, 0x20)
+
+	require.Equal(t, a.ComputeHash(), a.Hash)
+	assert.Equal(t, a.Hash, b.Hash)
+}
+
+// TestZobrist_SurvivesCHKCHK runs full legal move generation, which makes and
+// unmakes trial moves and reverses the board hundreds of times, with assertions on.
+func TestZobrist_SurvivesCHKCHK(t *testing.T) {
+	withDebugHash(t)
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Board[PiecePawn7] = 0x34
+	g.BK[PiecePawn8] = 0x43
+	g.Hash = g.ComputeHash()
+	start := g.Hash
+
+	moves := g.LegalMoves()
+
+	assert.NotEmpty(t, moves)
+	assert.Equal(t, start, g.Hash)
+}
+
+func TestZobrist_RecordedMovesKeepTheKey(t *testing.T) {
+	withDebugHash(t)
+	g := NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	// Enter records the move, which names it in SAN on a clone
+	typeKeys(g, "1333\r6444\r")
+	assert.Equal(t, g.ComputeHash(), g.Hash)
+
+	require.NoError(t, g.SetFEN(StandardFEN))
+	for _, san := range []string{"e4", "d5", "exd5", "Qxd5", "Nc3"} {
+		m, err := g.ParseMove(san)
+		require.NoError(t, err, san)
+		g.PlayMove(m)
+		assert.Equal(t, g.ComputeHash(), g.Hash, san)
+	}
+	assert.Len(t, g.Record(), 5)
+}
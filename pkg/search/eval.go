@@ -0,0 +1,50 @@
+// ABOUTME: This file defines the evaluators the alpha-beta search is plugged with by default.
+// ABOUTME: Negamax needs antisymmetric scores; Symmetric adapts evaluators such as STRATGY that are not.
+
+package search
+
+import (
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// PawnValue is the score unit: one pawn (POINTS value 2) is worth 100.
+const PawnValue = microchess.PawnValue
+
+// Material counts POINTS for the pieces still on the board, scaled so that a
+// pawn is worth PawnValue. It is the search's default evaluator.
+var Material microchess.Evaluator = microchess.MaterialPSTEvaluator{}
+
+// Strategy scores positions with the 1976 STRATGY formula, made antisymmetric.
+var Strategy microchess.Evaluator = Symmetric{microchess.StrategyEvaluator{}}
+
+// Symmetric turns any evaluator into one suitable for negamax, which
+// requires Score(P) == -Score(P after Reverse). STRATGY, for instance, is
+// not symmetric (its constants put a neutral position around $D0), so the
+// score is the difference between the side to move's score and the
+// opponent's, computed with Reverse.
+type Symmetric struct {
+	Evaluator microchess.Evaluator
+}
+
+// Name implements microchess.Evaluator.
+func (s Symmetric) Name() string {
+	return s.Evaluator.Name()
+}
+
+// Score implements microchess.Evaluator. The move context goes to
+// whichever side made the move, so STRATGY's position bonus counts for it.
+func (s Symmetric) Score(g *microchess.GameState, mc microchess.MoveContext) int {
+	ours := s.Evaluator.Score(g, mc)
+	g.Reverse()
+	mc.Mover ^= 1 // Reverse swapped the sides
+	theirs := s.Evaluator.Score(g, mc)
+	g.Reverse()
+	return ours - theirs
+}
+
+// PieceValue implements microchess.PieceValuer: a capture changes both
+// sides' scores, so the difference moves by twice the piece's value.
+func (s Symmetric) PieceValue(p microchess.Piece) (int, bool) {
+	v, ok := microchess.PieceValueOf(s.Evaluator, p)
+	return 2 * v, ok
+}
@@ -0,0 +1,91 @@
+// ABOUTME: This file defines the tunable engine options shared by the CLI and the UCI front end.
+// ABOUTME: Options are declared once with their UCI name, type and range, and set by name.
+
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Options holds the user-tunable settings of the search.
+type Options struct {
+	HashMB  int // Transposition table size in megabytes (UCI "Hash")
+	Depth   int // Maximum iterative deepening depth in plies (UCI "Depth")
+	Threads int // Search threads, Lazy SMP when above 1 (UCI "Threads")
+	MultiPV int // Root moves reported with their PV (UCI "MultiPV")
+}
+
+const (
+	// DefaultDepth is the search depth used when no option is given.
+	DefaultDepth = 4
+	// MaxDepth bounds the Depth option.
+	MaxDepth = 32
+	// MaxMultiPV bounds the MultiPV option.
+	MaxMultiPV = 64
+)
+
+// DefaultOptions returns the settings used when nothing is configured.
+func DefaultOptions() Options {
+	return Options{
+		HashMB:  DefaultHashMB,
+		Depth:   DefaultDepth,
+		Threads: 1,
+		MultiPV: 1,
+	}
+}
+
+// optionSpec describes one option as advertised by the UCI "option" command.
+type optionSpec struct {
+	name     string
+	min, max int
+	def      int
+	field    func(o *Options) *int
+}
+
+var optionSpecs = []optionSpec{
+	{name: "Hash", min: MinHashMB, max: MaxHashMB, def: DefaultHashMB, field: func(o *Options) *int { return &o.HashMB }},
+	{name: "Depth", min: 1, max: MaxDepth, def: DefaultDepth, field: func(o *Options) *int { return &o.Depth }},
+	{name: "Threads", min: 1, max: MaxThreads, def: 1, field: func(o *Options) *int { return &o.Threads }},
+	{name: "MultiPV", min: 1, max: MaxMultiPV, def: 1, field: func(o *Options) *int { return &o.MultiPV }},
+}
+
+// Set changes an option by its UCI name (case-insensitive, as UCI requires).
+func (o *Options) Set(name, value string) error {
+	for _, spec := range optionSpecs {
+		if !strings.EqualFold(spec.name, name) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("option %s: %q is not a number", spec.name, value)
+		}
+		if n < spec.min || n > spec.max {
+			return fmt.Errorf("option %s: %d out of range [%d, %d]", spec.name, n, spec.min, spec.max)
+		}
+		*spec.field(o) = n
+		return nil
+	}
+	return fmt.Errorf("unknown option %q", name)
+}
+
+// Validate reports the first option whose value is out of range.
+func (o *Options) Validate() error {
+	for _, spec := range optionSpecs {
+		if err := o.Set(spec.name, strconv.Itoa(*spec.field(o))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UCIOptions returns the "option ..." lines a UCI engine prints in reply to "uci".
+func UCIOptions() []string {
+	lines := make([]string, 0, len(optionSpecs))
+	for _, spec := range optionSpecs {
+		lines = append(lines, fmt.Sprintf("option name %s type spin default %d min %d max %d",
+			spec.name, spec.def, spec.min, spec.max))
+	}
+	return lines
+}
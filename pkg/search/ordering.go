@@ -0,0 +1,95 @@
+// ABOUTME: This file implements move ordering for the alpha-beta search.
+// ABOUTME: Order is: hash move, captures by MVV-LVA on POINTS, killer moves, losing captures (SEE), then history heuristic.
+
+package search
+
+import (
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+const (
+	hashMoveScore = 1 << 30
+	captureScore  = 1 << 20
+	killerScore   = 1 << 19
+	losingScore   = 1 << 18
+)
+
+// orderer keeps the heuristics that survive between nodes of one search.
+type orderer struct {
+	killers [maxPly][2]microchess.Move
+	history [16][128]int
+	scores  [maxPly][]int // Scores of the moves being sorted at each ply, reused between nodes
+}
+
+// clear forgets killers and history before a new search.
+func (o *orderer) clear() {
+	o.killers = [maxPly][2]microchess.Move{}
+	o.history = [16][128]int{}
+}
+
+// isCapture reports whether m lands on an opponent piece.
+func isCapture(g *microchess.GameState, m microchess.Move) bool {
+	_, side, found := g.PieceAt(m.To)
+	return found && side == microchess.SideBK
+}
+
+// score ranks one move; higher is searched first.
+func (o *orderer) score(g *microchess.GameState, m, ttMove microchess.Move, ply int) int {
+	if m == ttMove {
+		return hashMoveScore
+	}
+	if victim, side, found := g.PieceAt(m.To); found && side == microchess.SideBK {
+		// Most Valuable Victim, Least Valuable Attacker
+		mvvLva := 16*int(microchess.POINTS[victim]) - int(microchess.POINTS[m.Piece])
+		if losingCapture(g, m, victim) {
+			return losingScore + mvvLva // After the killers: it probably just gives material away
+		}
+		return captureScore + mvvLva
+	}
+	switch m {
+	case o.killers[ply][0]:
+		return killerScore + 1
+	case o.killers[ply][1]:
+		return killerScore
+	}
+	return o.history[m.Piece][m.To]
+}
+
+// losingCapture reports whether the capture of victim by m loses material
+// once the exchange is played out. Taking a piece worth at least the
+// attacker can never lose, so SEE only runs for the other captures.
+func losingCapture(g *microchess.GameState, m microchess.Move, victim microchess.Piece) bool {
+	if microchess.POINTS[victim] >= microchess.POINTS[m.Piece] {
+		return false
+	}
+	return g.SEE(m) < 0
+}
+
+// sort orders moves in place. The sort is stable, so moves with equal
+// scores stay in GNM order and the search remains deterministic. An
+// insertion sort is enough for the few dozen moves of a node, and keeps
+// the scores in a slice that is reused instead of allocated.
+func (o *orderer) sort(g *microchess.GameState, moves []microchess.Move, ttMove microchess.Move, ply int) {
+	scores := o.scores[ply][:0]
+	for _, m := range moves {
+		scores = append(scores, o.score(g, m, ttMove, ply))
+	}
+	o.scores[ply] = scores
+	for i := 1; i < len(moves); i++ {
+		m, s := moves[i], scores[i]
+		j := i
+		for ; j > 0 && scores[j-1] < s; j-- {
+			moves[j], scores[j] = moves[j-1], scores[j-1]
+		}
+		moves[j], scores[j] = m, s
+	}
+}
+
+// recordCutoff remembers a quiet move which caused a beta cutoff.
+func (o *orderer) recordCutoff(m microchess.Move, depth, ply int) {
+	if o.killers[ply][0] != m {
+		o.killers[ply][1] = o.killers[ply][0]
+		o.killers[ply][0] = m
+	}
+	o.history[m.Piece][m.To] += depth * depth
+}
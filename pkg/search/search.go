@@ -0,0 +1,610 @@
+// ABOUTME: This file implements the "modern" search: negamax alpha-beta with iterative deepening.
+// ABOUTME: It walks the tree with GNM, MOVE/UMOVE and Reverse, extends leaves with a capture-only quiescence search.
+
+package search
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+const (
+	// Infinity bounds every score.
+	Infinity = 32000
+	// Mate is the score of capturing the king; mates found at ply n score Mate - n.
+	Mate = 30000
+	// maxPly limits the search stack.
+	maxPly = 64
+	// checkInterval is how many nodes are searched between clock checks (a power of two).
+	checkInterval = 1024
+)
+
+// Compile-time checks that the engine supports multi-PV analysis, ':depth',
+// ':hash' and ':threads'.
+var (
+	_ microchess.Analyzer     = (*Engine)(nil)
+	_ microchess.DepthSetter  = (*Engine)(nil)
+	_ microchess.HashSetter   = (*Engine)(nil)
+	_ microchess.ThreadSetter = (*Engine)(nil)
+)
+
+// noMove is the zero move used where no move is known.
+var noMove = microchess.Move{Piece: microchess.NoPiece}
+
+// Engine is the alpha-beta engine ("modern" mode).
+//
+// Unlike the faithful engine, which scores each candidate move with STRATGY
+// and never looks at the reply, it searches every legal move to MaxDepth
+// plies with negamax alpha-beta, deepening one ply at a time so that each
+// iteration can start from the previous principal variation.
+//
+// Under a time control, or an infinite one, the engine deepens up to the
+// package MaxDepth instead, until the move's Budget is spent or it is
+// cancelled; TimeControl.Depth caps the iterations in every case. With Threads > 1, helper
+// threads search the same root concurrently (Lazy SMP, see smp.go).
+// With MultiPV > 1, Think ranks that many root moves (see Analyze).
+type Engine struct {
+	Eval     microchess.Evaluator // Leaf evaluation, antisymmetric (defaults to Material)
+	MaxDepth int                  // Deepest iteration without a time control
+	Threads  int                  // Search threads; 1 keeps the search single-threaded and deterministic
+	MultiPV  int                  // Root moves Think reports in SearchResult.Lines when above 1
+
+	table   *Table
+	stop    atomic.Bool // Raised by the main thread to end the search on every thread
+	workers []*worker
+	delta   deltaPruning // Set from Eval when a search starts
+}
+
+// deltaPruning sizes delta pruning in quiescence in the units of the
+// evaluator: a capture is skipped when even winning the victim plus a
+// margin of two pawns cannot raise the stand-pat score to alpha. It is off
+// for evaluators without a material scale (not PieceValuers), such as
+// STRATGY, whose scores no piece value can be compared with.
+type deltaPruning struct {
+	on     bool
+	gain   [16]int // Value of each captured piece
+	margin int
+}
+
+// newDeltaPruning derives delta pruning from the evaluator ev.
+func newDeltaPruning(ev microchess.Evaluator) deltaPruning {
+	var d deltaPruning
+	for p := microchess.Piece(0); p < 16; p++ {
+		v, ok := microchess.PieceValueOf(ev, p)
+		if !ok {
+			return deltaPruning{}
+		}
+		d.gain[p] = v
+	}
+	d.on = true
+	d.margin = 2 * d.gain[microchess.PiecePawn1]
+	return d
+}
+
+// worker is the state of one search thread: its own board (a clone for
+// helper threads), node counters, move ordering heuristics and PV.
+// Only the transposition table is shared between workers.
+type worker struct {
+	engine *Engine
+	id     int // 0 is the main thread
+
+	nodes, qnodes  uint64
+	probes, hits   uint64 // Transposition table use by this thread
+	completedDepth int    // Deepest iteration this thread finished
+	order          orderer
+	pv             [maxPly][maxPly]microchess.Move
+	pvLen          [maxPly]int
+	line           [maxPly]microchess.Move   // Move played at each ply, for the evaluator's MoveContext
+	moves          [maxPly][]microchess.Move // Move lists of each ply, reused between nodes
+	stopped        bool                      // The current iteration was abandoned
+	excluded       []microchess.Move         // Root moves already ranked in this iteration (multi-PV)
+
+	// Used by the main thread only
+	ctx     context.Context
+	observe microchess.Observer
+	best    microchess.Progress // Last reported progress
+	start   time.Time
+	budget  microchess.Budget
+	canStop bool // Set once an iteration has completed, so a move is always known
+}
+
+// New creates an engine from the given options.
+func New(opts Options) *Engine {
+	return &Engine{
+		Eval:     Material,
+		MaxDepth: opts.Depth,
+		Threads:  max(1, opts.Threads),
+		MultiPV:  max(1, opts.MultiPV),
+		table:    NewTable(opts.HashMB),
+	}
+}
+
+// Name implements microchess.Engine.
+func (e *Engine) Name() string {
+	return "alphabeta"
+}
+
+// Depth implements microchess.DepthSetter.
+func (e *Engine) Depth() int {
+	return e.MaxDepth
+}
+
+// SetDepth implements microchess.DepthSetter, with the range of the Depth
+// option.
+func (e *Engine) SetDepth(plies int) error {
+	opts := Options{Depth: e.MaxDepth}
+	if err := opts.Set("Depth", strconv.Itoa(plies)); err != nil {
+		return err
+	}
+	e.MaxDepth = opts.Depth
+	return nil
+}
+
+// HashMB implements microchess.HashSetter.
+func (e *Engine) HashMB() int {
+	return e.table.SizeMB()
+}
+
+// SetHashMB implements microchess.HashSetter, with the range of the Hash
+// option. The engine gets a new, empty table.
+func (e *Engine) SetHashMB(mb int) error {
+	var opts Options
+	if err := opts.Set("Hash", strconv.Itoa(mb)); err != nil {
+		return err
+	}
+	e.table = NewTable(opts.HashMB)
+	return nil
+}
+
+// ThreadCount implements microchess.ThreadSetter.
+func (e *Engine) ThreadCount() int {
+	return e.Threads
+}
+
+// SetThreadCount implements microchess.ThreadSetter, with the range of the
+// Threads option.
+func (e *Engine) SetThreadCount(n int) error {
+	var opts Options
+	if err := opts.Set("Threads", strconv.Itoa(n)); err != nil {
+		return err
+	}
+	e.Threads = opts.Threads
+	return nil
+}
+
+// Table returns the engine's transposition table (for statistics).
+func (e *Engine) Table() *Table {
+	return e.table
+}
+
+// Think implements microchess.Engine with iterative deepening.
+//
+// With a time control, no new iteration is started once half of the soft
+// budget is used (the next one would take several times as long), and an
+// iteration still running at the hard limit is abandoned: its partial
+// result is discarded and the previous iteration's move is returned.
+//
+// Cancelling ctx abandons the search the same way, at any depth. If not
+// even the first iteration has completed, the best root move searched so
+// far is returned. observe is called after every iteration and every
+// checkInterval nodes.
+//
+// The move always comes from the main thread; helper threads only
+// contribute through the transposition table.
+func (e *Engine) Think(ctx context.Context, g *microchess.GameState, tc microchess.TimeControl, observe microchess.Observer) microchess.SearchResult {
+	return e.Analyze(ctx, g, tc, e.MultiPV, observe)
+}
+
+// Analyze implements microchess.Analyzer: Think ranking the best n root
+// moves. Each iteration searches the root n times, each time excluding the
+// moves already ranked, so line k is the best move once the first k-1 are
+// set aside. The lines of the last completed iteration are returned,
+// sorted by score (ties in the order they were found).
+func (e *Engine) Analyze(ctx context.Context, g *microchess.GameState, tc microchess.TimeControl, n int, observe microchess.Observer) microchess.SearchResult {
+	n = max(1, n)
+	e.stop.Store(false)
+	e.table.NewSearch()
+	e.delta = newDeltaPruning(e.Eval)
+
+	maxDepth := max(1, e.MaxDepth)
+	budget := tc.Budget(g.Reversed)
+	if !budget.Unlimited() || tc.Infinite {
+		maxDepth = MaxDepth
+	}
+	if tc.Depth > 0 {
+		maxDepth = min(tc.Depth, MaxDepth)
+	}
+
+	main := e.worker(0)
+	main.reset(ctx, observe, budget)
+	helpers := e.startHelpers(g, maxDepth)
+
+	result := microchess.SearchResult{Move: noMove}
+	for depth := 1; depth <= maxDepth; depth++ {
+		main.best.Depth = depth
+		g.ActiveTrace().Reset() // Keep the tree of the last iteration only
+		lines, score := main.searchLines(g, depth, n)
+		if main.stopped && !main.canStop {
+			// Interrupted during the first iteration: keep the root moves already searched
+			if len(lines) == 0 && main.pvLen[0] > 0 {
+				lines = []microchess.PVLine{{Move: main.pv[0][0], Score: score, PV: []microchess.Move{main.pv[0][0]}}}
+			}
+			if len(lines) > 0 {
+				result.Depth = depth
+				setLines(&result, lines, n)
+			}
+		}
+		if main.stopped || len(lines) == 0 {
+			break // Out of time, cancelled, or no legal move at the root
+		}
+		result.Depth = depth
+		setLines(&result, lines, n)
+		score = result.Score
+		main.completedDepth = depth
+		main.canStop = true
+		main.best.Move, main.best.Score = result.Move, score
+		main.report()
+
+		if score > Mate-maxPly || score < -Mate+maxPly {
+			break // Forced mate found: deeper iterations cannot change the outcome
+		}
+		if main.budget.Soft > 0 && time.Since(main.start) > main.budget.Soft/2 {
+			break
+		}
+	}
+
+	e.stop.Store(true)
+	helpers.Wait()
+
+	result.Stopped = ctx.Err() != nil
+	if e.Threads > 1 {
+		result.Threads = make([]microchess.ThreadStats, 0, e.Threads)
+	}
+	for _, w := range e.workers[:e.Threads] {
+		result.Nodes += w.nodes
+		result.QNodes += w.qnodes
+		if e.Threads > 1 {
+			result.Threads = append(result.Threads, w.stats())
+		}
+	}
+	return result
+}
+
+// searchLines searches the root up to n times at the given depth, each
+// time excluding the moves found before, and returns the lines completed
+// (fewer than n if the search stopped or ran out of moves) together with
+// the score of the last root search.
+func (w *worker) searchLines(g *microchess.GameState, depth, n int) ([]microchess.PVLine, int) {
+	var lines []microchess.PVLine
+	var score int
+	w.excluded = w.excluded[:0]
+	for len(lines) < n {
+		score = w.negamax(g, depth, 0, -Infinity, Infinity)
+		if w.stopped || w.pvLen[0] == 0 {
+			break
+		}
+		pv := append([]microchess.Move(nil), w.pv[0][:w.pvLen[0]]...)
+		lines = append(lines, microchess.PVLine{Move: pv[0], Score: score, PV: pv})
+		w.excluded = append(w.excluded, pv[0])
+	}
+	w.excluded = w.excluded[:0]
+	return lines, score
+}
+
+// setLines fills the best move, score and PV of result from the ranked
+// lines, and Lines itself when more than one line was asked for.
+func setLines(result *microchess.SearchResult, lines []microchess.PVLine, n int) {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Score > lines[j].Score })
+	result.Move, result.Score, result.PV = lines[0].Move, lines[0].Score, lines[0].PV
+	result.Lines = nil
+	if n > 1 {
+		result.Lines = lines
+	}
+}
+
+// worker returns the state of thread id, allocating it on first use.
+func (e *Engine) worker(id int) *worker {
+	for len(e.workers) <= id {
+		e.workers = append(e.workers, &worker{engine: e, id: len(e.workers)})
+	}
+	return e.workers[id]
+}
+
+// reset prepares the worker for a new search.
+func (w *worker) reset(ctx context.Context, observe microchess.Observer, budget microchess.Budget) {
+	w.nodes, w.qnodes = 0, 0
+	w.probes, w.hits = 0, 0
+	w.completedDepth = 0
+	w.order.clear()
+	w.ctx, w.observe = ctx, observe
+	w.best = microchess.Progress{Move: noMove}
+	w.start = time.Now()
+	w.budget = budget
+	w.canStop, w.stopped = false, false
+}
+
+// stats returns the per-thread statistics of the last search.
+func (w *worker) stats() microchess.ThreadStats {
+	return microchess.ThreadStats{
+		Depth:    w.completedDepth,
+		Nodes:    w.nodes,
+		QNodes:   w.qnodes,
+		TTProbes: w.probes,
+		TTHits:   w.hits,
+	}
+}
+
+// makeMove plays m and hands the move to the opponent, like the CHKCHK
+// sequence MOVE + REVERSE.
+func makeMove(g *microchess.GameState, m microchess.Move) {
+	g.MovePiece = m.Piece
+	g.MoveSquare = m.To
+	g.MOVE()
+	g.Reverse()
+}
+
+// unmakeMove undoes makeMove with RUM (REVERSE + UMOVE).
+func unmakeMove(g *microchess.GameState) {
+	g.RUM()
+}
+
+// negamax returns the score of the position for the side to move, searching
+// depth more plies with the window (alpha, beta).
+func (w *worker) negamax(g *microchess.GameState, depth, ply, alpha, beta int) (value int) {
+	if depth <= 0 || ply >= maxPly-1 {
+		return w.quiesce(g, ply, alpha, beta)
+	}
+	w.nodes++
+	if trace := g.ActiveTrace(); trace != nil {
+		trace.Enter(g, microchess.TraceSearch, w.lastMove(ply))
+		trace.Window(depth, alpha, beta)
+		defer func() { trace.Leave(value) }()
+	}
+	w.pvLen[ply] = 0
+	if w.shouldStop() {
+		return 0
+	}
+
+	// LINE accepts sliding captures that leave the king en prise, so the
+	// king can actually be taken: that side has lost.
+	if !g.Board[microchess.PieceKing].IsValid() {
+		return -Mate + ply
+	}
+
+	ttMove := noMove
+	w.probes++
+	if entry, ok := w.engine.table.Probe(g.Hash); ok {
+		w.hits++
+		ttMove = entry.Move
+		if ply > 0 && int(entry.Depth) >= depth {
+			score := scoreFromTable(int(entry.Score), ply)
+			switch {
+			case entry.Bound == BoundExact,
+				entry.Bound == BoundLower && score >= beta,
+				entry.Bound == BoundUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	// Moves are generated without CHKCHK, which would cost a GNM of the
+	// replies for every move; IsLegal checks only those actually searched.
+	moves := g.PseudoLegalMoves(w.moves[ply][:0])
+	w.moves[ply] = moves
+	w.order.sort(g, moves, ttMove, ply)
+
+	alphaOrig := alpha
+	best, bestMove := -Infinity, noMove
+	legal := 0
+	for _, m := range moves {
+		if !g.IsLegal(m) {
+			continue
+		}
+		legal++
+		if ply == 0 && slices.Contains(w.excluded, m) {
+			continue // Already ranked by multi-PV
+		}
+		capture := isCapture(g, m)
+		w.line[ply] = m
+		makeMove(g, m)
+		score := -w.negamax(g, depth-1, ply+1, -beta, -alpha)
+		unmakeMove(g)
+		if w.stopped {
+			return 0
+		}
+
+		if score > best {
+			best, bestMove = score, m
+		}
+		if score > alpha {
+			alpha = score
+			w.updatePV(ply, m)
+		}
+		if alpha >= beta {
+			if !capture {
+				w.order.recordCutoff(m, depth, ply)
+			}
+			break
+		}
+	}
+
+	if legal == 0 {
+		if g.InCheck() {
+			return -Mate + ply
+		}
+		return 0 // Stalemate
+	}
+
+	bound := BoundExact
+	switch {
+	case best <= alphaOrig:
+		bound = BoundUpper
+	case best >= beta:
+		bound = BoundLower
+	}
+	if ply > 0 || len(w.excluded) == 0 {
+		// A root searched without some of its moves must not overwrite the real entry
+		w.engine.table.Store(g.Hash, Entry{Move: bestMove, Score: int16(scoreToTable(best, ply)), Depth: int8(depth), Bound: bound})
+	}
+	return best
+}
+
+// quiesce extends a leaf with captures only, until the position is quiet,
+// so that the evaluation is never taken in the middle of an exchange
+// (the horizon effect). The side to move may always "stand pat" on the
+// static evaluation instead of capturing.
+//
+// Captures come from GNMCaptures (GNM with the JANUS filter restricted to
+// captures) and are searched in MVV-LVA order; the legality of a capture
+// is only checked once the pruning below has kept it. Delta pruning skips captures
+// which cannot raise alpha even if the victim comes for free (with evaluators
+// that have piece values, see deltaPruning), and SEE
+// pruning skips captures which lose material once the exchange is played out.
+func (w *worker) quiesce(g *microchess.GameState, ply, alpha, beta int) (value int) {
+	w.qnodes++
+	if trace := g.ActiveTrace(); trace != nil {
+		trace.Enter(g, microchess.TraceQuiesce, w.lastMove(ply))
+		trace.Window(0, alpha, beta)
+		defer func() { trace.Leave(value) }()
+	}
+	w.pvLen[ply] = 0
+	if w.shouldStop() {
+		return 0
+	}
+
+	if !g.Board[microchess.PieceKing].IsValid() {
+		return -Mate + ply
+	}
+
+	standPat := w.evaluate(g, ply)
+	if standPat >= beta || ply >= maxPly-1 {
+		return standPat
+	}
+	alpha = max(alpha, standPat)
+
+	captures := g.PseudoLegalCaptures(w.moves[ply][:0])
+	w.moves[ply] = captures
+	w.order.sort(g, captures, noMove, ply)
+
+	for _, m := range captures {
+		victim := g.CapturedPiece(m.To)
+		if d := &w.engine.delta; d.on && victim != microchess.PieceKing && standPat+d.gain[victim]+d.margin <= alpha {
+			continue // Delta pruning
+		}
+		if victim != microchess.PieceKing && losingCapture(g, m, victim) {
+			continue // SEE pruning: the exchange loses material
+		}
+		if !g.IsLegal(m) {
+			continue
+		}
+
+		w.line[ply] = m
+		makeMove(g, m)
+		score := -w.quiesce(g, ply+1, -beta, -alpha)
+		unmakeMove(g)
+		if w.stopped {
+			return 0
+		}
+
+		if score >= beta {
+			return score
+		}
+		if score > alpha {
+			alpha = score
+			w.updatePV(ply, m)
+		}
+	}
+	return alpha
+}
+
+// evaluate scores a leaf with the engine's evaluator, telling it which move
+// led there. makeMove has reversed the board, so the mover is now in BK.
+func (w *worker) evaluate(g *microchess.GameState, ply int) int {
+	mc := microchess.NoMoveContext
+	if ply > 0 {
+		mc = microchess.MoveContext{Move: w.lastMove(ply), Mover: microchess.SideBK}
+	}
+	return w.engine.Eval.Score(g, mc)
+}
+
+// lastMove returns the move that led to the node at ply (noMove at the root).
+func (w *worker) lastMove(ply int) microchess.Move {
+	if ply == 0 {
+		return noMove
+	}
+	return w.line[ply-1]
+}
+
+// shouldStop reports whether the search must end on this thread.
+//
+// Every thread obeys the engine's stop flag. The main thread also checks
+// the context and the hard time limit every checkInterval nodes, and raises
+// the flag for everyone. The time limit only applies once the first
+// iteration has completed, so that Think has a move to return.
+func (w *worker) shouldStop() bool {
+	if w.stopped {
+		return true
+	}
+	if w.engine.stop.Load() {
+		w.stopped = true
+		return true
+	}
+	if w.id != 0 || (w.nodes+w.qnodes)%checkInterval != 0 {
+		return false
+	}
+	w.report()
+	switch {
+	case w.ctx.Err() != nil:
+		w.stopped = true
+	case w.canStop && w.budget.Hard > 0:
+		w.stopped = time.Since(w.start) >= w.budget.Hard
+	}
+	if w.stopped {
+		w.engine.stop.Store(true)
+	}
+	return w.stopped
+}
+
+// report sends the current progress to the observer, if any.
+func (w *worker) report() {
+	if w.observe != nil {
+		w.best.Nodes = w.nodes + w.qnodes
+		w.observe(w.best)
+	}
+}
+
+// updatePV makes m followed by the child's PV the principal variation at ply.
+func (w *worker) updatePV(ply int, m microchess.Move) {
+	w.pv[ply][0] = m
+	n := copy(w.pv[ply][1:], w.pv[ply+1][:w.pvLen[ply+1]])
+	w.pvLen[ply] = n + 1
+}
+
+// scoreToTable converts a mate score relative to the root into one relative
+// to the current node, so that it stays valid when found through another path.
+func scoreToTable(score, ply int) int {
+	switch {
+	case score > Mate-maxPly:
+		return score + ply
+	case score < -Mate+maxPly:
+		return score - ply
+	}
+	return score
+}
+
+// scoreFromTable undoes scoreToTable.
+func scoreFromTable(score, ply int) int {
+	switch {
+	case score > Mate-maxPly:
+		return score - ply
+	case score < -Mate+maxPly:
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,406 @@
+// ABOUTME: This file contains tests for the alpha-beta search engine.
+// ABOUTME: It checks tactical results, PV reporting and that the position is left untouched.
+
+package search
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyGame returns a game with every piece parked off board at $CC.
+func emptyGame() *microchess.GameState {
+	g := microchess.NewGame(&bytes.Buffer{})
+	for i := 0; i < 16; i++ {
+		g.Board[i] = 0xCC
+		g.BK[i] = 0xCC
+	}
+	return g
+}
+
+// backRankMate sets up Rd1-d8 mate against a king on a8 boxed in by its own pawns.
+func backRankMate() *microchess.GameState {
+	g := emptyGame()
+	g.Board[microchess.PieceKing] = 0x07
+	g.Board[microchess.PieceRook1] = 0x03
+	g.Board[microchess.PiecePawn1] = 0x16
+	g.Board[microchess.PiecePawn2] = 0x17
+	g.BK[microchess.PieceKing] = 0x70
+	g.BK[microchess.PiecePawn1] = 0x60
+	g.BK[microchess.PiecePawn2] = 0x61
+	g.Hash = g.ComputeHash()
+	return g
+}
+
+func newEngine(depth int) *Engine {
+	opts := DefaultOptions()
+	opts.HashMB = 1
+	opts.Depth = depth
+	return New(opts)
+}
+
+func TestSearch_FindsMateInOne(t *testing.T) {
+	g := backRankMate()
+
+	result := newEngine(3).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, microchess.Move{From: 0x03, To: 0x73, Piece: microchess.PieceRook1}, result.Move)
+	assert.Equal(t, Mate-1, result.Score)
+	assert.Equal(t, 2, result.Depth, "deepening stops once the mate is proven")
+}
+
+func TestSearch_TakesHangingQueen(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Board[microchess.PiecePawn7] = 0x34 // e4
+	g.BK[microchess.PieceQueen] = 0x43    // queen on d5, attacked by e4
+	g.Hash = g.ComputeHash()
+
+	result := newEngine(2).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, microchess.Move{From: 0x34, To: 0x43, Piece: microchess.PiecePawn7}, result.Move)
+	assert.Greater(t, result.Score, 3*PawnValue)
+}
+
+func TestSearch_LeavesPositionUntouched(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	before := *g
+
+	result := newEngine(3).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, before.Board, g.Board)
+	assert.Equal(t, before.BK, g.BK)
+	assert.Equal(t, before.Reversed, g.Reversed)
+	assert.Equal(t, before.Hash, g.Hash)
+	assert.Empty(t, g.MoveHistory)
+
+	require.Len(t, result.PV, 3)
+	assert.Equal(t, result.Move, result.PV[0])
+	assert.Contains(t, g.LegalMoves(), result.Move)
+	assert.Positive(t, result.Nodes)
+}
+
+func TestSearch_NoLegalMoves(t *testing.T) {
+	g := backRankMate()
+	g.MovePiece = microchess.PieceRook1
+	g.MoveSquare = board.Square(0x73)
+	g.MOVE()
+	g.Reverse()
+
+	result := newEngine(2).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, microchess.NoPiece, result.Move.Piece)
+	assert.Empty(t, result.PV)
+}
+
+func TestSearch_TranspositionTableIsUsed(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	engine := newEngine(3)
+
+	engine.Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	stats := engine.Table().Stats()
+	assert.Positive(t, stats.Stores)
+	assert.Positive(t, stats.Hits)
+}
+
+func TestStrategyIsAntisymmetric(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Board[microchess.PiecePawn7] = 0x34
+	g.Hash = g.ComputeHash()
+
+	ours := Strategy.Score(g, microchess.NoMoveContext)
+	g.Reverse()
+	theirs := Strategy.Score(g, microchess.NoMoveContext)
+
+	assert.Equal(t, -ours, theirs)
+}
+
+func TestSearch_QuiescenceAvoidsHorizonBlunder(t *testing.T) {
+	g := emptyGame()
+	g.Board[microchess.PieceKing] = 0x00
+	g.Board[microchess.PieceQueen] = 0x13
+	g.BK[microchess.PieceKing] = 0x77
+	g.BK[microchess.PiecePawn1] = 0x43 // Pawn on the queen's file...
+	g.BK[microchess.PiecePawn2] = 0x54 // ...defended by another pawn
+	g.Hash = g.ComputeHash()
+
+	// At depth 1 a plain search would see QxP winning a pawn; quiescence
+	// sees the recapture and declines.
+	static := Material.Score(g, microchess.NoMoveContext)
+	result := newEngine(1).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.NotEqual(t, board.Square(0x43), result.Move.To)
+	assert.Greater(t, result.QNodes, uint64(0))
+	assert.Equal(t, static, result.Score)
+}
+
+func TestSearch_QuiescenceResolvesCaptures(t *testing.T) {
+	g := emptyGame()
+	g.Board[microchess.PieceKing] = 0x00
+	g.Board[microchess.PieceRook1] = 0x13
+	g.BK[microchess.PieceKing] = 0x77
+	g.BK[microchess.PieceQueen] = 0x43 // Undefended queen on the rook's file
+	g.Hash = g.ComputeHash()
+
+	static := Material.Score(g, microchess.NoMoveContext)
+	result := newEngine(1).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, microchess.Move{From: 0x13, To: 0x43, Piece: microchess.PieceRook1}, result.Move)
+	assert.Equal(t, static+5*PawnValue, result.Score) // Queen is 10 POINTS
+
+}
+
+func TestOrdering_LosingCapturesAfterKillers(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("7k/8/4p3/3p4/8/8/8/K2Q4 w")) // Qd1xd5 loses the queen to e6xd5
+
+	var o orderer
+	quiet := microchess.Move{From: 0x03, To: 0x13, Piece: microchess.PieceQueen}
+	losing := microchess.Move{From: 0x03, To: 0x43, Piece: microchess.PieceQueen}
+	o.recordCutoff(quiet, 1, 0)
+
+	assert.Greater(t, o.score(g, quiet, noMove, 0), o.score(g, losing, noMove, 0))
+	assert.Greater(t, o.score(g, losing, noMove, 0), o.score(g, microchess.Move{From: 0x00, To: 0x10}, noMove, 0))
+}
+
+func TestAnalyze_RanksRootMoves(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("3q3k/8/8/8/8/8/3R2P1/K7 w")) // Rd2xd8 wins the queen
+	e := newEngine(2)
+
+	single := e.Think(context.Background(), g, microchess.TimeControl{}, nil)
+	result := e.Analyze(context.Background(), g, microchess.TimeControl{}, 3, nil)
+
+	require.Len(t, result.Lines, 3)
+	assert.Equal(t, single.Move, result.Move)
+	assert.Equal(t, single.Score, result.Score)
+	assert.Equal(t, result.Lines[0].Move, result.Move)
+	assert.Equal(t, result.Lines[0].PV, result.PV)
+	seen := map[microchess.Move]bool{}
+	for i, l := range result.Lines {
+		assert.False(t, seen[l.Move], "each line starts with a different move")
+		seen[l.Move] = true
+		assert.Equal(t, l.Move, l.PV[0])
+		if i > 0 {
+			assert.LessOrEqual(t, l.Score, result.Lines[i-1].Score)
+		}
+	}
+	assert.Less(t, result.Lines[1].Score, result.Lines[0].Score-4*PawnValue, "no other move wins the queen")
+}
+
+func TestAnalyze_MoreLinesThanMoves(t *testing.T) {
+	g := emptyGame()
+	g.Board[microchess.PieceKing] = 0x00
+	g.BK[microchess.PieceKing] = 0x77
+	g.Hash = g.ComputeHash()
+
+	result := newEngine(2).Analyze(context.Background(), g, microchess.TimeControl{}, 10, nil)
+	assert.Len(t, result.Lines, 3) // Ka1 has three squares
+}
+
+func TestSearch_StopsWithinMoveTime(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	before := *g
+
+	start := time.Now()
+	result := newEngine(1).Think(context.Background(), g, microchess.TimeControl{MoveTime: 100 * time.Millisecond}, nil)
+	elapsed := time.Since(start)
+
+	assert.Contains(t, g.LegalMoves(), result.Move)
+	assert.Greater(t, result.Depth, 1, "the time control lifts the Depth option")
+	assert.Less(t, result.Depth, MaxDepth)
+	assert.Less(t, elapsed, time.Second)
+	assert.Equal(t, before.Board, g.Board, "an abandoned iteration still unwinds the board")
+	assert.Equal(t, before.Hash, g.Hash)
+}
+
+func TestSearch_OnlyTheOtherSidesClockKeepsTheDepth(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	g.Reverse() // Black to move, but only white's clock is given
+
+	result := newEngine(2).Think(context.Background(), g, microchess.TimeControl{WTime: time.Minute, WInc: time.Second}, nil)
+
+	assert.Equal(t, 2, result.Depth, "no budget for black: the Depth option bounds the search")
+	assert.Contains(t, g.LegalMoves(), result.Move)
+}
+
+func TestDeltaPruning_FollowsTheEvaluator(t *testing.T) {
+	d := newDeltaPruning(Material)
+	assert.True(t, d.on)
+	assert.Equal(t, PawnValue, d.gain[microchess.PiecePawn1])
+	assert.Equal(t, 2*PawnValue, d.margin)
+
+	d = newDeltaPruning(Symmetric{Material})
+	assert.Equal(t, 2*PawnValue, d.gain[microchess.PiecePawn1], "the difference of both sides' scores")
+
+	assert.False(t, newDeltaPruning(Strategy).on, "STRATGY units cannot be compared with piece values")
+}
+
+func TestSearch_DepthCapsTheClock(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	result := newEngine(5).Think(context.Background(), g, microchess.TimeControl{WTime: time.Hour, BTime: time.Hour, Depth: 3}, nil)
+	assert.Equal(t, 3, result.Depth, "go depth 3 wtime ... stops at depth 3")
+
+	result = newEngine(5).Think(context.Background(), g, microchess.TimeControl{Depth: 2}, nil)
+	assert.Equal(t, 2, result.Depth, "the cap also lowers the Depth option")
+}
+
+func TestSearch_CancelledContextStopsTheSearch(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	before := *g
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Cancel from the observer once the search is under way
+	engine := newEngine(MaxDepth)
+	result := engine.Think(ctx, g, microchess.TimeControl{}, func(p microchess.Progress) {
+		if p.Depth >= 3 {
+			cancel()
+		}
+	})
+
+	assert.True(t, result.Stopped)
+	assert.Less(t, result.Depth, MaxDepth)
+	assert.Contains(t, g.LegalMoves(), result.Move)
+	assert.Equal(t, before.Board, g.Board)
+	assert.Equal(t, before.BK, g.BK)
+	assert.Equal(t, before.Hash, g.Hash)
+}
+
+func TestSearch_ReportsProgress(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+	var reports []microchess.Progress
+
+	result := newEngine(3).Think(context.Background(), g, microchess.TimeControl{}, func(p microchess.Progress) {
+		reports = append(reports, p)
+	})
+
+	require.NotEmpty(t, reports)
+	for i := 1; i < len(reports); i++ {
+		assert.GreaterOrEqual(t, reports[i].Depth, reports[i-1].Depth)
+		assert.GreaterOrEqual(t, reports[i].Nodes, reports[i-1].Nodes)
+	}
+	last := reports[len(reports)-1]
+	assert.Equal(t, 3, last.Depth)
+	assert.Equal(t, result.Move, last.Move)
+	assert.Equal(t, result.Score, last.Score)
+	assert.False(t, result.Stopped)
+}
+
+func TestSearch_LazySMP(t *testing.T) {
+	g := backRankMate()
+	before := *g
+	engine := newEngine(4)
+	engine.Threads = 4
+
+	result := engine.Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, microchess.Move{From: 0x03, To: 0x73, Piece: microchess.PieceRook1}, result.Move)
+	assert.Equal(t, Mate-1, result.Score)
+	require.Len(t, result.Threads, 4)
+	assert.Equal(t, result.Depth, result.Threads[0].Depth, "thread 0 is the main thread")
+	var nodes uint64
+	for _, ts := range result.Threads {
+		nodes += ts.Nodes
+	}
+	assert.Equal(t, result.Nodes, nodes)
+	assert.Equal(t, before.Board, g.Board)
+	assert.Equal(t, before.Hash, g.Hash)
+}
+
+func TestSearch_SingleThreadIsDeterministic(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	g.SetupBoard()
+
+	first := newEngine(3).Think(context.Background(), g, microchess.TimeControl{}, nil)
+	second := newEngine(3).Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+	assert.Equal(t, first, second)
+	assert.Nil(t, first.Threads)
+}
+
+func TestSearch_AcceptsAnyEvaluator(t *testing.T) {
+	evaluators := []microchess.Evaluator{
+		Strategy,
+		microchess.MaterialPSTEvaluator{PST: microchess.DefaultPST},
+		microchess.WeightedEvaluator{Terms: []microchess.WeightedTerm{
+			{Evaluator: Strategy, Weight: 400},
+			{Evaluator: Material, Weight: 100},
+		}},
+	}
+	for _, ev := range evaluators {
+		g := microchess.NewGame(&bytes.Buffer{})
+		g.SetupBoard()
+		g.Board[microchess.PiecePawn7] = 0x34
+		g.BK[microchess.PieceQueen] = 0x43
+		g.Hash = g.ComputeHash()
+		engine := newEngine(2)
+		engine.Eval = ev
+
+		result := engine.Think(context.Background(), g, microchess.TimeControl{}, nil)
+
+		assert.Equal(t, microchess.Move{From: 0x34, To: 0x43, Piece: microchess.PiecePawn7}, result.Move, ev.Name())
+	}
+}
+
+func TestSearch_Trace(t *testing.T) {
+	g := microchess.NewGame(&bytes.Buffer{})
+	require.NoError(t, g.SetFEN("3q3k/8/8/8/8/8/3R2P1/K7 w"))
+	trace := microchess.NewTrace(0, 0)
+	g.SetTrace(trace, "")
+
+	result := newEngine(2).Think(context.Background(), g, microchess.TimeControl{}, nil)
+	trace.MarkPV(result.PV)
+
+	require.NotEmpty(t, trace.Nodes)
+	root := trace.Nodes[0]
+	assert.Equal(t, -1, root.Parent)
+	assert.Equal(t, 2, root.Depth, "only the last iteration is kept")
+	assert.Equal(t, result.Score, root.Score)
+	var quiesce, evaluated, pv int
+	for _, n := range trace.Nodes[1:] {
+		parent := trace.Nodes[n.Parent]
+		assert.Equal(t, parent.Ply+1, n.Ply)
+		assert.NotEqual(t, parent.Black, n.Black, "the side to move alternates")
+		assert.Equal(t, n.Kind == microchess.TraceSearch, n.Depth > 0)
+		if n.Kind == microchess.TraceQuiesce {
+			quiesce++
+		}
+		if len(n.Evals) > 0 {
+			evaluated++
+		}
+		if n.PV {
+			pv++
+		}
+	}
+	assert.Positive(t, quiesce)
+	assert.Zero(t, evaluated, "Material does not run GNMZ")
+	assert.Equal(t, len(result.PV), pv)
+
+	e := newEngine(1)
+	e.Eval = Strategy
+	trace.Reset()
+	e.Think(context.Background(), g, microchess.TimeControl{}, nil)
+	for _, n := range trace.Nodes {
+		if n.Kind == microchess.TraceQuiesce {
+			require.Len(t, n.Evals, 2, "Symmetric evaluates both sides")
+			assert.NotEqual(t, n.Evals[0].Black, n.Evals[1].Black)
+		}
+	}
+}
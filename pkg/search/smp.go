@@ -0,0 +1,51 @@
+// ABOUTME: This file implements Lazy SMP: helper threads searching the same root as the main thread.
+// ABOUTME: Helpers share only the lock-free transposition table and stop when the main thread does.
+
+package search
+
+import (
+	"context"
+	"sync"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// MaxThreads bounds the Threads option.
+const MaxThreads = 256
+
+// startHelpers launches Threads-1 helper threads on clones of g.
+//
+// Lazy SMP needs no coordination beyond the transposition table: helpers
+// run the same iterative deepening as the main thread, and the entries they
+// store let the main thread cut off or order moves sooner. Odd helpers
+// start one ply deeper so that the threads spread over two depths instead
+// of searching the same nodes in lockstep.
+//
+// Helpers end when the main thread raises the engine's stop flag, or after
+// maxDepth. The returned WaitGroup waits for all of them.
+func (e *Engine) startHelpers(g *microchess.GameState, maxDepth int) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for id := 1; id < e.Threads; id++ {
+		w := e.worker(id)
+		w.reset(context.Background(), nil, microchess.Budget{})
+		board := g.Clone()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.helperSearch(board, maxDepth)
+		}()
+	}
+	return &wg
+}
+
+// helperSearch is the iterative deepening loop of a helper thread.
+// Its results are discarded; only its table entries matter.
+func (w *worker) helperSearch(g *microchess.GameState, maxDepth int) {
+	for depth := 1 + w.id%2; depth <= maxDepth; depth++ {
+		w.negamax(g, depth, 0, -Infinity, Infinity)
+		if w.stopped {
+			return
+		}
+		w.completedDepth = depth
+	}
+}
@@ -0,0 +1,241 @@
+// ABOUTME: This file implements a size-bounded, lock-free transposition table keyed by Zobrist hash.
+// ABOUTME: Entries are packed into 16 bytes and grouped in cache-line sized buckets with depth/age replacement.
+
+package search
+
+import (
+	"sync/atomic"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// Bound tells how a stored score relates to the true value of the position.
+type Bound uint8
+
+const (
+	BoundNone  Bound = iota // Empty entry
+	BoundExact              // Score is exact (PV node)
+	BoundLower              // Score is a lower bound (fail high, beta cutoff)
+	BoundUpper              // Score is an upper bound (fail low)
+)
+
+// Entry is the unpacked content of a transposition table slot.
+type Entry struct {
+	Move  microchess.Move // Best move found (Piece == NoPiece if none)
+	Score int16           // Score from the side to move's point of view
+	Depth int8            // Remaining depth the score was searched to
+	Bound Bound           // How Score relates to the true value
+}
+
+// slot is one packed entry: the Zobrist key plus a data word.
+//
+// The table is shared by all search threads without locks. Each word is
+// read and written atomically, and the key is stored XORed with the data
+// ("lockless hashing"): if two threads write the same slot at the same
+// time, a reader sees a data word that does not belong with the check word
+// and the probe simply misses, instead of returning a torn entry.
+//
+// Data word layout (least significant bit first):
+//
+//	bits  0-7   move from square
+//	bits  8-15  move to square
+//	bits 16-23  moving piece (0xFF = no move)
+//	bits 24-39  score (int16)
+//	bits 40-47  depth (int8)
+//	bits 48-49  bound
+//	bits 50-55  age (search generation, modulo 64)
+//	bit  56     always set, so that an occupied slot is never all zeroes
+type slot struct {
+	check atomic.Uint64 // key ^ data
+	data  atomic.Uint64
+}
+
+// load returns the slot's key and data word (data == 0 for an empty slot).
+func (s *slot) load() (key, data uint64) {
+	data = s.data.Load()
+	return s.check.Load() ^ data, data
+}
+
+// save writes key and data. A concurrent reader may see the new data with
+// the old check word (or the reverse), but then load returns a key that
+// does not match and the probe misses.
+func (s *slot) save(key, data uint64) {
+	s.data.Store(data)
+	s.check.Store(key ^ data)
+}
+
+// bucketSize entries share a bucket; 4 x 16 bytes fills one 64-byte cache line.
+const bucketSize = 4
+
+type bucket [bucketSize]slot
+
+const (
+	bytesPerBucket = bucketSize * 16
+	ageMask        = 0x3F
+	occupiedBit    = 1 << 56
+
+	// DefaultHashMB is the table size used when no option is given.
+	DefaultHashMB = 16
+	// MinHashMB and MaxHashMB bound the Hash option.
+	MinHashMB = 1
+	MaxHashMB = 4096
+)
+
+func pack(e Entry, age uint8) uint64 {
+	return uint64(e.Move.From) |
+		uint64(e.Move.To)<<8 |
+		uint64(e.Move.Piece)<<16 |
+		uint64(uint16(e.Score))<<24 |
+		uint64(uint8(e.Depth))<<40 |
+		uint64(e.Bound&3)<<48 |
+		uint64(age&ageMask)<<50 |
+		occupiedBit
+}
+
+func unpack(data uint64) (Entry, uint8) {
+	e := Entry{
+		Move: microchess.Move{
+			From:  board.Square(data),
+			To:    board.Square(data >> 8),
+			Piece: microchess.Piece(data >> 16),
+		},
+		Score: int16(uint16(data >> 24)),
+		Depth: int8(uint8(data >> 40)),
+		Bound: Bound(data>>48) & 3,
+	}
+	return e, uint8(data>>50) & ageMask
+}
+
+// Table is a transposition table with a fixed memory budget.
+//
+// Each Zobrist key maps to one bucket of four entries. Store replaces, in
+// order of preference: the entry with the same key, an empty entry, and
+// finally the entry with the lowest depth, where entries left over from
+// earlier searches count as shallower the older they are.
+//
+// Probe and Store may be called from several goroutines at once; NewSearch
+// and Clear may not run concurrently with them.
+type Table struct {
+	buckets []bucket
+	mask    uint64
+	age     uint8
+
+	probes, hits, stores, overwrites atomic.Uint64
+}
+
+// Stats reports how the table has been used since the last Clear.
+type Stats struct {
+	Probes     uint64 // Probe calls
+	Hits       uint64 // Probes which found the key
+	Misses     uint64 // Probes which did not
+	Stores     uint64 // Store calls
+	Overwrites uint64 // Stores which evicted a different position
+	Entries    int    // Capacity in entries
+	SizeMB     int    // Memory budget in MB
+}
+
+// HitRate returns hits as a fraction of probes (0 when nothing was probed).
+func (s Stats) HitRate() float64 {
+	if s.Probes == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Probes)
+}
+
+// NewTable allocates a table using at most sizeMB megabytes.
+// The bucket count is rounded down to a power of two so that a key is
+// mapped to its bucket with a mask. sizeMB is clamped to [MinHashMB, MaxHashMB].
+func NewTable(sizeMB int) *Table {
+	sizeMB = max(MinHashMB, min(sizeMB, MaxHashMB))
+	n := uint64(sizeMB) * 1024 * 1024 / bytesPerBucket
+	for n&(n-1) != 0 {
+		n &= n - 1
+	}
+	return &Table{buckets: make([]bucket, n), mask: n - 1}
+}
+
+// SizeMB returns the memory budget the table was created with.
+func (t *Table) SizeMB() int {
+	return len(t.buckets) * bytesPerBucket / (1024 * 1024)
+}
+
+// NewSearch starts a new search generation, so that entries from earlier
+// searches become preferred victims for replacement.
+func (t *Table) NewSearch() {
+	t.age = (t.age + 1) & ageMask
+}
+
+// Clear empties the table and resets the statistics.
+func (t *Table) Clear() {
+	clear(t.buckets)
+	t.age = 0
+	t.probes.Store(0)
+	t.hits.Store(0)
+	t.stores.Store(0)
+	t.overwrites.Store(0)
+}
+
+// Probe looks up key and returns the stored entry if present.
+func (t *Table) Probe(key uint64) (Entry, bool) {
+	t.probes.Add(1)
+	b := &t.buckets[key&t.mask]
+	for i := range b {
+		if k, data := b[i].load(); data != 0 && k == key {
+			t.hits.Add(1)
+			e, _ := unpack(data)
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Store saves an entry for key, choosing a victim within the key's bucket.
+// When the new entry has no move but the old one for the same key has,
+// the old move is kept: it is still the best guess for move ordering.
+func (t *Table) Store(key uint64, e Entry) {
+	t.stores.Add(1)
+	b := &t.buckets[key&t.mask]
+
+	victim := 0
+	victimWorth := int(^uint(0) >> 1)
+	for i := range b {
+		k, data := b[i].load()
+		if data == 0 {
+			victim = i
+			break
+		}
+		old, oldAge := unpack(data)
+		if k == key {
+			if e.Move.Piece == microchess.NoPiece {
+				e.Move = old.Move
+			}
+			victim = i
+			break
+		}
+		// Older generations lose 4 plies of worth per search
+		worth := int(old.Depth) - 4*int((t.age-oldAge)&ageMask)
+		if worth < victimWorth {
+			victim, victimWorth = i, worth
+		}
+	}
+
+	if k, data := b[victim].load(); data != 0 && k != key {
+		t.overwrites.Add(1)
+	}
+	b[victim].save(key, pack(e, t.age))
+}
+
+// Stats returns a snapshot of the usage counters.
+func (t *Table) Stats() Stats {
+	probes, hits := t.probes.Load(), t.hits.Load()
+	return Stats{
+		Probes:     probes,
+		Hits:       hits,
+		Misses:     probes - hits,
+		Stores:     t.stores.Load(),
+		Overwrites: t.overwrites.Load(),
+		Entries:    len(t.buckets) * bucketSize,
+		SizeMB:     t.SizeMB(),
+	}
+}
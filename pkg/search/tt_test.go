@@ -0,0 +1,169 @@
+// ABOUTME: This file contains tests for the transposition table and engine options.
+// ABOUTME: It covers packing, replacement policy, statistics and option parsing.
+
+package search
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable_SizeIsBounded(t *testing.T) {
+	table := NewTable(1)
+	assert.Equal(t, 1, table.SizeMB())
+	assert.Equal(t, 1024*1024/16, table.Stats().Entries)
+
+	assert.Equal(t, MinHashMB, NewTable(0).SizeMB(), "sizes are clamped")
+}
+
+func TestTable_StoreAndProbe(t *testing.T) {
+	table := NewTable(1)
+	move := microchess.Move{From: 0x14, To: 0x34, Piece: microchess.PiecePawn7}
+	table.Store(0xDEADBEEF, Entry{Move: move, Score: -321, Depth: 5, Bound: BoundLower})
+
+	e, ok := table.Probe(0xDEADBEEF)
+	require.True(t, ok)
+	assert.Equal(t, move, e.Move)
+	assert.Equal(t, int16(-321), e.Score)
+	assert.Equal(t, int8(5), e.Depth)
+	assert.Equal(t, BoundLower, e.Bound)
+
+	_, ok = table.Probe(0xFEEDFACE)
+	assert.False(t, ok)
+
+	stats := table.Stats()
+	assert.Equal(t, uint64(2), stats.Probes)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 0.5, stats.HitRate())
+}
+
+func TestTable_KeepsMoveWhenRestoringWithoutOne(t *testing.T) {
+	table := NewTable(1)
+	move := microchess.Move{From: 0x06, To: 0x25, Piece: microchess.PieceKnight2}
+	table.Store(42, Entry{Move: move, Score: 10, Depth: 2, Bound: BoundExact})
+	table.Store(42, Entry{Move: microchess.Move{Piece: microchess.NoPiece}, Score: 20, Depth: 3, Bound: BoundUpper})
+
+	e, ok := table.Probe(42)
+	require.True(t, ok)
+	assert.Equal(t, move, e.Move)
+	assert.Equal(t, int16(20), e.Score)
+}
+
+func TestTable_ReplacesShallowestAndOldest(t *testing.T) {
+	table := NewTable(1)
+	buckets := uint64(len(table.buckets))
+	noMove := microchess.Move{Piece: microchess.NoPiece}
+
+	// Fill one bucket: keys congruent modulo the bucket count collide.
+	for i, depth := range []int8{6, 2, 7, 5} {
+		table.Store(1+uint64(i)*buckets, Entry{Move: noMove, Depth: depth, Bound: BoundExact})
+	}
+	table.Store(1+4*buckets, Entry{Move: noMove, Depth: 4, Bound: BoundExact})
+
+	_, ok := table.Probe(1 + 1*buckets)
+	assert.False(t, ok, "depth 2 entry is the victim")
+	_, ok = table.Probe(1 + 4*buckets)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), table.Stats().Overwrites)
+
+	// Two searches later the deep entries are stale and lose to a fresh one.
+	table.NewSearch()
+	table.NewSearch()
+	table.Store(1+5*buckets, Entry{Move: noMove, Depth: 1, Bound: BoundExact})
+	_, ok = table.Probe(1 + 2*buckets)
+	assert.True(t, ok, "depth 7 entry survives")
+	_, ok = table.Probe(1 + 3*buckets)
+	assert.True(t, ok, "stale depth 5 beats stale depth 4")
+	_, ok = table.Probe(1 + 4*buckets)
+	assert.False(t, ok, "stale depth 4 entry is the victim")
+}
+
+func TestTable_Clear(t *testing.T) {
+	table := NewTable(1)
+	table.Store(7, Entry{Depth: 1, Bound: BoundExact})
+	table.Clear()
+
+	_, ok := table.Probe(7)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(0), table.Stats().Stores)
+}
+
+func TestTable_ConcurrentStoreAndProbe(t *testing.T) {
+	table := NewTable(1)
+	var wg sync.WaitGroup
+
+	// Threads hammer the same small set of keys; a probe must never return
+	// an entry written for a different key
+	for thread := 0; thread < 8; thread++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5000; i++ {
+				key := uint64(i%64) << 20 // Few buckets, many collisions
+				table.Store(key, Entry{Score: int16(key >> 20), Depth: int8(thread), Bound: BoundExact})
+				if e, ok := table.Probe(key); ok {
+					assert.Equal(t, int16(key>>20), e.Score)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOptions_Set(t *testing.T) {
+	opts := DefaultOptions()
+	require.NoError(t, opts.Set("hash", "64"))
+	assert.Equal(t, 64, opts.HashMB)
+
+	assert.Error(t, opts.Set("Hash", "0"))
+	assert.Error(t, opts.Set("Hash", "lots"))
+	assert.Error(t, opts.Set("Ponder", "true"))
+	assert.Contains(t, UCIOptions(), "option name Hash type spin default 16 min 1 max 4096")
+
+	require.NoError(t, opts.Set("Threads", "8"))
+	assert.Equal(t, 8, opts.Threads)
+	assert.Error(t, opts.Set("Threads", "0"))
+	assert.Contains(t, UCIOptions(), "option name Threads type spin default 1 min 1 max 256")
+
+	require.NoError(t, opts.Set("MultiPV", "3"))
+	assert.Equal(t, 3, opts.MultiPV)
+	assert.Equal(t, 3, New(opts).MultiPV)
+	assert.Contains(t, UCIOptions(), "option name MultiPV type spin default 1 min 1 max 64")
+}
+
+func TestEngine_SetDepth(t *testing.T) {
+	e := New(DefaultOptions())
+	assert.Equal(t, DefaultDepth, e.Depth())
+	require.NoError(t, e.SetDepth(6))
+	assert.Equal(t, 6, e.Depth())
+	assert.Error(t, e.SetDepth(0))
+	assert.Error(t, e.SetDepth(MaxDepth+1))
+	assert.Equal(t, 6, e.Depth(), "unchanged by a bad depth")
+}
+
+func TestEngine_SetThreadCount(t *testing.T) {
+	e := New(DefaultOptions())
+	assert.Equal(t, 1, e.ThreadCount())
+	require.NoError(t, e.SetThreadCount(4))
+	assert.Equal(t, 4, e.Threads)
+	assert.Error(t, e.SetThreadCount(MaxThreads+1))
+	assert.Equal(t, 4, e.ThreadCount(), "unchanged by a bad count")
+}
+
+func TestEngine_SetHashMB(t *testing.T) {
+	e := New(DefaultOptions())
+	assert.Equal(t, DefaultHashMB, e.HashMB())
+	e.Table().Store(1, Entry{Depth: 3})
+
+	require.NoError(t, e.SetHashMB(2))
+	assert.Equal(t, 2, e.HashMB())
+	_, ok := e.Table().Probe(1)
+	assert.False(t, ok, "a new size starts an empty table")
+	assert.Error(t, e.SetHashMB(MaxHashMB+1))
+	assert.Equal(t, 2, e.HashMB(), "unchanged by a bad size")
+}
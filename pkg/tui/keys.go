@@ -0,0 +1,77 @@
+// ABOUTME: This file decodes raw terminal bytes into key events for the TUI.
+// ABOUTME: Arrow keys arrive as ESC [ A-D (or ESC O A-D); a lone ESC is the Escape key.
+
+package tui
+
+import "time"
+
+// Key identifies a key press.
+type Key int
+
+const (
+	KeyRune Key = iota // A printable character, in Event.Rune
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyEnter
+	KeyEsc
+	KeyCtrlC
+)
+
+// Event is one key press.
+type Event struct {
+	Key  Key
+	Rune byte // For KeyRune
+}
+
+// escapeTimeout is how long to wait for the rest of an escape sequence
+// before taking ESC as the Escape key.
+const escapeTimeout = 30 * time.Millisecond
+
+// readKey reads one key from input. It returns false when input is closed.
+func readKey(input <-chan byte) (Event, bool) {
+	b, ok := <-input
+	if !ok {
+		return Event{}, false
+	}
+	return decodeKey(b, input), true
+}
+
+// decodeKey turns the byte b, and for escape sequences the bytes that
+// follow it on input, into an event.
+func decodeKey(b byte, input <-chan byte) Event {
+	switch b {
+	case '\r', '\n', ' ':
+		return Event{Key: KeyEnter, Rune: b}
+	case 0x03:
+		return Event{Key: KeyCtrlC}
+	case 0x1b:
+	default:
+		return Event{Key: KeyRune, Rune: b}
+	}
+
+	next := func() (byte, bool) {
+		select {
+		case c, ok := <-input:
+			return c, ok
+		case <-time.After(escapeTimeout):
+			return 0, false
+		}
+	}
+	if c, ok := next(); !ok || (c != '[' && c != 'O') {
+		return Event{Key: KeyEsc} // A lone ESC (anything typed right after it is dropped)
+	}
+	c, _ := next()
+	switch c {
+	case 'A':
+		return Event{Key: KeyUp}
+	case 'B':
+		return Event{Key: KeyDown}
+	case 'C':
+		return Event{Key: KeyRight}
+	case 'D':
+		return Event{Key: KeyLeft}
+	}
+	return Event{Key: KeyEsc} // Unsupported sequence (function keys, ...)
+}
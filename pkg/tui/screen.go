@@ -0,0 +1,206 @@
+// ABOUTME: This file draws the TUI screen with ANSI escape sequences (80x24 layout).
+// ABOUTME: Panes: title, board with cursor and highlights, move list, evaluation, LED line, output, status bar.
+
+package tui
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// Screen layout, 1-based rows and columns of an 80x24 terminal.
+const (
+	screenWidth = 80
+	boardRow    = 3  // File labels above the board
+	boardCol    = 2  // Rank labels left of the board
+	movesCol    = 36 // Move list pane
+	movesRows   = 9  // Moves shown (the last ones)
+	evalCol     = 56 // Evaluation and thinking pane
+	ledRow      = 13
+	outputRow   = 15 // Output pane title
+	outputRows  = 7  // Output lines shown (the last ones)
+	statusRow   = 24
+	cellWidth   = 3
+)
+
+// ANSI SGR sequences of the TUI (256-colour palette).
+const (
+	sgrReset    = "\x1b[0m"
+	sgrLight    = "\x1b[48;5;223m"
+	sgrDark     = "\x1b[48;5;137m"
+	sgrLastMove = "\x1b[48;5;186m"
+	sgrTarget   = "\x1b[48;5;151m" // Legal destination of the selected piece
+	sgrSelected = "\x1b[48;5;71m"
+	sgrCursor   = "\x1b[48;5;75m"
+	sgrCheck    = "\x1b[48;5;160m"
+	sgrWhiteMan = "\x1b[1;38;5;231m"
+	sgrBlackMan = "\x1b[38;5;16m"
+	sgrTitle    = "\x1b[1m"
+	sgrStatus   = "\x1b[7m"
+)
+
+// help is shown in the status bar when nothing else is.
+const help = "Arrows move  Enter select/play  0-7 type  H hint  A analyze  Esc cancel  Q quit"
+
+// Draw redraws the whole screen. The screen is not cleared first, which
+// would flicker on every key: each row is written over from the home
+// position and the rest of it erased with EL (\x1b[K).
+func (a *App) Draw() error {
+	a.mu.Lock()
+	snap := a.snap
+	moves := slices.Clone(a.moves)
+	output := a.log[max(0, len(a.log)-outputRows):]
+	output = slices.Clone(output)
+	status := a.status
+	eval, thinking := a.eval, a.thinking
+	a.mu.Unlock()
+
+	var rows [statusRow]strings.Builder
+	// at writes text on a row starting at col; panes sharing a row are
+	// written left to right.
+	at := func(row, col int, format string, args ...any) {
+		r := &rows[row-1]
+		r.WriteString(strings.Repeat(" ", max(0, col-1-width(r.String()))))
+		fmt.Fprintf(r, format, args...)
+	}
+
+	at(1, 1, "%s %s %s", sgrTitle, a.title, sgrReset)
+
+	// Board pane, white at the bottom; the move list and evaluation panes
+	// are on its right
+	files := "   a  b  c  d  e  f  g  h"
+	at(boardRow, boardCol, "%s", files)
+	at(boardRow, movesCol, "%sMoves%s", sgrTitle, sgrReset)
+	at(boardRow, evalCol, "%sEvaluation%s", sgrTitle, sgrReset)
+	first := max(0, len(moves)-movesRows)
+	for r := 7; r >= 0; r-- {
+		row := boardRow + 8 - r
+		at(row, boardCol, "%d ", r+1)
+		for f := 0; f < 8; f++ {
+			rows[row-1].WriteString(a.cell(&snap, board.Square(r<<4|f)))
+		}
+		at(row, boardCol+2+8*cellWidth, " %d", r+1)
+		if i := 7 - r; first+i < len(moves) {
+			at(row, movesCol, "%3d. %s", first+i+1, moves[first+i])
+		}
+	}
+	at(boardRow+9, boardCol, "%s", files)
+	if first+8 < len(moves) {
+		at(boardRow+9, movesCol, "%3d. %s", first+9, moves[first+8])
+	}
+	at(boardRow+1, evalCol, "%s", eval)
+	at(boardRow+3, evalCol, "%sThinking%s", sgrTitle, sgrReset)
+	if thinking.Depth > 0 {
+		best := "-"
+		if thinking.Move.Piece != microchess.NoPiece {
+			best = fmt.Sprintf("%02X%02X", uint8(thinking.Move.From), uint8(thinking.Move.To))
+		}
+		at(boardRow+4, evalCol, "depth %d  best %s", thinking.Depth, best)
+		at(boardRow+5, evalCol, "score %d", thinking.Score)
+		at(boardRow+6, evalCol, "nodes %d", thinking.Nodes)
+	}
+
+	// LED and selection line
+	line := fmt.Sprintf("LED %02X %02X %02X   cursor %s", snap.LED[0], snap.LED[1], snap.LED[2], a.cursor)
+	if a.from != noSquare {
+		line += fmt.Sprintf("   selected %s", a.from)
+	}
+	if len(snap.Checks) > 0 {
+		line += "   check"
+	}
+	at(ledRow, boardCol, "%s", line)
+
+	// Output pane: hints, analysis and other command output
+	at(outputRow, 1, "%sOutput%s", sgrTitle, sgrReset)
+	for i, l := range output {
+		at(outputRow+1+i, 2, "%s", truncate(l, screenWidth-2))
+	}
+
+	// Status bar
+	if status == "" {
+		status = help
+	}
+	at(statusRow, 1, "%s%s%s", sgrStatus, pad(truncate(status, screenWidth), screenWidth), sgrReset)
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H") // Home
+	for i := range rows {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(rows[i].String())
+		sb.WriteString("\x1b[K") // Erase what is left of the previous screen
+	}
+	_, err := io.WriteString(a.out, sb.String())
+	return err
+}
+
+// cell draws the absolute square sq. The background shows, by priority,
+// the cursor, the selected square, a king in check, a legal destination,
+// the last move, or the square colour.
+func (a *App) cell(snap *microchess.Snapshot, sq board.Square) string {
+	stored := snap.Absolute(sq)
+	sp := snap.At(stored)
+	lastMove := snap.LastMove.Piece != microchess.NoPiece && (stored == snap.LastMove.From || stored == snap.LastMove.To)
+
+	bg := sgrLight
+	switch {
+	case sq == a.cursor:
+		bg = sgrCursor
+	case sq == a.from:
+		bg = sgrSelected
+	case sp.Piece == microchess.PieceKing && snap.InCheck(stored):
+		bg = sgrCheck
+	case slices.Contains(a.targets, sq):
+		bg = sgrTarget
+	case lastMove:
+		bg = sgrLastMove
+	case (sq.Rank()+sq.File())%2 == 0:
+		bg = sgrDark
+	}
+	if sp.Piece == microchess.NoPiece {
+		return bg + strings.Repeat(" ", cellWidth) + sgrReset
+	}
+	fg := sgrBlackMan
+	if sp.White {
+		fg = sgrWhiteMan
+	}
+	return bg + fg + " " + microchess.PieceGlyph(sp.Piece.Kind(), false) + " " + sgrReset
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// width is the number of characters s takes on screen, SGR sequences
+// (ESC [ ... m) excluded.
+func width(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			if end := strings.IndexByte(s[i:], 'm'); end >= 0 {
+				i += end + 1
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// pad fills s with spaces up to n characters.
+func pad(s string, n int) string {
+	return s + strings.Repeat(" ", max(0, n-utf8.RuneCountInString(s)))
+}
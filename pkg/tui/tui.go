@@ -0,0 +1,410 @@
+// ABOUTME: This file implements the full-screen terminal UI: board, move list, evaluation, output and status panes.
+// ABOUTME: Moves are picked with the arrow keys or typed; everything goes through the GameState command API.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// noSquare marks "no square selected".
+const noSquare board.Square = 0xFF
+
+// logLines is how many lines of command output the App keeps.
+const logLines = 200
+
+// App is the full-screen UI. It drives a GameState only through its
+// command API (HandleCharacterContext), exactly like the line-mode CLI:
+// moves picked with the cursor are sent as the four digits and Enter a
+// player would type. The App installs itself as the game's Renderer to
+// receive a Snapshot whenever a command displays the board, and captures
+// everything else the commands print for the output pane.
+//
+// Squares in the App are absolute (white at the bottom, as named by
+// board.Square.String); they are converted with Snapshot.Absolute when
+// talking to the game.
+type App struct {
+	game     *microchess.GameState
+	out      io.Writer
+	progress chan microchess.Progress
+
+	// Used by the UI goroutine only
+	cursor  board.Square
+	from    board.Square   // Selected square, or noSquare
+	targets []board.Square // Legal destinations of the piece on from
+	typing  bool           // Digits were typed: Enter goes to the game
+	editing bool           // A ':' command line is open: keys go to the game
+	title   string         // Engine and profile, refreshed between commands
+	pending []Event        // Keys typed while a command was thinking
+
+	mu       sync.Mutex // Guards the fields below, written while a command runs
+	snap     microchess.Snapshot
+	lastMove microchess.Move // Last move added to moves, in absolute squares
+	moves    []string
+	log      []string
+	partial  string // Output not yet ended by a newline
+	status   string
+	eval     string              // Static evaluation of the position, refreshed after every command
+	thinking microchess.Progress // Last progress report of H or A (Depth 0 if none)
+}
+
+// New creates the UI for game, drawing on out. It takes over the game's
+// output, renderer and progress observer.
+func New(game *microchess.GameState, out io.Writer) *App {
+	a := &App{
+		game:     game,
+		out:      out,
+		cursor:   0x13, // d2, the king's pawn in the 1976 setup
+		from:     noSquare,
+		lastMove: microchess.Move{Piece: microchess.NoPiece},
+		progress: make(chan microchess.Progress, 1),
+	}
+	game.SetOutput(logWriter{a})
+	game.SetRenderer(a)
+	game.SetObserver(func(p microchess.Progress) {
+		select {
+		case a.progress <- p:
+		default: // The screen is still busy with the previous report
+		}
+	})
+	a.snap = game.Snapshot()
+	a.refreshTitle()
+	a.evaluate()
+	return a
+}
+
+// evaluate refreshes the evaluation pane with the active evaluator's score
+// of the position, for the side to move. It scores a clone, so the game's
+// registers are left alone; it must not run while a command is running.
+func (a *App) evaluate() {
+	e := a.game.ActiveEvaluator()
+	side := "white"
+	if a.game.Reversed {
+		side = "black"
+	}
+	eval := fmt.Sprintf("%s %d for %s", e.Name(), e.Score(a.game.Clone(), microchess.NoMoveContext), side)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.eval = eval
+}
+
+// refreshTitle updates the title bar from the game. It must not run while
+// a command is running.
+func (a *App) refreshTitle() {
+	a.title = fmt.Sprintf("MicroChess  engine %s  evaluator %s  profile %s",
+		a.game.ActiveEngine().Name(), a.game.ActiveEvaluator().Name(), microchess.ActiveProfile().Name)
+}
+
+// Name implements microchess.Renderer.
+func (a *App) Name() string {
+	return "tui"
+}
+
+// Render implements microchess.Renderer: it keeps the snapshot for the
+// board pane (w is ignored) and adds new moves to the move list. Setting
+// up the board (no last move) starts a new list.
+func (a *App) Render(_ io.Writer, s microchess.Snapshot) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.snap = s
+	if s.LastMove.Piece == microchess.NoPiece {
+		a.moves, a.lastMove = nil, s.LastMove
+		return nil
+	}
+	abs := s.LastMove
+	abs.From, abs.To = s.Absolute(abs.From), s.Absolute(abs.To)
+	if abs != a.lastMove {
+		a.lastMove = abs
+		a.moves = append(a.moves, fmt.Sprintf("%s-%s", abs.From, abs.To))
+	}
+	return nil
+}
+
+// logWriter collects command output for the output pane, one entry per
+// non-empty line.
+type logWriter struct {
+	a *App
+}
+
+// Write implements io.Writer.
+func (l logWriter) Write(p []byte) (int, error) {
+	a := l.a
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	text := a.partial + string(p)
+	lines := strings.Split(text, "\n")
+	a.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		if line = strings.TrimRight(line, "\r "); line != "" {
+			a.log = append(a.log, line)
+		}
+	}
+	if len(a.log) > logLines {
+		a.log = a.log[len(a.log)-logLines:]
+	}
+	return len(p), nil
+}
+
+// Run shows the UI until Q is pressed or input is closed. Commands that
+// make an engine think (H and A) run in the background: the thinking pane
+// shows their progress and Esc or Ctrl-C stops them.
+func (a *App) Run(input <-chan byte) error {
+	if _, err := io.WriteString(a.out, "\x1b[?1049h\x1b[?25l"); err != nil { // Alternate screen, hide cursor
+		return err
+	}
+	defer func() { _, _ = io.WriteString(a.out, "\x1b[?25h\x1b[?1049l") }()
+
+	for {
+		if err := a.Draw(); err != nil {
+			return err
+		}
+		ev, ok := a.nextKey(input)
+		if !ok {
+			return nil
+		}
+		if !a.editing && ev.Key == KeyRune && (upper(ev.Rune) == 'H' || upper(ev.Rune) == 'A') {
+			if !a.think(upper(ev.Rune), input) {
+				return nil
+			}
+			continue
+		}
+		if !a.HandleKey(context.Background(), ev) {
+			return nil
+		}
+		a.refreshTitle()
+	}
+}
+
+// nextKey returns the keys typed while thinking first, then reads input.
+func (a *App) nextKey(input <-chan byte) (Event, bool) {
+	if len(a.pending) > 0 {
+		ev := a.pending[0]
+		a.pending = a.pending[1:]
+		return ev, true
+	}
+	return readKey(input)
+}
+
+// think runs an engine command in the background, updating the status
+// bar with its progress, until it ends or Esc/Ctrl-C stops it. Other keys
+// are kept for when it ends.
+func (a *App) think(c byte, input <-chan byte) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan bool, 1)
+	a.mu.Lock()
+	a.thinking = microchess.Progress{}
+	a.mu.Unlock()
+	a.setStatus("Thinking... (Esc stops)")
+	_ = a.Draw()
+	go func() {
+		done <- a.HandleKey(ctx, Event{Key: KeyRune, Rune: c})
+	}()
+
+	for {
+		select {
+		case ok := <-done:
+			a.refreshTitle()
+			return ok
+		case p := <-a.progress:
+			a.mu.Lock()
+			a.thinking = p
+			a.mu.Unlock()
+			_ = a.Draw()
+		case b, open := <-input:
+			if !open {
+				input = nil // Let the command finish
+				continue
+			}
+			switch ev := decodeKey(b, input); ev.Key {
+			case KeyEsc, KeyCtrlC:
+				cancel()
+			default:
+				a.pending = append(a.pending, ev) // Played once the command ends
+			}
+		}
+	}
+}
+
+// HandleKey applies one key press and reports whether the UI goes on
+// (false after Q). Arrows move the cursor; Enter (or space) selects the
+// piece under the cursor, then plays it to the cursor square; Esc drops
+// the selection. Digits are typed into the game like in line mode, after
+// which Enter plays the typed move. Any other key is a game command; ':'
+// opens the game's command line, shown in the status bar (see editKey).
+func (a *App) HandleKey(ctx context.Context, ev Event) bool {
+	if a.editing {
+		return a.editKey(ctx, ev)
+	}
+	switch ev.Key {
+	case KeyUp:
+		a.moveCursor(1, 0)
+	case KeyDown:
+		a.moveCursor(-1, 0)
+	case KeyRight:
+		a.moveCursor(0, 1)
+	case KeyLeft:
+		a.moveCursor(0, -1)
+	case KeyEsc:
+		a.from, a.targets, a.typing = noSquare, nil, false
+		a.setStatus("")
+	case KeyCtrlC:
+		return false
+	case KeyEnter:
+		if a.typing {
+			a.typing = false
+			return a.command(ctx, '\r')
+		}
+		a.selectSquare()
+	case KeyRune:
+		c := upper(ev.Rune)
+		if c >= '0' && c <= '7' {
+			a.typing = true
+			a.from, a.targets = noSquare, nil
+		}
+		return a.command(ctx, c)
+	}
+	return true
+}
+
+// editKey sends a key to the game's ':' command line: Up and Down recall
+// earlier lines, Esc and Ctrl-C abandon it.
+func (a *App) editKey(ctx context.Context, ev Event) bool {
+	switch ev.Key {
+	case KeyRune:
+		return a.command(ctx, ev.Rune)
+	case KeyEnter:
+		if ev.Rune == ' ' {
+			return a.command(ctx, ' ')
+		}
+		return a.command(ctx, '\r')
+	case KeyUp:
+		return a.command(ctx, 0x10) // Ctrl-P
+	case KeyDown:
+		return a.command(ctx, 0x0E) // Ctrl-N
+	case KeyEsc, KeyCtrlC:
+		return a.command(ctx, 0x03)
+	}
+	return true
+}
+
+// command sends one character to the game.
+func (a *App) command(ctx context.Context, c byte) bool {
+	switch {
+	case a.editing && c == '\r':
+		text, _ := a.game.EditingLine()
+		_, _ = fmt.Fprintf(logWriter{a}, "> :%s\n", text)
+	case !a.editing && c != '\r' && c != ':' && (c < '0' || c > '7'):
+		_, _ = fmt.Fprintf(logWriter{a}, "> %c\n", c) // Echo commands, not digits
+	}
+	ok := a.game.HandleCharacterContext(ctx, c)
+	a.evaluate()
+	text, editing := a.game.EditingLine()
+	a.editing = editing
+	if editing {
+		a.setStatus(":" + text)
+	} else {
+		a.setStatus(a.idleStatus())
+	}
+	return ok
+}
+
+// idleStatus is the status bar between commands: whose turn it is in
+// hot-seat mode, nothing otherwise.
+func (a *App) idleStatus() string {
+	if a.game.HotSeat() {
+		return a.game.TurnStatus()
+	}
+	return ""
+}
+
+// moveCursor moves the cursor by the given number of ranks and files,
+// staying on the board.
+func (a *App) moveCursor(ranks, files int) {
+	rank := min(max(a.cursor.Rank()+ranks, 0), 7)
+	file := min(max(a.cursor.File()+files, 0), 7)
+	a.cursor = board.Square(rank<<4 | file)
+}
+
+// selectSquare handles Enter on the cursor square: select a piece, drop
+// the selection, or play the selected piece there.
+func (a *App) selectSquare() {
+	snap := a.snapshot()
+	switch {
+	case a.from == noSquare:
+		if snap.At(snap.Absolute(a.cursor)).Piece == microchess.NoPiece {
+			a.setStatus(fmt.Sprintf("No piece on %s", a.cursor))
+			return
+		}
+		a.from = a.cursor
+		a.targets = a.legalTargets(&snap, a.cursor)
+	case a.from == a.cursor:
+		a.from, a.targets = noSquare, nil
+	default:
+		from, to := snap.Absolute(a.from), snap.Absolute(a.cursor)
+		a.from, a.targets = noSquare, nil
+		for _, d := range []uint8{uint8(from) >> 4, uint8(from) & 7, uint8(to) >> 4, uint8(to) & 7} {
+			a.game.HandleCharacter('0' + d)
+		}
+		a.game.HandleCharacter('\r')
+		a.evaluate()
+		a.setStatus(a.idleStatus())
+	}
+}
+
+// legalTargets returns the absolute squares the piece on the absolute
+// square sq may move to, if it belongs to the side to move (the Board
+// array). Pieces of the other side can still be moved, as when typing.
+func (a *App) legalTargets(snap *microchess.Snapshot, sq board.Square) []board.Square {
+	var targets []board.Square
+	stored := snap.Absolute(sq)
+	for _, m := range a.game.LegalMoves() {
+		if m.From == stored {
+			targets = append(targets, snap.Absolute(m.To))
+		}
+	}
+	return targets
+}
+
+// Moves returns the moves played since the board was set up, like "d2-d4".
+func (a *App) Moves() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.moves)
+}
+
+// Output returns the lines of command output kept for the output pane.
+func (a *App) Output() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.log)
+}
+
+// snapshot returns the last board shown.
+func (a *App) snapshot() microchess.Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.snap
+}
+
+func (a *App) setStatus(s string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = s
+}
+
+// upper converts a lower case letter to upper case, like the game does.
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
@@ -0,0 +1,207 @@
+// ABOUTME: This file contains tests for the full-screen TUI: key decoding, cursor moves and the panes.
+// ABOUTME: The App is driven with key events and its screen is rendered into a buffer.
+
+package tui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matteo/microchess-go/pkg/board"
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApp returns an App on a freshly set up board, drawing into a buffer.
+func newApp(t *testing.T) (*App, *bytes.Buffer) {
+	t.Helper()
+	var screen bytes.Buffer
+	game := microchess.NewGame(&bytes.Buffer{})
+	app := New(game, &screen)
+	require.True(t, app.HandleKey(context.Background(), Event{Key: KeyRune, Rune: 'c'}))
+	return app, &screen
+}
+
+// keys sends a sequence of events to the App.
+func keys(t *testing.T, app *App, events ...Event) {
+	t.Helper()
+	for _, ev := range events {
+		require.True(t, app.HandleKey(context.Background(), ev))
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	feed := func(b ...byte) <-chan byte {
+		ch := make(chan byte, len(b))
+		for _, c := range b {
+			ch <- c
+		}
+		close(ch)
+		return ch
+	}
+
+	for seq, want := range map[string]Event{
+		"\x1b[A": {Key: KeyUp},
+		"\x1b[B": {Key: KeyDown},
+		"\x1bOC": {Key: KeyRight},
+		"\x1b[D": {Key: KeyLeft},
+		"\r":     {Key: KeyEnter, Rune: '\r'},
+		" ":      {Key: KeyEnter, Rune: ' '},
+		"\x03":   {Key: KeyCtrlC},
+		"h":      {Key: KeyRune, Rune: 'h'},
+		"\x1b":   {Key: KeyEsc},
+	} {
+		ev, ok := readKey(feed([]byte(seq)...))
+		require.True(t, ok)
+		assert.Equal(t, want, ev, "%q", seq)
+	}
+
+	// A lone ESC is recognised once no sequence follows in time
+	input := make(chan byte, 1)
+	input <- 0x1b
+	start := time.Now()
+	ev, _ := readKey(input)
+	assert.Equal(t, KeyEsc, ev.Key)
+	assert.GreaterOrEqual(t, time.Since(start), escapeTimeout)
+
+	_, ok := readKey(feed())
+	assert.False(t, ok, "closed input")
+}
+
+func TestApp_CursorMove(t *testing.T) {
+	app, _ := newApp(t)
+	assert.Equal(t, "d2", app.cursor.String())
+
+	keys(t, app, Event{Key: KeyEnter})
+	assert.Equal(t, "d2", app.from.String())
+	assert.ElementsMatch(t, []board.Square{0x23, 0x33}, app.targets, "d3 and d4")
+
+	keys(t, app, Event{Key: KeyUp}, Event{Key: KeyUp}, Event{Key: KeyEnter})
+	assert.Equal(t, noSquare, app.from)
+	assert.Equal(t, []string{"d2-d4"}, app.Moves())
+	s := app.snapshot()
+	assert.Equal(t, microchess.KindPawn, s.At(0x33).Piece.Kind())
+
+	// Black moves the same way; the game does not reverse after manual moves
+	keys(t, app, Event{Key: KeyUp}, Event{Key: KeyUp}, Event{Key: KeyUp}, Event{Key: KeyRight},
+		Event{Key: KeyEnter}, Event{Key: KeyDown}, Event{Key: KeyDown}, Event{Key: KeyEnter})
+	assert.Equal(t, []string{"d2-d4", "e7-e5"}, app.Moves())
+
+	// The cursor stays on the board
+	for i := 0; i < 10; i++ {
+		keys(t, app, Event{Key: KeyLeft}, Event{Key: KeyDown})
+	}
+	assert.Equal(t, board.SquareA1, app.cursor)
+}
+
+func TestApp_SelectionCancelled(t *testing.T) {
+	app, _ := newApp(t)
+	keys(t, app, Event{Key: KeyEnter}, Event{Key: KeyEsc})
+	assert.Equal(t, noSquare, app.from)
+	keys(t, app, Event{Key: KeyUp}, Event{Key: KeyEnter})
+	assert.Equal(t, noSquare, app.from, "no piece on d3")
+	assert.Contains(t, app.status, "No piece on d3")
+	assert.Empty(t, app.Moves())
+}
+
+func TestApp_TypedMove(t *testing.T) {
+	app, _ := newApp(t)
+	for _, c := range "1434" {
+		keys(t, app, Event{Key: KeyRune, Rune: byte(c)})
+	}
+	led := app.snapshot().LED
+	assert.Equal(t, []uint8{0x14, 0x34}, led[1:], "the typed move is on the LEDs")
+	keys(t, app, Event{Key: KeyEnter})
+	assert.Equal(t, []string{"e2-e4"}, app.Moves())
+
+	keys(t, app, Event{Key: KeyRune, Rune: 'c'})
+	assert.Empty(t, app.Moves(), "setting up starts a new list")
+}
+
+func TestApp_CommandLine(t *testing.T) {
+	app, _ := newApp(t)
+	keys(t, app, Event{Key: KeyRune, Rune: ':'})
+	for _, c := range "fen" {
+		keys(t, app, Event{Key: KeyRune, Rune: byte(c)})
+	}
+	assert.Equal(t, ":fen", app.status, "the line is shown in the status bar")
+	assert.Equal(t, "d2", app.cursor.String(), "the cursor does not move")
+
+	keys(t, app, Event{Key: KeyEnter})
+	assert.False(t, app.editing)
+	assert.Contains(t, app.Output(), "> :fen")
+	assert.Contains(t, app.Output(), "FEN: "+microchess.InitialFEN)
+
+	// Up recalls the line, Esc abandons it
+	keys(t, app, Event{Key: KeyRune, Rune: ':'}, Event{Key: KeyUp})
+	assert.Equal(t, ":fen", app.status)
+	keys(t, app, Event{Key: KeyEsc})
+	assert.False(t, app.editing)
+	assert.Empty(t, app.status)
+	keys(t, app, Event{Key: KeyUp})
+	assert.Equal(t, "d3", app.cursor.String(), "arrows move the cursor again")
+}
+
+func TestApp_CommandOutput(t *testing.T) {
+	app, screen := newApp(t)
+	keys(t, app, Event{Key: KeyRune, Rune: 'h'})
+
+	out := app.Output()
+	require.NotEmpty(t, out)
+	assert.Contains(t, out, "> H")
+	assert.True(t, strings.HasPrefix(out[len(out)-1], "Engine: faithful"), "the board itself is not in the output: %q", out)
+
+	require.NoError(t, app.Draw())
+	text := screen.String()
+	assert.Contains(t, text, "MicroChess  engine faithful")
+	assert.Contains(t, text, "Moves")
+	assert.Contains(t, text, "Output")
+	assert.Contains(t, text, "♚")
+	assert.Contains(t, text, "Engine: faithful")
+	assert.Contains(t, text, "Evaluation")
+	assert.Contains(t, text, app.eval)
+	assert.Contains(t, text, help)
+
+	assert.False(t, app.HandleKey(context.Background(), Event{Key: KeyRune, Rune: 'q'}), "Q quits")
+}
+
+func TestApp_DrawDoesNotClear(t *testing.T) {
+	app, screen := newApp(t)
+	keys(t, app, Event{Key: KeyEnter}, Event{Key: KeyUp}, Event{Key: KeyUp}, Event{Key: KeyEnter})
+	screen.Reset()
+	require.NoError(t, app.Draw())
+	text := screen.String()
+	assert.True(t, strings.HasPrefix(text, "\x1b[H"), "drawn from the home position")
+	assert.NotContains(t, text, "\x1b[2J", "the screen is not cleared")
+	assert.Equal(t, statusRow, strings.Count(text, "\x1b[K"), "every row is erased to its end")
+	assert.Equal(t, statusRow-1, strings.Count(text, "\r\n"))
+
+	lines := strings.Split(text, "\r\n")
+	assert.Contains(t, lines[boardRow], "  1. d2-d4", "the move list is right of the board")
+	assert.Contains(t, lines[boardRow-1], "Evaluation")
+	assert.Equal(t, evalCol-1, width(lines[boardRow][:strings.Index(lines[boardRow], "strategy")]), "the evaluation pane starts at its column")
+}
+
+func TestApp_EvaluationPane(t *testing.T) {
+	app, _ := newApp(t)
+	assert.Regexp(t, `^strategy \d+ for white$`, app.eval)
+	keys(t, app, Event{Key: KeyRune, Rune: 'e'}) // Reverse the board
+	assert.Regexp(t, `^strategy \d+ for black$`, app.eval)
+}
+
+func TestApp_Run(t *testing.T) {
+	app, screen := newApp(t)
+	input := make(chan byte, 16)
+	for _, b := range []byte(" \x1b[A\x1b[A hq") {
+		input <- b
+	}
+	require.NoError(t, app.Run(input))
+	assert.Equal(t, []string{"d2-d4"}, app.Moves())
+	assert.Contains(t, app.Output(), "> H", "H ran in the background")
+	assert.True(t, strings.HasPrefix(screen.String(), "\x1b[?1049h"))
+	assert.True(t, strings.HasSuffix(screen.String(), "\x1b[?1049l"), "the screen is restored")
+}
@@ -0,0 +1,100 @@
+// ABOUTME: This file reads labelled positions for the tuner from EPD or CSV files.
+// ABOUTME: Each sample is a FEN with the game result from white's point of view.
+
+package tune
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// Sample is a position with the result of the game it was taken from.
+type Sample struct {
+	FEN    string
+	Result float64 // 1 white won, 0.5 draw, 0 black won
+}
+
+// ReadSamples reads one labelled position per line. Two formats are
+// accepted, and may be mixed:
+//
+//   - EPD with the result in the c9 opcode or in brackets, as in the
+//     common quiet-position sets:
+//     rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - c9 "1/2-1/2";
+//     rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - [0.5]
+//   - CSV with the FEN in the first column and the result in the second;
+//     a header line is skipped.
+//
+// Results may be written 1-0, 0-1, 1/2-1/2, or as 1, 0.5, 0. Empty lines
+// and lines starting with # are ignored. Every position is checked with
+// SetFEN, so errors report the line they come from.
+func ReadSamples(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	var g microchess.GameState
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var s Sample
+		var err error
+		if fen, result, ok := strings.Cut(line, ","); ok && !strings.Contains(line, ";") {
+			s.FEN = strings.Trim(strings.TrimSpace(fen), `"`)
+			s.Result, err = parseResult(strings.Trim(strings.TrimSpace(result), `"`))
+			if err != nil && len(samples) == 0 && n == 1 {
+				continue // Header
+			}
+		} else {
+			s, err = parseEPD(line)
+		}
+		if err == nil {
+			err = g.SetFEN(s.FEN)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+// parseEPD splits an EPD line into the position and its result.
+func parseEPD(line string) (Sample, error) {
+	if i := strings.Index(line, "["); i >= 0 {
+		j := strings.Index(line[i:], "]")
+		if j < 0 {
+			return Sample{}, fmt.Errorf("unterminated result in %q", line)
+		}
+		result, err := parseResult(line[i+1 : i+j])
+		return Sample{FEN: strings.TrimSpace(line[:i]), Result: result}, err
+	}
+	if i := strings.Index(line, "c9 "); i >= 0 {
+		op, _, _ := strings.Cut(line[i+3:], ";")
+		result, err := parseResult(strings.Trim(strings.TrimSpace(op), `"`))
+		return Sample{FEN: strings.TrimSpace(line[:i]), Result: result}, err
+	}
+	return Sample{}, fmt.Errorf("no result (c9 opcode or [result]) in %q", line)
+}
+
+// parseResult reads a game result from white's point of view.
+func parseResult(s string) (float64, error) {
+	switch s {
+	case "1-0":
+		return 1, nil
+	case "0-1":
+		return 0, nil
+	case "1/2-1/2", "1/2":
+		return 0.5, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || (v != 0 && v != 0.5 && v != 1) {
+		return 0, fmt.Errorf("invalid result %q (want 1-0, 0-1, 1/2-1/2, 1, 0.5 or 0)", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,58 @@
+// ABOUTME: This file contains tests for reading labelled positions.
+// ABOUTME: It covers EPD c9 and bracket results, CSV with a header, and error reporting.
+
+package tune
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSamples(t *testing.T) {
+	input := strings.Join([]string{
+		"# comment",
+		`rnbkqbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKQBNR w - - c9 "1/2-1/2";`,
+		"rnbkqbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBK1BNR b - - [0-1]",
+		"",
+		"4k3/8/8/8/3Q4/8/8/4K3 w - - [1.0]",
+	}, "\n")
+
+	samples, err := ReadSamples(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{FEN: "rnbkqbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKQBNR w - -", Result: 0.5},
+		{FEN: "rnbkqbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBK1BNR b - -", Result: 0},
+		{FEN: "4k3/8/8/8/3Q4/8/8/4K3 w - -", Result: 1},
+	}, samples)
+}
+
+func TestReadSamples_CSV(t *testing.T) {
+	input := "fen,result\n4k3/8/8/8/3Q4/8/8/4K3 w - - 0 1,1-0\n\"4k3/8/8/3q4/8/8/8/4K3 b - - 0 1\",0.5\n"
+
+	samples, err := ReadSamples(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 1.0, samples[0].Result)
+	assert.Equal(t, 0.5, samples[1].Result)
+}
+
+func TestReadSamples_Errors(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"no result", "4k3/8/8/8/3Q4/8/8/4K3 w - -\n", "line 1"},
+		{"bad result", "4k3/8/8/8/3Q4/8/8/4K3 w - - [2]\n", "invalid result"},
+		{"bad position", "# header\n4k3/8/8/8 w - - [1-0]\n", "line 2"},
+		{"bad CSV result after the first line", "4k3/8/8/8/3Q4/8/8/4K3 w,1-0\n4k3/8/8/8/3Q4/8/8/4K3 w,win\n", "line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadSamples(strings.NewReader(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
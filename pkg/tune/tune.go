@@ -0,0 +1,258 @@
+// ABOUTME: This file implements a Texel-style tuner for evaluation profiles.
+// ABOUTME: It fits piece values and STRATGY weights to game results by local search on a logistic error.
+
+package tune
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+)
+
+// counters are the STRATGY inputs GNMZ produces for a position: the
+// W registers for the side to move, the B registers for the opponent.
+// The other STRATGY inputs (WCAP0-2, PMOB, ...) are only set during the
+// search and are zero for a position scored on its own.
+type counters struct {
+	wmob, wmaxc, wcc  uint8
+	bmob, bmaxc, bmcc uint8
+	black             bool // Black to move
+}
+
+// Param is a tunable number of a profile.
+type Param struct {
+	Name  string
+	Field func(p *microchess.Profile) *uint8
+}
+
+// Params are the numbers the tuner adjusts. The king value is left alone:
+// it only decides what CHKCHK considers a king capture.
+var Params = []Param{
+	{"points.queen", func(p *microchess.Profile) *uint8 { return &p.Points.Queen }},
+	{"points.rook", func(p *microchess.Profile) *uint8 { return &p.Points.Rook }},
+	{"points.bishop", func(p *microchess.Profile) *uint8 { return &p.Points.Bishop }},
+	{"points.knight", func(p *microchess.Profile) *uint8 { return &p.Points.Knight }},
+	{"points.pawn", func(p *microchess.Profile) *uint8 { return &p.Points.Pawn }},
+	{"strategy.phase1_base", func(p *microchess.Profile) *uint8 { return &p.Strategy.Phase1Base }},
+	{"strategy.phase2_base", func(p *microchess.Profile) *uint8 { return &p.Strategy.Phase2Base }},
+	{"strategy.phase3_base", func(p *microchess.Profile) *uint8 { return &p.Strategy.Phase3Base }},
+	{"strategy.wcap0", func(p *microchess.Profile) *uint8 { return &p.Strategy.WCAP0 }},
+	{"strategy.bmaxc", func(p *microchess.Profile) *uint8 { return &p.Strategy.BMAXC }},
+	{"strategy.bmcc", func(p *microchess.Profile) *uint8 { return &p.Strategy.BMCC }},
+}
+
+// Tuner fits a profile to labelled positions.
+//
+// The evaluation of a position is STRATGY for the side to move minus
+// STRATGY for the opponent (like search.Symmetric), from white's point of
+// view. The expected result is sigmoid(K * eval), where K is fitted once
+// on the starting profile, and the error is the mean squared difference
+// with the game results. Each pass tries every parameter one step up and
+// down and keeps any change that lowers the error; tuning ends when a pass
+// changes nothing or after MaxPasses.
+//
+// The tuner switches the active profile while it runs (GNMZ reads POINTS)
+// and restores it before returning, so nothing else may evaluate
+// positions at the same time.
+type Tuner struct {
+	Samples   []Sample
+	Workers   int       // Goroutines computing GNMZ counters; 0 uses every CPU
+	MaxPasses int       // 0 means no limit
+	Log       io.Writer // Progress, one line per pass; nil is silent
+}
+
+// Change is a parameter changed by tuning.
+type Change struct {
+	Param    string
+	From, To uint8
+}
+
+// Report describes a tuning run.
+type Report struct {
+	Positions     int
+	K             float64
+	Before, After float64 // Mean squared error
+	Passes        int
+	Start, Tuned  microchess.Profile
+	Changes       []Change
+}
+
+// Run tunes start and returns the report, whose Tuned profile is the result.
+func (t *Tuner) Run(start microchess.Profile) (Report, error) {
+	if len(t.Samples) == 0 {
+		return Report{}, fmt.Errorf("no samples to tune on")
+	}
+	if err := start.Validate(); err != nil {
+		return Report{}, err
+	}
+	saved := microchess.ActiveProfile()
+	defer func() { _ = microchess.UseProfile(saved) }()
+
+	feats, err := t.counters(start)
+	if err != nil {
+		return Report{}, err
+	}
+	k := fitK(func(k float64) float64 { return t.meanSquaredError(start, feats, k) })
+	best := start
+	bestErr := t.meanSquaredError(best, feats, k)
+	report := Report{Positions: len(t.Samples), K: k, Before: bestErr, Start: start}
+
+	for improved := true; improved && (t.MaxPasses == 0 || report.Passes < t.MaxPasses); {
+		improved = false
+		report.Passes++
+		for _, param := range Params {
+			for _, delta := range []int{+1, -1} {
+				trial := best
+				v := int(*param.Field(&trial)) + delta
+				if v < 0 || v > 255 {
+					continue
+				}
+				*param.Field(&trial) = uint8(v)
+				if trial.Validate() != nil {
+					continue
+				}
+				trialFeats := feats
+				if trial.Points != best.Points {
+					if trialFeats, err = t.counters(trial); err != nil {
+						return Report{}, err
+					}
+				}
+				if e := t.meanSquaredError(trial, trialFeats, k); e < bestErr {
+					best, bestErr, feats = trial, e, trialFeats
+					improved = true
+					break
+				}
+			}
+		}
+		if t.Log != nil {
+			_, _ = fmt.Fprintf(t.Log, "pass %d: error %.6f\n", report.Passes, bestErr)
+		}
+	}
+
+	report.After = bestErr
+	report.Tuned = best
+	for _, param := range Params {
+		from, to := *param.Field(&start), *param.Field(&best)
+		if from != to {
+			report.Changes = append(report.Changes, Change{Param: param.Name, From: from, To: to})
+		}
+	}
+	return report, nil
+}
+
+// counters runs GNMZ on every sample with the piece values of p.
+func (t *Tuner) counters(p microchess.Profile) ([]counters, error) {
+	if err := microchess.UseProfile(p); err != nil {
+		return nil, err
+	}
+	feats := make([]counters, len(t.Samples))
+	workers := t.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			g := microchess.NewGame(io.Discard)
+			for i := w; i < len(t.Samples); i += workers {
+				if err := g.SetFEN(t.Samples[i].FEN); err != nil {
+					errs[w] = err
+					return
+				}
+				g.Evaluate()
+				feats[i] = counters{g.WMOB, g.WMAXC, g.WCC, g.BMOB, g.BMAXC, g.BMCC, g.IsReversed()}
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return feats, nil
+}
+
+// meanSquaredError returns the mean squared difference between the results
+// and the expected results under the STRATGY weights of p.
+func (t *Tuner) meanSquaredError(p microchess.Profile, feats []counters, k float64) float64 {
+	if err := microchess.UseProfile(p); err != nil {
+		return math.Inf(1)
+	}
+	g := microchess.NewGame(io.Discard)
+	sum := 0.0
+	for i, s := range t.Samples {
+		d := s.Result - sigmoid(k*eval(g, feats[i]))
+		sum += d * d
+	}
+	return sum / float64(len(t.Samples))
+}
+
+// eval scores the counters of a position with STRATGY for both sides,
+// from white's point of view. After REVERSE the W and B registers swap, so
+// the opponent's score needs no second GNMZ.
+func eval(g *microchess.GameState, c counters) float64 {
+	g.WMOB, g.WMAXC, g.WCC = c.wmob, c.wmaxc, c.wcc
+	g.BMOB, g.BMAXC, g.BMCC = c.bmob, c.bmaxc, c.bmcc
+	ours := int(g.STRATGY())
+	g.WMOB, g.WMAXC, g.WCC = c.bmob, c.bmaxc, c.bmcc
+	g.BMOB, g.BMAXC, g.BMCC = c.wmob, c.wmaxc, c.wcc
+	theirs := int(g.STRATGY())
+	if c.black {
+		return float64(theirs - ours)
+	}
+	return float64(ours - theirs)
+}
+
+// sigmoid maps an evaluation to an expected result, like the Elo formula.
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Pow(10, -x/400))
+}
+
+// fitK returns the scaling constant minimising errorAt, found by golden
+// section search on a logarithmic scale.
+func fitK(errorAt func(k float64) float64) float64 {
+	lo, hi := math.Log(0.001), math.Log(1000.0)
+	phi := (math.Sqrt(5) - 1) / 2
+	a, b := hi-phi*(hi-lo), lo+phi*(hi-lo)
+	ea, eb := errorAt(math.Exp(a)), errorAt(math.Exp(b))
+	for i := 0; i < 40; i++ {
+		if ea < eb {
+			hi, b, eb = b, a, ea
+			a = hi - phi*(hi-lo)
+			ea = errorAt(math.Exp(a))
+		} else {
+			lo, a, ea = a, b, eb
+			b = lo + phi*(hi-lo)
+			eb = errorAt(math.Exp(b))
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// WriteTo prints the report: the fitted K, the error before and after
+// tuning, and every parameter that changed.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	printf := func(format string, args ...any) {
+		m, _ := fmt.Fprintf(w, format, args...)
+		n += int64(m)
+	}
+	printf("Positions: %d\n", r.Positions)
+	printf("K:         %.4f\n", r.K)
+	printf("Error:     %.6f -> %.6f (%d passes)\n", r.Before, r.After, r.Passes)
+	if len(r.Changes) == 0 {
+		printf("No parameter changed\n")
+	}
+	for _, c := range r.Changes {
+		printf("  %-22s %3d -> %3d\n", c.Param, c.From, c.To)
+	}
+	return n, nil
+}
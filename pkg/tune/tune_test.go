@@ -0,0 +1,68 @@
+// ABOUTME: This file contains tests for the profile tuner.
+// ABOUTME: It checks that tuning never increases the error and leaves the active profile alone.
+
+package tune
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/matteo/microchess-go/pkg/microchess"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadSamples(t *testing.T) []Sample {
+	t.Helper()
+	f, err := os.Open("testdata/sample.epd")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	samples, err := ReadSamples(f)
+	require.NoError(t, err)
+	return samples
+}
+
+func TestTuner_Run(t *testing.T) {
+	var log bytes.Buffer
+	tuner := Tuner{Samples: loadSamples(t), Workers: 2, Log: &log}
+
+	report, err := tuner.Run(microchess.DefaultProfile())
+	require.NoError(t, err)
+
+	assert.Equal(t, 12, report.Positions)
+	assert.LessOrEqual(t, report.After, report.Before)
+	assert.NoError(t, report.Tuned.Validate())
+	assert.Equal(t, microchess.DefaultProfile(), report.Start)
+	assert.Contains(t, log.String(), "pass 1: error")
+	assert.Equal(t, microchess.DefaultProfile(), microchess.ActiveProfile(), "the active profile is restored")
+
+	// The report lists exactly the parameters that differ
+	for _, c := range report.Changes {
+		assert.NotEqual(t, c.From, c.To, c.Param)
+	}
+	var out bytes.Buffer
+	_, err = report.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Error:")
+}
+
+func TestTuner_IsDeterministic(t *testing.T) {
+	samples := loadSamples(t)
+	one, err := (&Tuner{Samples: samples, Workers: 1, MaxPasses: 2}).Run(microchess.DefaultProfile())
+	require.NoError(t, err)
+	many, err := (&Tuner{Samples: samples, Workers: 4, MaxPasses: 2}).Run(microchess.DefaultProfile())
+	require.NoError(t, err)
+	assert.Equal(t, one, many)
+}
+
+func TestTuner_NoSamples(t *testing.T) {
+	_, err := (&Tuner{}).Run(microchess.DefaultProfile())
+	assert.Error(t, err)
+}
+
+func TestFitK(t *testing.T) {
+	k := fitK(func(k float64) float64 { return (math.Log(k) - math.Log(3)) * (math.Log(k) - math.Log(3)) })
+	assert.InDelta(t, 3, k, 0.001)
+}